@@ -0,0 +1,349 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	ma "github.com/multiformats/go-multiaddr"
+	logging "github.com/op/go-logging"
+	"github.com/unitychain/zkvote-node/restapi/auth"
+	"gopkg.in/yaml.v2"
+)
+
+// ENV_PREFIX prefixes the environment variables overriding the configuration,
+// e.g. ZKVOTE_REST_ADDR overrides rest.addr and ZKVOTE_NETWORK_BOOTSTRAP_PEERS overrides network.bootstrapPeers.
+// The items of a list are separated by spaces.
+const ENV_PREFIX = "ZKVOTE_"
+
+// Roles of the process
+const (
+	ROLE_OPERATOR = "operator"
+	ROLE_NODE     = "node"
+)
+
+// REDACTED replaces the secrets when the configuration is printed
+const REDACTED = "<redacted>"
+
+// Config of an operator or a rollup node
+type Config struct {
+	Role            string        `yaml:"role"`
+	DataDir         string        `yaml:"dataDir"`
+	ShutdownTimeout time.Duration `yaml:"shutdownTimeout"` // of draining the requests and the syncs on SIGINT/SIGTERM
+	Network         Network       `yaml:"network"`
+	DHT             DHT           `yaml:"dht"`
+	Keys            Keys          `yaml:"keys"`
+	Sync            Sync          `yaml:"sync"`
+	REST            REST          `yaml:"rest"`
+	Log             Log           `yaml:"log"`
+}
+
+// Network ...
+type Network struct {
+	ListenAddrs      []string      `yaml:"listenAddrs"`    // libp2p defaults if empty
+	BootstrapPeers   []string      `yaml:"bootstrapPeers"` // multiaddrs with /p2p/ IDs
+	BootstrapTimeout time.Duration `yaml:"bootstrapTimeout"`
+	Relay            bool          `yaml:"relay"`
+	ConnLow          int           `yaml:"connLow"`
+	ConnHigh         int           `yaml:"connHigh"`
+	ConnGrace        time.Duration `yaml:"connGrace"`
+	MDNSInterval     time.Duration `yaml:"mdnsInterval"`
+}
+
+// DHT ...
+type DHT struct {
+	BucketSize int `yaml:"bucketSize"`
+}
+
+// Keys are the paths of the key material
+type Keys struct {
+	PrivateKeyFile        string `yaml:"privateKeyFile"` // generated and kept in the datastore if empty
+	VerificationKey       string `yaml:"verificationKey"`
+	RollupVerificationKey string `yaml:"rollupVerificationKey"`
+}
+
+// Sync ...
+type Sync struct {
+	SubjectInterval time.Duration `yaml:"subjectInterval"`
+	RequestTimeout  time.Duration `yaml:"requestTimeout"`
+}
+
+// REST ...
+type REST struct {
+	Addr          string   `yaml:"addr"`
+	CORSOrigins   []string `yaml:"corsOrigins"` // none if empty, * for all
+	TLSCert       string   `yaml:"tlsCert"`
+	TLSKey        string   `yaml:"tlsKey"`
+	TLSClientCA   string   `yaml:"tlsClientCA"`
+	APIKeys       []string `yaml:"apiKeys"` // name:role:key
+	JWTSecret     string   `yaml:"jwtSecret"`
+	ClientCerts   []string `yaml:"clientCerts"` // commonName=role
+	AnonymousRole string   `yaml:"anonymousRole"`
+	Insecure      bool     `yaml:"insecure"` // serve without authentication if there is no other way
+	Webhooks      []string `yaml:"webhooks"`
+	WebhookSecret string   `yaml:"webhookSecret"`
+}
+
+// Log ...
+type Log struct {
+	File  string `yaml:"file"`
+	Level string `yaml:"level"`
+}
+
+// Default returns the configuration used without a file, environment variables or flags
+func Default() *Config {
+	return &Config{
+		Role:            ROLE_OPERATOR,
+		DataDir:         "data/node_data",
+		ShutdownTimeout: 30 * time.Second,
+		Network: Network{
+			BootstrapTimeout: 30 * time.Second,
+			ConnLow:          1500,
+			ConnHigh:         2000,
+			ConnGrace:        time.Minute,
+			MDNSInterval:     5 * time.Second,
+		},
+		DHT: DHT{BucketSize: 1},
+		Keys: Keys{
+			VerificationKey:       "./snark/verification_key.json",
+			RollupVerificationKey: "./snark/rollup_verification_key.json",
+		},
+		Sync: Sync{
+			SubjectInterval: 60 * time.Second,
+			RequestTimeout:  30 * time.Second,
+		},
+		REST: REST{Addr: ":9900"},
+		Log:  Log{File: "logs.log", Level: "DEBUG"},
+	}
+}
+
+// Load returns the defaults overridden by the YAML file if a path is given, and then by the environment variables
+func Load(path string) (*Config, error) {
+	c := Default()
+	if 0 != len(path) {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		err = yaml.UnmarshalStrict(data, c)
+		if err != nil {
+			return nil, fmt.Errorf("invalid config file %v, %v", path, err)
+		}
+	}
+	err := c.applyEnv(os.LookupEnv)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Validate returns the first invalid setting
+func (c *Config) Validate() error {
+	if ROLE_OPERATOR != c.Role && ROLE_NODE != c.Role {
+		return fmt.Errorf("role must be %v or %v", ROLE_OPERATOR, ROLE_NODE)
+	}
+	if 0 == len(c.DataDir) {
+		return fmt.Errorf("dataDir is empty")
+	}
+
+	for _, a := range c.Network.ListenAddrs {
+		if _, err := ma.NewMultiaddr(a); err != nil {
+			return fmt.Errorf("invalid network.listenAddrs %v, %v", a, err)
+		}
+	}
+	if _, err := c.BootstrapPeers(); err != nil {
+		return err
+	}
+	if 0 >= c.Network.ConnLow || c.Network.ConnLow > c.Network.ConnHigh {
+		return fmt.Errorf("network.connLow must be positive and not above network.connHigh")
+	}
+	if 0 >= c.DHT.BucketSize {
+		return fmt.Errorf("dht.bucketSize must be positive")
+	}
+	for name, d := range map[string]time.Duration{
+		"shutdownTimeout":          c.ShutdownTimeout,
+		"network.bootstrapTimeout": c.Network.BootstrapTimeout,
+		"network.connGrace":        c.Network.ConnGrace,
+		"network.mdnsInterval":     c.Network.MDNSInterval,
+		"sync.subjectInterval":     c.Sync.SubjectInterval,
+		"sync.requestTimeout":      c.Sync.RequestTimeout,
+	} {
+		if 0 >= d {
+			return fmt.Errorf("%v must be positive", name)
+		}
+	}
+
+	if ROLE_OPERATOR == c.Role && 0 == len(c.Keys.VerificationKey) {
+		return fmt.Errorf("keys.verificationKey is empty")
+	}
+	if _, _, err := net.SplitHostPort(c.REST.Addr); err != nil {
+		return fmt.Errorf("invalid rest.addr %v, %v", c.REST.Addr, err)
+	}
+	if (0 == len(c.REST.TLSCert)) != (0 == len(c.REST.TLSKey)) {
+		return fmt.Errorf("rest.tlsCert and rest.tlsKey must be given together")
+	}
+	if 0 != len(c.REST.TLSClientCA) && 0 == len(c.REST.TLSCert) {
+		return fmt.Errorf("rest.tlsClientCA requires rest.tlsCert")
+	}
+	for _, k := range c.REST.APIKeys {
+		parts := strings.SplitN(k, ":", 3)
+		if 3 != len(parts) || 0 == len(parts[2]) {
+			return fmt.Errorf("rest.apiKeys must be name:role:key")
+		}
+		if _, err := auth.ParseRole(parts[1]); err != nil {
+			return fmt.Errorf("invalid rest.apiKeys, %v", err)
+		}
+	}
+	for _, cc := range c.REST.ClientCerts {
+		i := strings.LastIndex(cc, "=")
+		if 0 >= i {
+			return fmt.Errorf("rest.clientCerts must be commonName=role, %v", cc)
+		}
+		if _, err := auth.ParseRole(cc[i+1:]); err != nil {
+			return fmt.Errorf("invalid rest.clientCerts, %v", err)
+		}
+	}
+	if 0 != len(c.REST.AnonymousRole) {
+		if _, err := auth.ParseRole(c.REST.AnonymousRole); err != nil {
+			return fmt.Errorf("invalid rest.anonymousRole, %v", err)
+		}
+	}
+	authenticated := 0 != len(c.REST.APIKeys) || 0 != len(c.REST.JWTSecret) || 0 != len(c.REST.ClientCerts)
+	if !authenticated && 0 == len(c.REST.AnonymousRole) && !c.REST.Insecure {
+		return fmt.Errorf("rest requires apiKeys, jwtSecret, clientCerts or anonymousRole, or insecure to serve without authentication")
+	}
+
+	if _, err := logging.LogLevel(c.Log.Level); err != nil {
+		return fmt.Errorf("invalid log.level %v", c.Log.Level)
+	}
+	return nil
+}
+
+// BootstrapPeers returns the parsed network.bootstrapPeers
+func (c *Config) BootstrapPeers() ([]ma.Multiaddr, error) {
+	peers := make([]ma.Multiaddr, 0, len(c.Network.BootstrapPeers))
+	for _, s := range c.Network.BootstrapPeers {
+		a, err := ma.NewMultiaddr(s)
+		if err == nil {
+			_, err = peer.AddrInfoFromP2pAddr(a)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid network.bootstrapPeers %v, %v", s, err)
+		}
+		peers = append(peers, a)
+	}
+	return peers, nil
+}
+
+// String returns the configuration in YAML with the secrets redacted
+func (c *Config) String() string {
+	redacted := *c
+	redacted.REST.APIKeys = make([]string, len(c.REST.APIKeys))
+	for i, k := range c.REST.APIKeys {
+		// Keep the name and the role
+		parts := strings.SplitN(k, ":", 3)
+		redacted.REST.APIKeys[i] = strings.Join(append(parts[:len(parts)-1], REDACTED), ":")
+	}
+	if 0 != len(c.REST.JWTSecret) {
+		redacted.REST.JWTSecret = REDACTED
+	}
+	if 0 != len(c.REST.WebhookSecret) {
+		redacted.REST.WebhookSecret = REDACTED
+	}
+
+	data, err := yaml.Marshal(&redacted)
+	if err != nil {
+		return err.Error()
+	}
+	return string(data)
+}
+
+//
+// internal functions
+//
+
+// applyEnv sets the fields whose variables are defined
+func (c *Config) applyEnv(lookup func(string) (string, bool)) error {
+	return walk(reflect.ValueOf(c).Elem(), ENV_PREFIX, func(name string, v reflect.Value) error {
+		s, ok := lookup(name)
+		if !ok {
+			return nil
+		}
+		err := setValue(v, s)
+		if err != nil {
+			return fmt.Errorf("invalid %v, %v", name, err)
+		}
+		return nil
+	})
+}
+
+// walk calls fn with the environment variable name of each field
+func walk(v reflect.Value, prefix string, fn func(name string, v reflect.Value) error) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name := prefix + envName(strings.Split(t.Field(i).Tag.Get("yaml"), ",")[0])
+		f := v.Field(i)
+		if reflect.Struct == f.Kind() {
+			err := walk(f, name+"_", fn)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		err := fn(name, f)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// envName converts a YAML key to upper snake case, e.g. bootstrapPeers to BOOTSTRAP_PEERS and tlsClientCA to TLS_CLIENT_CA
+func envName(key string) string {
+	var b strings.Builder
+	runes := []rune(key)
+	for i, r := range runes {
+		if 0 < i && unicode.IsUpper(r) && (unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+			b.WriteRune('_')
+		}
+		b.WriteRune(unicode.ToUpper(r))
+	}
+	return b.String()
+}
+
+func setValue(v reflect.Value, s string) error {
+	switch v.Interface().(type) {
+	case string:
+		v.SetString(s)
+	case bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case int:
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(n))
+	case time.Duration:
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+	case []string:
+		v.Set(reflect.ValueOf(strings.Fields(s)))
+	default:
+		return fmt.Errorf("unsupported type %v", v.Type())
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "zkvote.yaml")
+	assert.Nil(t, ioutil.WriteFile(path, []byte(`
+role: node
+network:
+  listenAddrs: [/ip4/0.0.0.0/tcp/4001]
+  connGrace: 2m
+sync:
+  requestTimeout: 10s
+rest:
+  jwtSecret: s3cret
+`), 0644))
+
+	os.Setenv("ZKVOTE_REST_ADDR", "127.0.0.1:8080")
+	os.Setenv("ZKVOTE_NETWORK_LISTEN_ADDRS", "/ip4/0.0.0.0/tcp/4002 /ip6/::/tcp/4002")
+	defer os.Unsetenv("ZKVOTE_REST_ADDR")
+	defer os.Unsetenv("ZKVOTE_NETWORK_LISTEN_ADDRS")
+
+	c, err := Load(path)
+	assert.Nil(t, err)
+	assert.Nil(t, c.Validate())
+	assert.Equal(t, ROLE_NODE, c.Role)
+	assert.Equal(t, 2*time.Minute, c.Network.ConnGrace)
+	assert.Equal(t, 10*time.Second, c.Sync.RequestTimeout)
+	assert.Equal(t, "127.0.0.1:8080", c.REST.Addr)
+	assert.Equal(t, []string{"/ip4/0.0.0.0/tcp/4002", "/ip6/::/tcp/4002"}, c.Network.ListenAddrs)
+	// Defaults are kept
+	assert.Equal(t, 1500, c.Network.ConnLow)
+
+	// Unknown keys are rejected
+	assert.Nil(t, ioutil.WriteFile(path, []byte("rest:\n  port: 1\n"), 0644))
+	_, err = Load(path)
+	assert.NotNil(t, err)
+}
+
+func TestEnvName(t *testing.T) {
+	assert.Equal(t, "BOOTSTRAP_PEERS", envName("bootstrapPeers"))
+	assert.Equal(t, "TLS_CLIENT_CA", envName("tlsClientCA"))
+	assert.Equal(t, "DATA_DIR", envName("dataDir"))
+
+	c := Default()
+	env := map[string]string{"ZKVOTE_DHT_BUCKET_SIZE": "x"}
+	err := c.applyEnv(func(name string) (string, bool) {
+		v, ok := env[name]
+		return v, ok
+	})
+	assert.NotNil(t, err)
+}
+
+func TestValidate(t *testing.T) {
+	// The REST API isn't served without authentication by default
+	assert.NotNil(t, Default().Validate())
+	valid := func() *Config {
+		c := Default()
+		c.REST.AnonymousRole = "read-only"
+		return c
+	}
+	assert.Nil(t, valid().Validate())
+	insecure := Default()
+	insecure.REST.Insecure = true
+	assert.Nil(t, insecure.Validate())
+
+	for _, modify := range []func(c *Config){
+		func(c *Config) { c.Role = "relay" },
+		func(c *Config) { c.Network.BootstrapPeers = []string{"/ip4/1.2.3.4/tcp/4001"} },
+		func(c *Config) { c.Network.ConnLow = c.Network.ConnHigh + 1 },
+		func(c *Config) { c.DHT.BucketSize = 0 },
+		func(c *Config) { c.Sync.RequestTimeout = 0 },
+		func(c *Config) { c.ShutdownTimeout = 0 },
+		func(c *Config) { c.REST.Addr = "9900" },
+		func(c *Config) { c.REST.TLSCert = "cert.pem" },
+		func(c *Config) { c.REST.APIKeys = []string{"ops:root:key"} },
+		func(c *Config) { c.Log.Level = "LOUD" },
+	} {
+		c := valid()
+		modify(c)
+		assert.NotNil(t, c.Validate())
+	}
+}
+
+func TestString(t *testing.T) {
+	c := Default()
+	c.REST.APIKeys = []string{"ops:admin:s3cret"}
+	c.REST.JWTSecret = "s3cret"
+	s := c.String()
+	assert.NotContains(t, s, "s3cret")
+	assert.Contains(t, s, "ops:admin:"+REDACTED)
+	// The configuration itself is untouched
+	assert.Equal(t, "ops:admin:s3cret", c.REST.APIKeys[0])
+}
@@ -4,69 +4,339 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"io"
+	"os"
+	"os/signal"
 	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
 	levelds "github.com/ipfs/go-ds-leveldb"
+	"github.com/unitychain/zkvote-node/config"
 	"github.com/unitychain/zkvote-node/restapi"
+	"github.com/unitychain/zkvote-node/restapi/auth"
 	"github.com/unitychain/zkvote-node/zkvote/common/utils"
 	"github.com/unitychain/zkvote-node/zkvote/node"
 	zkvote "github.com/unitychain/zkvote-node/zkvote/operator"
+	"github.com/unitychain/zkvote-node/zkvote/operator/service/manager"
 )
 
 func main() {
-	path := flag.String("db", "node_data", "Database folder")
+	configPath := flag.String("config", "", "YAML configuration file, overridden by the ZKVOTE_ environment variables and the flags")
+	printConfig := flag.Bool("print-config", false, "Print the effective configuration and exit")
+	path := flag.String("db", "node_data", "Database folder under data/")
 	serverPort := flag.Int("p", 9900, "Web UI port")
 	cmds := flag.Bool("cmds", false, "Interactive commands")
 	type_operator := flag.Bool("op", true, "activate as an operator")
 	type_node := flag.Bool("n", false, "activate as a node")
+	auditPath := flag.String("audit", "", "Audit an exported subject archive offline and print the signed report")
+	var listenAddrs, bootstrapPeers stringsFlag
+	flag.Var(&listenAddrs, "listen", "Multiaddr to listen on, repeatable")
+	flag.Var(&bootstrapPeers, "bootstrap", "Multiaddr of a bootstrap peer with its /p2p/ ID, repeatable")
+	relay := flag.Bool("relay", false, "Relay the connections of other peers")
+	bucketSize := flag.Int("bucket-size", 1, "Bucket size of the DHT routing table")
+	keyFile := flag.String("key-file", "", "File of the hex encoded ECDSA key of the peer")
+	logLevel := flag.String("log-level", "DEBUG", "Log level, DEBUG, INFO, NOTICE, WARNING, ERROR or CRITICAL")
+	var webhookURLs stringsFlag
+	flag.Var(&webhookURLs, "webhook", "Webhook URL notified of events, optionally prefixed with event types as \"type,type=url\", repeatable")
+	webhookSecret := flag.String("webhook-secret", "", "Secret signing the webhook payloads with HMAC-SHA256")
+	var apiKeys, clientCerts, corsOrigins stringsFlag
+	flag.Var(&apiKeys, "api-key", "API key of a client as \"name:role:key\", role is admin, proposer, voter or read-only, repeatable")
+	jwtSecret := flag.String("jwt-secret", "", "Secret verifying the HS256 bearer tokens of clients")
+	flag.Var(&clientCerts, "client-cert", "Role of a client certificate as \"commonName=role\", repeatable")
+	anonymousRole := flag.String("anonymous-role", "", "Role of the clients without credentials, none if empty")
+	insecure := flag.Bool("insecure", false, "Serve without authentication if there is neither a credential nor an anonymous role, every client is an admin")
+	flag.Var(&corsOrigins, "cors-origin", "Origin allowed to make cross-origin requests, none if none is given and * for all, repeatable")
+	tlsCert := flag.String("tls-cert", "", "Certificate file to serve HTTPS")
+	tlsKey := flag.String("tls-key", "", "Key file of the certificate")
+	tlsClientCA := flag.String("tls-client-ca", "", "CA file verifying the client certificates")
 	flag.Parse()
 
-	utils.OpenLog()
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		panic(err)
+	}
+	// The flags given explicitly override the file and the environment
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "db":
+			cfg.DataDir = "data/" + *path
+		case "p":
+			cfg.REST.Addr = ":" + strconv.Itoa(*serverPort)
+		case "op":
+			if *type_operator && !*type_node {
+				cfg.Role = config.ROLE_OPERATOR
+			}
+		case "n":
+			if *type_node {
+				cfg.Role = config.ROLE_NODE
+			}
+		case "listen":
+			cfg.Network.ListenAddrs = listenAddrs
+		case "bootstrap":
+			cfg.Network.BootstrapPeers = bootstrapPeers
+		case "relay":
+			cfg.Network.Relay = *relay
+		case "bucket-size":
+			cfg.DHT.BucketSize = *bucketSize
+		case "key-file":
+			cfg.Keys.PrivateKeyFile = *keyFile
+		case "log-level":
+			cfg.Log.Level = *logLevel
+		case "webhook":
+			cfg.REST.Webhooks = webhookURLs
+		case "webhook-secret":
+			cfg.REST.WebhookSecret = *webhookSecret
+		case "api-key":
+			cfg.REST.APIKeys = apiKeys
+		case "jwt-secret":
+			cfg.REST.JWTSecret = *jwtSecret
+		case "client-cert":
+			cfg.REST.ClientCerts = clientCerts
+		case "anonymous-role":
+			cfg.REST.AnonymousRole = *anonymousRole
+		case "insecure":
+			cfg.REST.Insecure = *insecure
+		case "cors-origin":
+			cfg.REST.CORSOrigins = corsOrigins
+		case "tls-cert":
+			cfg.REST.TLSCert = *tlsCert
+		case "tls-key":
+			cfg.REST.TLSKey = *tlsKey
+		case "tls-client-ca":
+			cfg.REST.TLSClientCA = *tlsClientCA
+		}
+	})
+	err = cfg.Validate()
+	if err != nil {
+		fmt.Printf("Invalid configuration, %v\n", err)
+		os.Exit(2)
+	}
+	if *printConfig {
+		fmt.Print(cfg)
+		return
+	}
+
+	err = utils.OpenLogFile(cfg.Log.File, cfg.Log.Level)
+	if err != nil {
+		panic(err)
+	}
 	utils.LogInfo("======================")
 	utils.LogInfo("===== Node Start =====")
 	utils.LogInfo("======================")
+	utils.LogInfof("Configuration:\n%v", cfg)
 
-	// ~~ 0c. Note that contexts are an ugly way of controlling component
-	// lifecycles. Talk about the service-based host refactor.
+	// The services are stopped explicitly on SIGINT/SIGTERM, ctx is cancelled after them
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
 
-	// Set default values
-	if *path == "" {
-		*path = "node_data"
+	ds, err := levelds.NewDatastore(cfg.DataDir, nil)
+	if err != nil {
+		panic(err)
 	}
-	*path = "data/" + *path
 
-	relay := false
-	bucketSize := 1
-	ds, err := levelds.NewDatastore(*path, nil)
+	if 0 != len(*auditPath) {
+		report, err := zkvote.AuditArchive(ds, *auditPath,
+			zkvote.WithPrivateKeyFile(cfg.Keys.PrivateKeyFile), zkvote.WithVerificationKey(cfg.Keys.VerificationKey))
+		if err != nil {
+			panic(err)
+		}
+		data, err := report.Byte()
+		if err != nil {
+			panic(err)
+		}
+		fmt.Println(string(data))
+		return
+	}
+
+	seeds, _ := cfg.BootstrapPeers()
+	serverOpts, err := securityOpts(cfg.REST.APIKeys, cfg.REST.JWTSecret, cfg.REST.ClientCerts, cfg.REST.AnonymousRole)
 	if err != nil {
 		panic(err)
 	}
+	if cfg.REST.Insecure {
+		serverOpts = append(serverOpts, restapi.WithInsecure())
+	}
+	serverOpts = append(serverOpts,
+		restapi.WithCORSOrigins(cfg.REST.CORSOrigins...),
+		restapi.WithTLS(cfg.REST.TLSCert, cfg.REST.TLSKey, cfg.REST.TLSClientCA))
+	if config.ROLE_NODE == cfg.Role {
+		n, err := node.NewNode(ctx, ds,
+			node.WithListenAddrs(cfg.Network.ListenAddrs...),
+			node.WithBucketSize(cfg.DHT.BucketSize),
+			node.WithConnLimits(cfg.Network.ConnLow, cfg.Network.ConnHigh, cfg.Network.ConnGrace),
+			node.WithBootstrapTimeout(cfg.Network.BootstrapTimeout),
+			node.WithVerificationKey(cfg.Keys.RollupVerificationKey),
+			node.WithPrivateKeyFile(cfg.Keys.PrivateKeyFile))
+		if err != nil {
+			panic(err)
+		}
+		n.Info()
+		if 0 != len(seeds) {
+			go func() {
+				err := n.DHTBootstrap(seeds...)
+				if err != nil {
+					utils.LogErrorf("DHT bootstrap error, %v", err)
+				}
+			}()
+		}
 
-	if *type_node {
-		n := node.NewNode(ctx, ds, bucketSize)
-		_ = n
-	} else if *type_operator {
-		serverAddr := ":" + strconv.Itoa(*serverPort)
+		server, err := restapi.NewNodeServer(n, cfg.REST.Addr, serverOpts...)
+		if err != nil {
+			panic(err)
+		}
 
-		op, err := zkvote.NewOperator(ctx, ds, relay, bucketSize)
+		go func() {
+			err := server.ListenAndServe()
+			if err != nil {
+				utils.LogErrorf("HTTP server error, %v", err)
+			}
+		}()
+		fmt.Printf("HTTP server listens to %v\n", cfg.REST.Addr)
+
+		sig := <-signals
+		utils.LogInfof("Received %v, shutting down", sig)
+		shutdown(cfg.ShutdownTimeout, server, n.Stop, ds)
+	} else {
+		op, err := zkvote.NewOperator(ctx, ds,
+			zkvote.WithListenAddrs(cfg.Network.ListenAddrs...),
+			zkvote.WithRelay(cfg.Network.Relay),
+			zkvote.WithBucketSize(cfg.DHT.BucketSize),
+			zkvote.WithConnLimits(cfg.Network.ConnLow, cfg.Network.ConnHigh, cfg.Network.ConnGrace),
+			zkvote.WithMDNSInterval(cfg.Network.MDNSInterval),
+			zkvote.WithBootstrapTimeout(cfg.Network.BootstrapTimeout),
+			zkvote.WithVerificationKey(cfg.Keys.VerificationKey),
+			zkvote.WithPrivateKeyFile(cfg.Keys.PrivateKeyFile),
+			zkvote.WithManagerOpts(
+				manager.WithSyncInterval(cfg.Sync.SubjectInterval),
+				manager.WithSyncTimeout(cfg.Sync.RequestTimeout)))
 		if err != nil {
 			panic(err)
 		}
+		op.Start()
+		go func() {
+			err := op.DHTBootstrap(seeds...)
+			if err != nil {
+				utils.LogErrorf("DHT bootstrap error, %v", err)
+			}
+		}()
 
-		server, err := restapi.NewServer(op, serverAddr)
+		server, err := restapi.NewServer(op, cfg.REST.Addr, append(serverOpts,
+			restapi.WithWebhookURLs(cfg.REST.Webhooks...),
+			restapi.WithWebhookSecret(cfg.REST.WebhookSecret))...)
 		if err != nil {
 			panic(err)
 		}
 
-		go server.ListenAndServe()
-		fmt.Printf("HTTP server listens to port %d\n", *serverPort)
+		go func() {
+			err := server.ListenAndServe()
+			if err != nil {
+				utils.LogErrorf("HTTP server error, %v", err)
+			}
+		}()
+		fmt.Printf("HTTP server listens to %v\n", cfg.REST.Addr)
 
+		// Quitting the interactive commands shuts down as well
+		quit := make(chan error, 1)
 		if *cmds {
-			op.Run()
-		} else {
-			select {}
+			go func() {
+				quit <- op.Run()
+			}()
+		}
+		select {
+		case sig := <-signals:
+			utils.LogInfof("Received %v, shutting down", sig)
+		case err := <-quit:
+			utils.LogInfof("Commands quit, %v, shutting down", err)
+		}
+		shutdown(cfg.ShutdownTimeout, server, op.Stop, ds)
+	}
+}
+
+// shutdown stops the server first so that no request changes the state being saved,
+// then the services, and finally closes the datastore to flush it
+func shutdown(timeout time.Duration, server *restapi.Server, stop func(ctx context.Context) error, ds io.Closer) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	err := server.Shutdown(ctx)
+	if err != nil {
+		utils.LogWarningf("HTTP server shutdown error, %v", err)
+	}
+	err = stop(ctx)
+	if err != nil {
+		utils.LogWarningf("Stop error, %v", err)
+	}
+	err = ds.Close()
+	if err != nil {
+		utils.LogErrorf("Close datastore error, %v", err)
+	}
+	utils.LogInfo("===== Node Stop =====")
+	utils.CloseLog()
+}
+
+// securityOpts returns the authentication options of the REST server
+func securityOpts(apiKeys []string, jwtSecret string, clientCerts []string, anonymousRole string) ([]restapi.Opt, error) {
+	authenticators := make([]auth.Authenticator, 0)
+	if 0 != len(clientCerts) {
+		certs := auth.NewClientCerts()
+		for _, c := range clientCerts {
+			i := strings.LastIndex(c, "=")
+			if 0 > i {
+				return nil, fmt.Errorf("invalid client certificate role, %v", c)
+			}
+			role, err := auth.ParseRole(c[i+1:])
+			if err != nil {
+				return nil, err
+			}
+			certs.Add(c[:i], role)
+		}
+		authenticators = append(authenticators, certs)
+	}
+	if 0 != len(apiKeys) {
+		keys := auth.NewAPIKeys()
+		for _, k := range apiKeys {
+			parts := strings.SplitN(k, ":", 3)
+			if 3 != len(parts) {
+				return nil, fmt.Errorf("API key must be name:role:key")
+			}
+			role, err := auth.ParseRole(parts[1])
+			if err != nil {
+				return nil, err
+			}
+			err = keys.Add(parts[2], parts[0], role)
+			if err != nil {
+				return nil, err
+			}
+		}
+		authenticators = append(authenticators, keys)
+	}
+	if 0 != len(jwtSecret) {
+		authenticators = append(authenticators, auth.NewJWT(jwtSecret))
+	}
+
+	opts := []restapi.Opt{restapi.WithAuthenticators(authenticators...)}
+	if 0 != len(anonymousRole) {
+		role, err := auth.ParseRole(anonymousRole)
+		if err != nil {
+			return nil, err
 		}
+		opts = append(opts, restapi.WithAnonymousRole(role))
 	}
+	return opts, nil
+}
+
+// stringsFlag is a flag which can be given multiple times
+type stringsFlag []string
+
+func (f *stringsFlag) String() string {
+	return strings.Join(*f, " ")
+}
+
+func (f *stringsFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
 }
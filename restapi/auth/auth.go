@@ -0,0 +1,120 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// Role of a principal, a role is granted the permissions of the lower ones
+type Role string
+
+// Roles from the lowest to the highest
+const (
+	RolePublic   Role = ""
+	RoleReadOnly Role = "read-only"
+	RoleVoter    Role = "voter"
+	RoleProposer Role = "proposer"
+	RoleAdmin    Role = "admin"
+)
+
+var levels = map[Role]int{
+	RolePublic:   0,
+	RoleReadOnly: 1,
+	RoleVoter:    2,
+	RoleProposer: 3,
+	RoleAdmin:    4,
+}
+
+// ParseRole ...
+func ParseRole(s string) (Role, error) {
+	r := Role(s)
+	if _, ok := levels[r]; !ok || RolePublic == r {
+		return RolePublic, fmt.Errorf("unknown role %v", s)
+	}
+	return r, nil
+}
+
+// Allows returns true if the role is granted the permissions of the required role
+func (r Role) Allows(required Role) bool {
+	level, ok := levels[r]
+	return ok && level >= levels[required]
+}
+
+// DefaultRole is required by a handler without a role, read-only for GET and admin otherwise
+func DefaultRole(method string) Role {
+	if http.MethodGet == method || http.MethodHead == method {
+		return RoleReadOnly
+	}
+	return RoleAdmin
+}
+
+// Principal is an authenticated client
+type Principal struct {
+	Name   string `json:"name"`
+	Role   Role   `json:"role"`
+	Method string `json:"method"` // the authenticator
+}
+
+// Authenticator identifies the client of a request.
+// It returns nil without error if the request carries no credentials of its kind.
+type Authenticator interface {
+	Authenticate(req *http.Request) (*Principal, error)
+}
+
+// ErrUnauthenticated means the request carries no valid credentials
+var ErrUnauthenticated = fmt.Errorf("authentication required")
+
+type principalKey struct{}
+
+// NewContext returns a context carrying the principal
+func NewContext(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// FromContext returns the principal of a request, nil if authentication is disabled
+func FromContext(ctx context.Context) *Principal {
+	p, _ := ctx.Value(principalKey{}).(*Principal)
+	return p
+}
+
+// Authenticate tries the authenticators in order, the anonymous role is given to a request without credentials.
+// Invalid credentials are rejected instead of falling back to the anonymous role.
+func Authenticate(authenticators []Authenticator, anonymous Role, req *http.Request) (*Principal, error) {
+	for _, a := range authenticators {
+		p, err := a.Authenticate(req)
+		if err != nil {
+			return nil, err
+		}
+		if nil != p {
+			return p, nil
+		}
+	}
+	if RolePublic != anonymous {
+		return &Principal{Name: "anonymous", Role: anonymous}, nil
+	}
+	return nil, ErrUnauthenticated
+}
+
+// Authorize wraps a handler which requires the role.
+// A public handler is served without authentication.
+func Authorize(authenticators []Authenticator, anonymous Role, required Role, next http.HandlerFunc) http.HandlerFunc {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		if RolePublic == required {
+			next(rw, req)
+			return
+		}
+
+		p, err := Authenticate(authenticators, anonymous, req)
+		if err != nil {
+			rw.Header().Set("WWW-Authenticate", `Bearer realm="zkvote"`)
+			http.Error(rw, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		if !p.Role.Allows(required) {
+			http.Error(rw, fmt.Sprintf("%v is not allowed, %v role is required", p.Name, required), http.StatusForbidden)
+			return
+		}
+		next(rw, req.WithContext(NewContext(req.Context(), p)))
+	}
+}
@@ -0,0 +1,119 @@
+package auth
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIKeys(t *testing.T) {
+	keys := NewAPIKeys()
+	assert.NotNil(t, keys.Add("", "empty", RoleAdmin))
+	assert.Nil(t, keys.Add("secret", "alice", RoleVoter))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	p, err := keys.Authenticate(req)
+	assert.Nil(t, err)
+	assert.Nil(t, p)
+
+	req.Header.Set(HEADER_API_KEY, "secret")
+	p, err = keys.Authenticate(req)
+	assert.Nil(t, err)
+	assert.Equal(t, "alice", p.Name)
+	assert.Equal(t, RoleVoter, p.Role)
+
+	req.Header.Set(HEADER_API_KEY, "wrong")
+	_, err = keys.Authenticate(req)
+	assert.NotNil(t, err)
+}
+
+func TestJWT(t *testing.T) {
+	j := NewJWT("secret")
+	j.now = func() time.Time { return time.Unix(1000, 0) }
+
+	token, err := j.Issue("bob", RoleProposer, 2000)
+	assert.Nil(t, err)
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	p, err := j.Authenticate(req)
+	assert.Nil(t, err)
+	assert.Equal(t, "bob", p.Name)
+	assert.Equal(t, RoleProposer, p.Role)
+
+	// Expired
+	j.now = func() time.Time { return time.Unix(2000, 0) }
+	_, err = j.Authenticate(req)
+	assert.NotNil(t, err)
+
+	// Signed with another secret
+	token, err = NewJWT("other").Issue("bob", RoleAdmin, 0)
+	assert.Nil(t, err)
+	req.Header.Set("Authorization", "Bearer "+token)
+	_, err = j.Authenticate(req)
+	assert.NotNil(t, err)
+
+	// Unsigned
+	header, _ := encodeSegment(&jwtHeader{Alg: "none"})
+	claims, _ := encodeSegment(&jwtClaims{Sub: "bob", Role: string(RoleAdmin)})
+	req.Header.Set("Authorization", "Bearer "+header+"."+claims+".")
+	_, err = j.Authenticate(req)
+	assert.NotNil(t, err)
+}
+
+func TestClientCerts(t *testing.T) {
+	certs := NewClientCerts()
+	certs.Add("carol", RoleAdmin)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	p, err := certs.Authenticate(req)
+	assert.Nil(t, err)
+	assert.Nil(t, p)
+
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: "carol"}}
+	req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+	p, err = certs.Authenticate(req)
+	assert.Nil(t, err)
+	assert.Equal(t, RoleAdmin, p.Role)
+
+	cert.Subject.CommonName = "mallory"
+	_, err = certs.Authenticate(req)
+	assert.NotNil(t, err)
+}
+
+func TestAuthorize(t *testing.T) {
+	keys := NewAPIKeys()
+	keys.Add("voter-key", "voter", RoleVoter)
+	keys.Add("admin-key", "admin", RoleAdmin)
+	authenticators := []Authenticator{keys}
+
+	var principal *Principal
+	next := func(rw http.ResponseWriter, req *http.Request) {
+		principal = FromContext(req.Context())
+	}
+	serve := func(anonymous Role, required Role, key string) int {
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		if 0 != len(key) {
+			req.Header.Set(HEADER_API_KEY, key)
+		}
+		rw := httptest.NewRecorder()
+		Authorize(authenticators, anonymous, required, next)(rw, req)
+		return rw.Code
+	}
+
+	assert.Equal(t, http.StatusOK, serve(RolePublic, RolePublic, ""))
+	assert.Equal(t, http.StatusUnauthorized, serve(RolePublic, RoleReadOnly, ""))
+	assert.Equal(t, http.StatusUnauthorized, serve(RoleReadOnly, RoleReadOnly, "wrong"))
+	assert.Equal(t, http.StatusOK, serve(RoleReadOnly, RoleReadOnly, ""))
+	assert.Equal(t, "anonymous", principal.Name)
+	assert.Equal(t, http.StatusForbidden, serve(RoleReadOnly, RoleVoter, ""))
+	assert.Equal(t, http.StatusOK, serve(RolePublic, RoleVoter, "voter-key"))
+	assert.Equal(t, http.StatusForbidden, serve(RolePublic, RoleProposer, "voter-key"))
+	assert.Equal(t, http.StatusOK, serve(RolePublic, RoleProposer, "admin-key"))
+	assert.Equal(t, "admin", principal.Name)
+}
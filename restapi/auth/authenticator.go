@@ -0,0 +1,176 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// HEADER_API_KEY carries a static API key
+const HEADER_API_KEY = "X-Api-Key"
+
+// APIKeys authenticates static API keys in the X-Api-Key header
+type APIKeys struct {
+	keys map[string]*Principal
+}
+
+// NewAPIKeys ...
+func NewAPIKeys() *APIKeys {
+	return &APIKeys{keys: make(map[string]*Principal)}
+}
+
+// Add a key of the named client with the role
+func (a *APIKeys) Add(key string, name string, role Role) error {
+	if 0 == len(key) {
+		return fmt.Errorf("empty API key")
+	}
+	a.keys[key] = &Principal{Name: name, Role: role, Method: "api-key"}
+	return nil
+}
+
+// Authenticate ...
+func (a *APIKeys) Authenticate(req *http.Request) (*Principal, error) {
+	key := req.Header.Get(HEADER_API_KEY)
+	if 0 == len(key) {
+		return nil, nil
+	}
+	for k, p := range a.keys {
+		if 1 == subtle.ConstantTimeCompare([]byte(k), []byte(key)) {
+			return p, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid API key")
+}
+
+// JWT authenticates bearer tokens signed with HS256.
+// The subject is the name of the client and the role claim is its role.
+type JWT struct {
+	secret []byte
+	now    func() time.Time
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+}
+
+type jwtClaims struct {
+	Sub  string `json:"sub"`
+	Role string `json:"role"`
+	Exp  int64  `json:"exp,omitempty"`
+	Nbf  int64  `json:"nbf,omitempty"`
+}
+
+// NewJWT ...
+func NewJWT(secret string) *JWT {
+	return &JWT{secret: []byte(secret), now: time.Now}
+}
+
+// Authenticate ...
+func (j *JWT) Authenticate(req *http.Request) (*Principal, error) {
+	authorization := req.Header.Get("Authorization")
+	if !strings.HasPrefix(authorization, "Bearer ") {
+		return nil, nil
+	}
+	parts := strings.Split(strings.TrimPrefix(authorization, "Bearer "), ".")
+	if 3 != len(parts) {
+		return nil, fmt.Errorf("malformed bearer token")
+	}
+
+	var header jwtHeader
+	err := decodeSegment(parts[0], &header)
+	if err != nil || "HS256" != header.Alg {
+		return nil, fmt.Errorf("unsupported bearer token")
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || !hmac.Equal(sig, j.sign(parts[0]+"."+parts[1])) {
+		return nil, fmt.Errorf("invalid signature of bearer token")
+	}
+
+	var claims jwtClaims
+	err = decodeSegment(parts[1], &claims)
+	if err != nil {
+		return nil, fmt.Errorf("invalid claims of bearer token")
+	}
+	now := j.now().Unix()
+	if 0 != claims.Exp && now >= claims.Exp {
+		return nil, fmt.Errorf("bearer token has expired")
+	}
+	if 0 != claims.Nbf && now < claims.Nbf {
+		return nil, fmt.Errorf("bearer token isn't valid yet")
+	}
+	role, err := ParseRole(claims.Role)
+	if err != nil {
+		return nil, err
+	}
+	return &Principal{Name: claims.Sub, Role: role, Method: "jwt"}, nil
+}
+
+// Issue signs a token of the client with the role, expiresAt is in unix time and 0 for never
+func (j *JWT) Issue(name string, role Role, expiresAt int64) (string, error) {
+	header, err := encodeSegment(&jwtHeader{Alg: "HS256"})
+	if err != nil {
+		return "", err
+	}
+	claims, err := encodeSegment(&jwtClaims{Sub: name, Role: string(role), Exp: expiresAt})
+	if err != nil {
+		return "", err
+	}
+	return header + "." + claims + "." + base64.RawURLEncoding.EncodeToString(j.sign(header+"."+claims)), nil
+}
+
+func (j *JWT) sign(data string) []byte {
+	mac := hmac.New(sha256.New, j.secret)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// ClientCerts authenticates the client certificates verified by the TLS server,
+// by the common name of their subjects
+type ClientCerts struct {
+	roles map[string]Role
+}
+
+// NewClientCerts ...
+func NewClientCerts() *ClientCerts {
+	return &ClientCerts{roles: make(map[string]Role)}
+}
+
+// Add the role of a common name
+func (c *ClientCerts) Add(commonName string, role Role) {
+	c.roles[commonName] = role
+}
+
+// Authenticate ...
+func (c *ClientCerts) Authenticate(req *http.Request) (*Principal, error) {
+	if nil == req.TLS || 0 == len(req.TLS.VerifiedChains) || 0 == len(req.TLS.VerifiedChains[0]) {
+		return nil, nil
+	}
+	cn := req.TLS.VerifiedChains[0][0].Subject.CommonName
+	role, ok := c.roles[cn]
+	if !ok {
+		return nil, fmt.Errorf("no role for client certificate %v", cn)
+	}
+	return &Principal{Name: cn, Role: role, Method: "mtls"}, nil
+}
+
+func encodeSegment(v interface{}) (string, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func decodeSegment(s string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
@@ -0,0 +1,101 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/unitychain/zkvote-node/restapi/auth"
+	"github.com/unitychain/zkvote-node/restapi/controller"
+	adminModel "github.com/unitychain/zkvote-node/restapi/model/admin"
+	"github.com/unitychain/zkvote-node/zkvote/common/utils"
+	zkvote "github.com/unitychain/zkvote-node/zkvote/operator"
+	"github.com/unitychain/zkvote-node/zkvote/operator/service/manager"
+)
+
+const (
+	adminURL  = "/admin"
+	healthURL = adminURL + "/health"
+	readyURL  = adminURL + "/ready"
+	peersURL  = adminURL + "/peers"
+	dhtURL    = adminURL + "/dht"
+	debugURL  = adminURL + "/subjects/{hash}/debug"
+)
+
+// Controller serves the diagnostics of the operator,
+// health and readiness are public for the probes of orchestrators
+type Controller struct {
+	handlers []controller.Handler
+	*zkvote.Operator
+}
+
+// New ...
+func New(op *zkvote.Operator) (*Controller, error) {
+	c := &Controller{
+		Operator: op,
+	}
+	c.registerHandler()
+
+	return c, nil
+}
+
+func (c *Controller) health(rw http.ResponseWriter, req *http.Request) {
+	c.writeResponse(rw, http.StatusOK, c.GetHealth())
+}
+
+func (c *Controller) ready(rw http.ResponseWriter, req *http.Request) {
+	r := c.GetReadiness()
+	status := http.StatusOK
+	if !r.Ready {
+		status = http.StatusServiceUnavailable
+	}
+	c.writeResponse(rw, status, r)
+}
+
+func (c *Controller) peers(rw http.ResponseWriter, req *http.Request) {
+	c.writeResponse(rw, http.StatusOK, c.GetPeers())
+}
+
+func (c *Controller) dht(rw http.ResponseWriter, req *http.Request) {
+	c.writeResponse(rw, http.StatusOK, c.GetDHTInfo())
+}
+
+func (c *Controller) debug(rw http.ResponseWriter, req *http.Request) {
+	debug, err := c.GetSubjectDebug(mux.Vars(req)["hash"])
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, manager.ErrSubjectNotFound) {
+			status = http.StatusNotFound
+		}
+		c.writeResponse(rw, status, &adminModel.Error{Message: err.Error()})
+		return
+	}
+	c.writeResponse(rw, http.StatusOK, debug)
+}
+
+func (c *Controller) writeResponse(rw http.ResponseWriter, status int, v interface{}) {
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(status)
+	err := json.NewEncoder(rw).Encode(v)
+	if err != nil {
+		utils.LogWarningf("Unable to send response, %v", err)
+	}
+}
+
+// GetRESTHandlers get all controller API handler available for this protocol service
+func (c *Controller) GetRESTHandlers() []controller.Handler {
+	return c.handlers
+}
+
+// registerHandler register handlers to be exposed from this protocol service as REST API endpoints
+func (c *Controller) registerHandler() {
+	// Add more protocol endpoints here to expose them as controller API endpoints
+	c.handlers = []controller.Handler{
+		controller.NewHTTPHandler(healthURL, http.MethodGet, c.health).WithDoc(healthDoc).WithRole(auth.RolePublic),
+		controller.NewHTTPHandler(readyURL, http.MethodGet, c.ready).WithDoc(readyDoc).WithRole(auth.RolePublic),
+		controller.NewHTTPHandler(peersURL, http.MethodGet, c.peers).WithDoc(peersDoc).WithRole(auth.RoleAdmin),
+		controller.NewHTTPHandler(dhtURL, http.MethodGet, c.dht).WithDoc(dhtDoc).WithRole(auth.RoleAdmin),
+		controller.NewHTTPHandler(debugURL, http.MethodGet, c.debug).WithDoc(debugDoc).WithRole(auth.RoleAdmin),
+	}
+}
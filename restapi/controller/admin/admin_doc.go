@@ -0,0 +1,49 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/unitychain/zkvote-node/restapi/controller"
+	adminModel "github.com/unitychain/zkvote-node/restapi/model/admin"
+	zkvote "github.com/unitychain/zkvote-node/zkvote/operator"
+	"github.com/unitychain/zkvote-node/zkvote/operator/service/manager"
+)
+
+var tags = []string{"admin"}
+
+var healthDoc = &controller.Doc{
+	Summary:   "Get the health of this node, it's served as long as the node runs",
+	Tags:      tags,
+	Responses: map[int]interface{}{http.StatusOK: zkvote.Health{}},
+}
+
+var readyDoc = &controller.Doc{
+	Summary: "Get the readiness of this node, which needs DHT peers and no pending sync",
+	Tags:    tags,
+	Responses: map[int]interface{}{
+		http.StatusOK:                 zkvote.Readiness{},
+		http.StatusServiceUnavailable: zkvote.Readiness{},
+	},
+}
+
+var peersDoc = &controller.Doc{
+	Summary:   "Get the addresses of this node, its connected, mDNS and topic peers",
+	Tags:      tags,
+	Responses: map[int]interface{}{http.StatusOK: zkvote.Peers{}},
+}
+
+var dhtDoc = &controller.Doc{
+	Summary:   "Get the routing table of the DHT",
+	Tags:      tags,
+	Responses: map[int]interface{}{http.StatusOK: zkvote.DHTInfo{}},
+}
+
+var debugDoc = &controller.Doc{
+	Summary: "Get the identities, ballots, topic peers and conflicts of a subject",
+	Tags:    tags,
+	Responses: map[int]interface{}{
+		http.StatusOK:       manager.SubjectDebug{},
+		http.StatusNotFound: adminModel.Error{},
+		0:                   adminModel.Error{},
+	},
+}
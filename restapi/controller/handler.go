@@ -1,6 +1,10 @@
 package controller
 
-import "net/http"
+import (
+	"net/http"
+
+	"github.com/unitychain/zkvote-node/restapi/auth"
+)
 
 // Handler http handler for each controller API endpoint
 type Handler interface {
@@ -21,6 +25,8 @@ type HTTPHandler struct {
 	path   string
 	method string
 	handle http.HandlerFunc
+	doc    *Doc
+	role   *auth.Role
 }
 
 // Path returns http request path
@@ -37,3 +43,51 @@ func (h *HTTPHandler) Method() string {
 func (h *HTTPHandler) Handle() http.HandlerFunc {
 	return h.handle
 }
+
+// Doc documents a handler in the OpenAPI specification.
+// Query, Form and Body are models whose JSON fields are the parameters of the request,
+// Responses are the models by status code, where 0 is the default response and a nil model has no content.
+type Doc struct {
+	Summary     string
+	Tags        []string
+	Query       interface{}
+	Form        interface{}
+	Body        interface{}
+	ContentType string // of the successful responses, application/json if empty
+	Responses   map[int]interface{}
+}
+
+// Documented is a handler with its documentation
+type Documented interface {
+	Doc() *Doc
+}
+
+// WithDoc attaches the documentation to the handler
+func (h *HTTPHandler) WithDoc(doc *Doc) *HTTPHandler {
+	h.doc = doc
+	return h
+}
+
+// Doc returns the documentation of the handler, nil if it's undocumented
+func (h *HTTPHandler) Doc() *Doc {
+	return h.doc
+}
+
+// Authorized is a handler which requires a role of its clients
+type Authorized interface {
+	Role() auth.Role
+}
+
+// WithRole sets the role required by the handler, auth.RolePublic serves everyone
+func (h *HTTPHandler) WithRole(role auth.Role) *HTTPHandler {
+	h.role = &role
+	return h
+}
+
+// Role returns the role required by the handler, auth.DefaultRole of its method if it isn't set
+func (h *HTTPHandler) Role() auth.Role {
+	if nil == h.role {
+		return auth.DefaultRole(h.method)
+	}
+	return *h.role
+}
@@ -136,7 +136,7 @@ func (c *Controller) GetRESTHandlers() []controller.Handler {
 func (c *Controller) registerHandler() {
 	// Add more protocol endpoints here to expose them as controller API endpoints
 	c.handlers = []controller.Handler{
-		controller.NewHTTPHandler(getSnarkDataURL, http.MethodGet, c.getSnarkData),
+		controller.NewHTTPHandler(getSnarkDataURL, http.MethodGet, c.getSnarkData).WithDoc(getSnarkDataDoc),
 		// support.NewHTTPHandler(connections, http.MethodGet, c.QueryConnections),
 		// support.NewHTTPHandler(connectionsByID, http.MethodGet, c.QueryConnectionByID),
 		// support.NewHTTPHandler(receiveInvitationPath, http.MethodPost, c.ReceiveInvitation),
@@ -0,0 +1,20 @@
+package identity
+
+import (
+	"net/http"
+
+	"github.com/unitychain/zkvote-node/restapi/controller"
+	identityModel "github.com/unitychain/zkvote-node/restapi/model/identity"
+)
+
+var getSnarkDataDoc = &controller.Doc{
+	Summary:     "Download the proving key and circuit of the ballots",
+	Tags:        []string{"identities"},
+	Query:       identityModel.GetSnarkDataRequest{},
+	ContentType: "application/zip",
+	Responses: map[int]interface{}{
+		http.StatusOK:       []byte{},
+		http.StatusNotFound: nil,
+		0:                   identityModel.GenericError{},
+	},
+}
@@ -0,0 +1,48 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/unitychain/zkvote-node/restapi/controller"
+	"github.com/unitychain/zkvote-node/zkvote/common/metrics"
+	"github.com/unitychain/zkvote-node/zkvote/common/utils"
+)
+
+const metricsURL = "/metrics"
+
+// Controller exports the metrics of the default registry to Prometheus
+type Controller struct {
+	handlers []controller.Handler
+}
+
+// New ...
+func New() (*Controller, error) {
+	c := &Controller{}
+	c.registerHandler()
+	return c, nil
+}
+
+func (c *Controller) getMetrics(rw http.ResponseWriter, req *http.Request) {
+	rw.Header().Set("Content-Type", metrics.CONTENT_TYPE)
+	err := metrics.Default.Write(rw)
+	if err != nil {
+		utils.LogWarningf("Failed to write metrics, %v", err)
+	}
+}
+
+// GetRESTHandlers get all controller API handler available for this protocol service
+func (c *Controller) GetRESTHandlers() []controller.Handler {
+	return c.handlers
+}
+
+// registerHandler register handlers to be exposed from this protocol service as REST API endpoints
+func (c *Controller) registerHandler() {
+	c.handlers = []controller.Handler{
+		controller.NewHTTPHandler(metricsURL, http.MethodGet, c.getMetrics).WithDoc(&controller.Doc{
+			Summary:     "Get the metrics of this node in the Prometheus text format",
+			Tags:        []string{"metrics"},
+			ContentType: "text/plain",
+			Responses:   map[int]interface{}{http.StatusOK: ""},
+		}),
+	}
+}
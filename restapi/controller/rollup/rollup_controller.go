@@ -0,0 +1,161 @@
+package rollup
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/unitychain/zkvote-node/restapi/auth"
+	"github.com/unitychain/zkvote-node/restapi/controller"
+	rollupModel "github.com/unitychain/zkvote-node/restapi/model/rollup"
+	"github.com/unitychain/zkvote-node/zkvote/node"
+)
+
+const (
+	operationID = "/rollups"
+	indexURL    = operationID
+	submitURL   = operationID + "/submit"
+	tallyURL    = operationID + "/tally"
+)
+
+// Controller ...
+type Controller struct {
+	handlers []controller.Handler
+	*node.Node
+}
+
+// New ...
+func New(n *node.Node) (*Controller, error) {
+	controller := &Controller{
+		Node: n,
+	}
+	controller.registerHandler()
+
+	return controller, nil
+}
+
+func (c *Controller) index(rw http.ResponseWriter, req *http.Request) {
+	response := rollupModel.IndexResponse{}
+	response.Results.Root = c.GetRoot()
+	response.Results.Subjects = make([]*rollupModel.SubjectTally, 0)
+	for _, l := range c.GetVoteLeaves() {
+		response.Results.Subjects = append(response.Results.Subjects,
+			&rollupModel.SubjectTally{SubjectHash: l.Hash.String(), Ballots: l.Ballots})
+	}
+
+	c.writeResponse(rw, response)
+}
+
+func (c *Controller) submit(rw http.ResponseWriter, req *http.Request) {
+	var request rollupModel.SubmitRequest
+
+	err := req.ParseMultipartForm(0)
+	if err != nil {
+		c.writeGenericError(rw, err, http.StatusInternalServerError)
+		return
+	}
+
+	err = getQueryParams(&request, req.Form)
+	if err != nil {
+		c.writeGenericError(rw, err, http.StatusInternalServerError)
+		return
+	}
+
+	response := rollupModel.SubmitResponse{}
+	if request.SubmitParams != nil {
+		root, err := c.Rollup(request.SubmitParams.SubjectHash, request.SubmitParams.PrevRoot, request.SubmitParams.Proof)
+		if err != nil {
+			c.writeGenericError(rw, err, http.StatusInternalServerError)
+			return
+		}
+		response.Results.Root = root
+	}
+
+	c.writeResponse(rw, response)
+}
+
+func (c *Controller) tally(rw http.ResponseWriter, req *http.Request) {
+	var request rollupModel.TallyRequest
+
+	err := getQueryParams(&request, req.URL.Query())
+	if err != nil {
+		c.writeGenericError(rw, err, http.StatusInternalServerError)
+		return
+	}
+
+	response := rollupModel.TallyResponse{}
+	if request.TallyParams != nil {
+		subjectHash := request.TallyParams.SubjectHash
+		ballots, err := c.GetBallots(subjectHash)
+		if err != nil {
+			c.writeGenericError(rw, err, http.StatusNotFound)
+			return
+		}
+		response.Results = &rollupModel.SubjectTally{SubjectHash: subjectHash, Ballots: ballots}
+	}
+
+	c.writeResponse(rw, response)
+}
+
+// writeGenericError writes given error to writer as generic error response
+func (c *Controller) writeGenericError(rw http.ResponseWriter, err error, statusCode int) {
+	rw.WriteHeader(statusCode)
+	rw.Header().Set("Content-Type", "application/json")
+
+	json.NewEncoder(rw).Encode(rollupModel.GenericError{
+		Body: struct {
+			Code    int32  `json:"code"`
+			Message string `json:"message"`
+		}{
+			// TODO implement error codes, below is sample error code
+			Code:    1,
+			Message: err.Error(),
+		},
+	})
+}
+
+// writeResponse writes interface value to response
+func (c *Controller) writeResponse(rw io.Writer, v interface{}) {
+	err := json.NewEncoder(rw).Encode(v)
+	// as of now, just log errors for writing response
+	if err != nil {
+		fmt.Printf("Unable to send error response, %s\n", err)
+	}
+}
+
+// GetRESTHandlers get all controller API handler available for this protocol service
+func (c *Controller) GetRESTHandlers() []controller.Handler {
+	return c.handlers
+}
+
+// registerHandler register handlers to be exposed from this protocol service as REST API endpoints
+func (c *Controller) registerHandler() {
+	c.handlers = []controller.Handler{
+		controller.NewHTTPHandler(indexURL, http.MethodGet, c.index).WithDoc(indexDoc),
+		controller.NewHTTPHandler(submitURL, http.MethodPost, c.submit).WithDoc(submitDoc).WithRole(auth.RoleVoter),
+		controller.NewHTTPHandler(tallyURL, http.MethodGet, c.tally).WithDoc(tallyDoc),
+	}
+}
+
+// getQueryParams converts query strings to `map[string]string`
+// and unmarshals to the value pointed by v by following
+// `json.Unmarshal` rules.
+func getQueryParams(v interface{}, vals url.Values) error {
+	// normalize all query string key/values
+	args := make(map[string]string)
+
+	for k, v := range vals {
+		if len(v) > 0 {
+			args[k] = v[0]
+		}
+	}
+
+	bytes, err := json.Marshal(args)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(bytes, v)
+}
@@ -0,0 +1,40 @@
+package rollup
+
+import (
+	"net/http"
+
+	"github.com/unitychain/zkvote-node/restapi/controller"
+	rollupModel "github.com/unitychain/zkvote-node/restapi/model/rollup"
+)
+
+var tags = []string{"rollups"}
+
+var indexDoc = &controller.Doc{
+	Summary: "Get the root of the rollup and the tally of its subjects",
+	Tags:    tags,
+	Query:   rollupModel.IndexRequest{},
+	Responses: map[int]interface{}{
+		http.StatusOK: rollupModel.IndexResponse{},
+		0:             rollupModel.GenericError{},
+	},
+}
+
+var submitDoc = &controller.Doc{
+	Summary: "Submit a proof of the ballots of a subject",
+	Tags:    tags,
+	Form:    rollupModel.SubmitRequest{},
+	Responses: map[int]interface{}{
+		http.StatusOK: rollupModel.SubmitResponse{},
+		0:             rollupModel.GenericError{},
+	},
+}
+
+var tallyDoc = &controller.Doc{
+	Summary: "Get the tally of a subject in the rollup",
+	Tags:    tags,
+	Query:   rollupModel.TallyRequest{},
+	Responses: map[int]interface{}{
+		http.StatusOK: rollupModel.TallyResponse{},
+		0:             rollupModel.GenericError{},
+	},
+}
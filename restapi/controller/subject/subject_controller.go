@@ -4,11 +4,17 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net/http"
 	"net/url"
+	"strconv"
+	"time"
 
+	"github.com/unitychain/zkvote-node/restapi/auth"
 	"github.com/unitychain/zkvote-node/restapi/controller"
 	subjectModel "github.com/unitychain/zkvote-node/restapi/model/subject"
+	"github.com/unitychain/zkvote-node/zkvote/common/event"
+	"github.com/unitychain/zkvote-node/zkvote/common/utils"
 	subject "github.com/unitychain/zkvote-node/zkvote/model/subject"
 	zkvote "github.com/unitychain/zkvote-node/zkvote/operator"
 	// 	"errors"
@@ -21,9 +27,17 @@ const (
 	indexURL           = operationID
 	proposeURL         = operationID + "/propose"
 	joinURL            = operationID + "/join"
+	admitURL           = operationID + "/admit"
+	credentialURL      = operationID + "/credential"
+	revokeURL          = operationID + "/revoke"
 	voteURL            = operationID + "/vote"
 	openURL            = operationID + "/open"
 	getIdentityPathURL = operationID + "/identity_path"
+	exportURL          = operationID + "/export"
+	importURL          = operationID + "/import"
+	auditURL           = operationID + "/audit"
+	conflictsURL       = operationID + "/conflicts"
+	eventsURL          = operationID + "/events"
 	// receiveInvitationPath   = operationID + "/receive-invitation"
 	// acceptInvitationPath    = operationID + "/{id}/accept-invitation"
 	// connectionsByID         = operationID + "/{id}"
@@ -32,6 +46,9 @@ const (
 	// connectionsWebhookTopic = "connections"
 )
 
+// EVENTS_KEEPALIVE is the interval of the comments which keep an idle event stream open
+const EVENTS_KEEPALIVE = 15 * time.Second
+
 // Controller ...
 type Controller struct {
 	handlers []controller.Handler
@@ -98,10 +115,16 @@ func (c *Controller) propose(rw http.ResponseWriter, req *http.Request) {
 
 	var title, description, identityCommitment string
 	if request.ProposeParams != nil {
+		// Options are repeated form values, e.g. options=alice&options=bob
+		request.ProposeParams.Options = req.Form["options"]
+
 		title = request.ProposeParams.Title
 		description = request.ProposeParams.Description
 		identityCommitment = request.ProposeParams.IdentityCommitment
-		err := c.Propose(title, description, identityCommitment)
+		_, err := c.Propose(title, description, identityCommitment,
+			subject.WithOptions(request.ProposeParams.Options...),
+			subject.WithPeriod(request.ProposeParams.OpenAt, request.ProposeParams.CloseAt),
+			subject.WithTreeLevel(request.ProposeParams.TreeLevel, request.ProposeParams.VerificationKey))
 		if err != nil {
 			c.writeGenericError(rw, err, http.StatusInternalServerError)
 			return
@@ -132,11 +155,15 @@ func (c *Controller) join(rw http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	var subjectHash, identityCommitment string
+	var subjectHash, identityCommitment, presented string
 	if request.JoinParams != nil {
 		subjectHash = request.JoinParams.SubjectHash
 		identityCommitment = request.JoinParams.IdentityCommitment
-		err = c.Join(subjectHash, identityCommitment)
+		presented = request.JoinParams.Credential
+		if 0 == len(presented) {
+			presented = request.JoinParams.Admission
+		}
+		err = c.Join(subjectHash, identityCommitment, presented)
 	}
 	if err != nil {
 		c.writeGenericError(rw, err, http.StatusInternalServerError)
@@ -150,6 +177,91 @@ func (c *Controller) join(rw http.ResponseWriter, req *http.Request) {
 	c.writeResponse(rw, response)
 }
 
+func (c *Controller) admit(rw http.ResponseWriter, req *http.Request) {
+	var request subjectModel.AdmitRequest
+
+	err := req.ParseMultipartForm(0)
+	if err != nil {
+		c.writeGenericError(rw, err, http.StatusInternalServerError)
+		return
+	}
+
+	err = getQueryParams(&request, req.Form)
+	if err != nil {
+		c.writeGenericError(rw, err, http.StatusInternalServerError)
+		return
+	}
+
+	response := subjectModel.AdmitResponse{}
+	if request.AdmitParams != nil {
+		response.Results, err = c.Admit(request.AdmitParams.SubjectHash, request.AdmitParams.IdentityCommitment)
+		if err != nil {
+			c.writeGenericError(rw, err, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	c.writeResponse(rw, response)
+}
+
+func (c *Controller) issueCredential(rw http.ResponseWriter, req *http.Request) {
+	var request subjectModel.IssueCredentialRequest
+
+	err := req.ParseMultipartForm(0)
+	if err != nil {
+		c.writeGenericError(rw, err, http.StatusInternalServerError)
+		return
+	}
+
+	err = getQueryParams(&request, req.Form)
+	if err != nil {
+		c.writeGenericError(rw, err, http.StatusInternalServerError)
+		return
+	}
+
+	response := subjectModel.IssueCredentialResponse{}
+	if request.IssueCredentialParams != nil {
+		params := request.IssueCredentialParams
+		response.Results, err = c.IssueCredential(params.SubjectHash, params.IdentityCommitment, params.Format, params.ExpiresAt)
+		if err != nil {
+			c.writeGenericError(rw, err, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	c.writeResponse(rw, response)
+}
+
+func (c *Controller) revokeCredential(rw http.ResponseWriter, req *http.Request) {
+	var request subjectModel.RevokeCredentialRequest
+
+	err := req.ParseMultipartForm(0)
+	if err != nil {
+		c.writeGenericError(rw, err, http.StatusInternalServerError)
+		return
+	}
+
+	err = getQueryParams(&request, req.Form)
+	if err != nil {
+		c.writeGenericError(rw, err, http.StatusInternalServerError)
+		return
+	}
+
+	if request.RevokeCredentialParams != nil {
+		err = c.RevokeCredential(request.RevokeCredentialParams.CredentialID)
+	}
+	if err != nil {
+		c.writeGenericError(rw, err, http.StatusNotFound)
+		return
+	}
+
+	response := subjectModel.RevokeCredentialResponse{
+		Results: "Success",
+	}
+
+	c.writeResponse(rw, response)
+}
+
 func (c *Controller) vote(rw http.ResponseWriter, req *http.Request) {
 	// logger.Debugf("Querying subjects")
 
@@ -199,14 +311,21 @@ func (c *Controller) open(rw http.ResponseWriter, req *http.Request) {
 	response := subjectModel.OpenResponse{}
 	if request.OpenParams != nil {
 		subjectHash := request.OpenParams.SubjectHash
-		yes, no := c.Open(subjectHash)
-		response.Results.Yes = yes
-		response.Results.No = no
-	}
+		sub, err := c.Manager.GetSubject(subjectHash)
+		if err != nil {
+			c.writeGenericError(rw, err, http.StatusInternalServerError)
+			return
+		}
+		votes, err := c.Open(subjectHash)
+		if err != nil {
+			c.writeGenericError(rw, err, http.StatusInternalServerError)
+			return
+		}
 
-	if err != nil {
-		c.writeGenericError(rw, err, http.StatusInternalServerError)
-		return
+		response.Results = make([]*subjectModel.OptionTally, len(votes))
+		for i, o := range sub.GetOptions() {
+			response.Results[i] = &subjectModel.OptionTally{Option: o, Votes: votes[i]}
+		}
 	}
 
 	c.writeResponse(rw, response)
@@ -241,8 +360,186 @@ func (c *Controller) getIdentityPath(rw http.ResponseWriter, req *http.Request)
 	c.writeResponse(rw, response)
 }
 
-func subjectToJSON(s []*subject.Subject) []map[string]string {
-	result := make([]map[string]string, 0)
+func (c *Controller) export(rw http.ResponseWriter, req *http.Request) {
+	var request subjectModel.ExportRequest
+
+	err := getQueryParams(&request, req.URL.Query())
+	if err != nil {
+		c.writeGenericError(rw, err, http.StatusInternalServerError)
+		return
+	}
+	if request.ExportParams == nil {
+		c.writeGenericError(rw, fmt.Errorf("subjectHash is required"), http.StatusBadRequest)
+		return
+	}
+
+	subjectHash := request.ExportParams.SubjectHash
+	data, err := c.Export(subjectHash)
+	if err != nil {
+		c.writeGenericError(rw, err, http.StatusNotFound)
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s.zkvote.json\"", subjectHash))
+	rw.Write(data)
+}
+
+func (c *Controller) importSubject(rw http.ResponseWriter, req *http.Request) {
+	// The archive is uploaded as the file field "archive"
+	file, _, err := req.FormFile("archive")
+	if err != nil {
+		c.writeGenericError(rw, err, http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	data, err := ioutil.ReadAll(file)
+	if err != nil {
+		c.writeGenericError(rw, err, http.StatusInternalServerError)
+		return
+	}
+
+	s, err := c.Import(data)
+	if err != nil {
+		c.writeGenericError(rw, err, http.StatusBadRequest)
+		return
+	}
+
+	response := subjectModel.ImportResponse{
+		Results: s.JSON(),
+	}
+
+	c.writeResponse(rw, response)
+}
+
+func (c *Controller) audit(rw http.ResponseWriter, req *http.Request) {
+	var request subjectModel.AuditRequest
+
+	err := getQueryParams(&request, req.URL.Query())
+	if err != nil {
+		c.writeGenericError(rw, err, http.StatusInternalServerError)
+		return
+	}
+
+	response := subjectModel.AuditResponse{}
+	if request.AuditParams != nil {
+		response.Results, err = c.Audit(request.AuditParams.SubjectHash)
+		if err != nil {
+			c.writeGenericError(rw, err, http.StatusNotFound)
+			return
+		}
+	}
+
+	c.writeResponse(rw, response)
+}
+
+func (c *Controller) conflicts(rw http.ResponseWriter, req *http.Request) {
+	var request subjectModel.ConflictsRequest
+
+	err := getQueryParams(&request, req.URL.Query())
+	if err != nil {
+		c.writeGenericError(rw, err, http.StatusInternalServerError)
+		return
+	}
+
+	response := subjectModel.ConflictsResponse{}
+	if request.ConflictsParams != nil {
+		response.Results, err = c.GetConflicts(request.ConflictsParams.SubjectHash)
+		if err != nil {
+			c.writeGenericError(rw, err, http.StatusNotFound)
+			return
+		}
+	}
+
+	c.writeResponse(rw, response)
+}
+
+// events streams the activity of subjects as server-sent events.
+// A client resumes from the last event it received by Last-Event-ID or the cursor parameter.
+func (c *Controller) events(rw http.ResponseWriter, req *http.Request) {
+	var request subjectModel.EventsRequest
+
+	err := getQueryParams(&request, req.URL.Query())
+	if err != nil {
+		c.writeGenericError(rw, err, http.StatusInternalServerError)
+		return
+	}
+
+	flusher, ok := rw.(http.Flusher)
+	if !ok {
+		c.writeGenericError(rw, fmt.Errorf("streaming is not supported"), http.StatusInternalServerError)
+		return
+	}
+
+	var subjectHash, cursor string
+	if request.EventsParams != nil {
+		subjectHash = utils.Remove0x(request.EventsParams.SubjectHash)
+		cursor = request.EventsParams.Cursor
+	}
+	if lastEventID := req.Header.Get("Last-Event-ID"); 0 != len(lastEventID) {
+		cursor = lastEventID
+	}
+	after := c.Events.LastID()
+	if 0 != len(cursor) {
+		after, err = strconv.ParseUint(cursor, 10, 64)
+		if err != nil {
+			c.writeGenericError(rw, fmt.Errorf("invalid cursor, %v", cursor), http.StatusBadRequest)
+			return
+		}
+	}
+
+	replay, sub, err := c.Events.Subscribe(subjectHash, after)
+	if err == event.ErrCursorExpired {
+		c.writeGenericError(rw, err, http.StatusGone)
+		return
+	}
+	if err != nil {
+		c.writeGenericError(rw, err, http.StatusBadRequest)
+		return
+	}
+	defer sub.Close()
+
+	rw.Header().Set("Content-Type", "text/event-stream")
+	rw.Header().Set("Cache-Control", "no-cache")
+	rw.Header().Set("Connection", "keep-alive")
+	rw.WriteHeader(http.StatusOK)
+	for _, e := range replay {
+		writeEvent(rw, e)
+	}
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(EVENTS_KEEPALIVE)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-req.Context().Done():
+			return
+		case e, ok := <-sub.C:
+			// Closed if the client falls behind, it reconnects with the last event id
+			if !ok {
+				return
+			}
+			writeEvent(rw, e)
+			flusher.Flush()
+		case <-keepAlive.C:
+			fmt.Fprint(rw, ": keep-alive\n\n")
+			flusher.Flush()
+		}
+	}
+}
+
+func writeEvent(w io.Writer, e *event.Event) {
+	data, err := json.Marshal(e)
+	if err != nil {
+		fmt.Printf("Unable to marshal event, %s\n", err)
+		return
+	}
+	fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Type, data)
+}
+
+func subjectToJSON(s []*subject.Subject) []map[string]interface{} {
+	result := make([]map[string]interface{}, 0)
 	for _, s := range s {
 		result = append(result, s.JSON())
 	}
@@ -285,12 +582,20 @@ func (c *Controller) GetRESTHandlers() []controller.Handler {
 func (c *Controller) registerHandler() {
 	// Add more protocol endpoints here to expose them as controller API endpoints
 	c.handlers = []controller.Handler{
-		controller.NewHTTPHandler(indexURL, http.MethodGet, c.index),
-		controller.NewHTTPHandler(proposeURL, http.MethodPost, c.propose),
-		controller.NewHTTPHandler(joinURL, http.MethodPost, c.join),
-		controller.NewHTTPHandler(voteURL, http.MethodPost, c.vote),
-		controller.NewHTTPHandler(openURL, http.MethodGet, c.open),
-		controller.NewHTTPHandler(getIdentityPathURL, http.MethodGet, c.getIdentityPath),
+		controller.NewHTTPHandler(indexURL, http.MethodGet, c.index).WithDoc(indexDoc),
+		controller.NewHTTPHandler(proposeURL, http.MethodPost, c.propose).WithDoc(proposeDoc).WithRole(auth.RoleProposer),
+		controller.NewHTTPHandler(joinURL, http.MethodPost, c.join).WithDoc(joinDoc).WithRole(auth.RoleVoter),
+		controller.NewHTTPHandler(admitURL, http.MethodPost, c.admit).WithDoc(admitDoc).WithRole(auth.RoleProposer),
+		controller.NewHTTPHandler(credentialURL, http.MethodPost, c.issueCredential).WithDoc(issueCredentialDoc).WithRole(auth.RoleProposer),
+		controller.NewHTTPHandler(revokeURL, http.MethodPost, c.revokeCredential).WithDoc(revokeCredentialDoc).WithRole(auth.RoleProposer),
+		controller.NewHTTPHandler(voteURL, http.MethodPost, c.vote).WithDoc(voteDoc).WithRole(auth.RoleVoter),
+		controller.NewHTTPHandler(openURL, http.MethodGet, c.open).WithDoc(openDoc),
+		controller.NewHTTPHandler(getIdentityPathURL, http.MethodGet, c.getIdentityPath).WithDoc(getIdentityPathDoc),
+		controller.NewHTTPHandler(exportURL, http.MethodGet, c.export).WithDoc(exportDoc),
+		controller.NewHTTPHandler(importURL, http.MethodPost, c.importSubject).WithDoc(importDoc).WithRole(auth.RoleAdmin),
+		controller.NewHTTPHandler(auditURL, http.MethodGet, c.audit).WithDoc(auditDoc),
+		controller.NewHTTPHandler(conflictsURL, http.MethodGet, c.conflicts).WithDoc(conflictsDoc),
+		controller.NewHTTPHandler(eventsURL, http.MethodGet, c.events).WithDoc(eventsDoc),
 		// support.NewHTTPHandler(connections, http.MethodGet, c.QueryConnections),
 		// support.NewHTTPHandler(connectionsByID, http.MethodGet, c.QueryConnectionByID),
 		// support.NewHTTPHandler(acceptInvitationPath, http.MethodPost, c.AcceptInvitation),
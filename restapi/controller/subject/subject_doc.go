@@ -0,0 +1,122 @@
+package subject
+
+import (
+	"net/http"
+
+	"github.com/unitychain/zkvote-node/restapi/controller"
+	subjectModel "github.com/unitychain/zkvote-node/restapi/model/subject"
+	"github.com/unitychain/zkvote-node/zkvote/common/event"
+	"github.com/unitychain/zkvote-node/zkvote/model/archive"
+)
+
+var tags = []string{"subjects"}
+
+// withErrors adds the generic error as the default response
+func withErrors(responses map[int]interface{}) map[int]interface{} {
+	responses[0] = subjectModel.GenericError{}
+	return responses
+}
+
+var indexDoc = &controller.Doc{
+	Summary:   "List the created and collected subjects",
+	Tags:      tags,
+	Query:     subjectModel.IndexRequest{},
+	Responses: withErrors(map[int]interface{}{http.StatusOK: subjectModel.IndexResponse{}}),
+}
+
+var proposeDoc = &controller.Doc{
+	Summary:   "Propose a subject, options are repeated form values",
+	Tags:      tags,
+	Form:      subjectModel.ProposeRequest{},
+	Responses: withErrors(map[int]interface{}{http.StatusOK: subjectModel.ProposeResponse{}}),
+}
+
+var joinDoc = &controller.Doc{
+	Summary:   "Join a subject with an identity commitment",
+	Tags:      tags,
+	Form:      subjectModel.JoinRequest{},
+	Responses: withErrors(map[int]interface{}{http.StatusOK: subjectModel.JoinResponse{}}),
+}
+
+var admitDoc = &controller.Doc{
+	Summary:   "Admit an identity commitment to a subject proposed by this node",
+	Tags:      tags,
+	Form:      subjectModel.AdmitRequest{},
+	Responses: withErrors(map[int]interface{}{http.StatusOK: subjectModel.AdmitResponse{}}),
+}
+
+var issueCredentialDoc = &controller.Doc{
+	Summary:   "Issue a membership credential of a subject proposed by this node",
+	Tags:      tags,
+	Form:      subjectModel.IssueCredentialRequest{},
+	Responses: withErrors(map[int]interface{}{http.StatusOK: subjectModel.IssueCredentialResponse{}}),
+}
+
+var revokeCredentialDoc = &controller.Doc{
+	Summary:   "Revoke a membership credential issued by this node",
+	Tags:      tags,
+	Form:      subjectModel.RevokeCredentialRequest{},
+	Responses: withErrors(map[int]interface{}{http.StatusOK: subjectModel.RevokeCredentialResponse{}}),
+}
+
+var voteDoc = &controller.Doc{
+	Summary:   "Vote on a subject with a ballot",
+	Tags:      tags,
+	Form:      subjectModel.VoteRequest{},
+	Responses: withErrors(map[int]interface{}{http.StatusOK: subjectModel.VoteResponse{}}),
+}
+
+var openDoc = &controller.Doc{
+	Summary:   "Get the tally of a subject",
+	Tags:      tags,
+	Query:     subjectModel.OpenRequest{},
+	Responses: withErrors(map[int]interface{}{http.StatusOK: subjectModel.OpenResponse{}}),
+}
+
+var getIdentityPathDoc = &controller.Doc{
+	Summary:   "Get the merkle path of an identity commitment",
+	Tags:      tags,
+	Query:     subjectModel.GetIdentityPathRequest{},
+	Responses: withErrors(map[int]interface{}{http.StatusOK: subjectModel.GetIdentityPathResponse{}}),
+}
+
+var exportDoc = &controller.Doc{
+	Summary:   "Export the voting state of a subject as an archive",
+	Tags:      tags,
+	Query:     subjectModel.ExportRequest{},
+	Responses: withErrors(map[int]interface{}{http.StatusOK: archive.Archive{}}),
+}
+
+var importDoc = &controller.Doc{
+	Summary: "Import an archive uploaded as the file field archive",
+	Tags:    tags,
+	Form: struct {
+		Archive []byte `json:"archive"`
+	}{},
+	Responses: withErrors(map[int]interface{}{http.StatusOK: subjectModel.ImportResponse{}}),
+}
+
+var auditDoc = &controller.Doc{
+	Summary:   "Audit the tally of a subject and sign the report",
+	Tags:      tags,
+	Query:     subjectModel.AuditRequest{},
+	Responses: withErrors(map[int]interface{}{http.StatusOK: subjectModel.AuditResponse{}}),
+}
+
+var conflictsDoc = &controller.Doc{
+	Summary:   "List the conflicting identity insertions of a subject",
+	Tags:      tags,
+	Query:     subjectModel.ConflictsRequest{},
+	Responses: withErrors(map[int]interface{}{http.StatusOK: subjectModel.ConflictsResponse{}}),
+}
+
+var eventsDoc = &controller.Doc{
+	Summary:     "Stream the activity of subjects as server-sent events, resumed by Last-Event-ID or cursor",
+	Tags:        tags,
+	Query:       subjectModel.EventsRequest{},
+	ContentType: "text/event-stream",
+	Responses: withErrors(map[int]interface{}{
+		http.StatusOK:   event.Event{},
+		http.StatusGone: subjectModel.GenericError{},
+	}),
+}
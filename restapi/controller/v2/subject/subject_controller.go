@@ -0,0 +1,362 @@
+package subject
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/unitychain/zkvote-node/restapi/auth"
+	"github.com/unitychain/zkvote-node/restapi/controller"
+	subjectModel "github.com/unitychain/zkvote-node/restapi/model/v2/subject"
+	"github.com/unitychain/zkvote-node/zkvote/common/utils"
+	ba "github.com/unitychain/zkvote-node/zkvote/model/ballot"
+	id "github.com/unitychain/zkvote-node/zkvote/model/identity"
+	subject "github.com/unitychain/zkvote-node/zkvote/model/subject"
+	zkvote "github.com/unitychain/zkvote-node/zkvote/operator"
+	"github.com/unitychain/zkvote-node/zkvote/operator/service/manager"
+)
+
+const (
+	operationID = "/v2/subjects"
+	subjectsURL = operationID
+	subjectURL  = operationID + "/{hash}"
+	membersURL  = subjectURL + "/members"
+	ballotsURL  = subjectURL + "/ballots"
+	tallyURL    = subjectURL + "/tally"
+)
+
+// Pagination of lists
+const (
+	DEFAULT_LIMIT = 20
+	MAX_LIMIT     = 100
+)
+
+// MAX_BODY_SIZE is the limit of a request body, a ballot with its proof is a few kilobytes
+const MAX_BODY_SIZE = 1 << 20
+
+// Controller serves the resources of subjects under /v2
+type Controller struct {
+	handlers []controller.Handler
+	*zkvote.Operator
+}
+
+// New ...
+func New(op *zkvote.Operator) (*Controller, error) {
+	controller := &Controller{
+		Operator: op,
+	}
+	controller.registerHandler()
+
+	return controller, nil
+}
+
+// listSubjects lists the subjects ordered by hash
+func (c *Controller) listSubjects(rw http.ResponseWriter, req *http.Request) {
+	offset, limit, err := getPagination(req)
+	if err != nil {
+		c.writeError(rw, http.StatusBadRequest, subjectModel.CodeInvalidRequest, err)
+		return
+	}
+
+	subjects, err := c.Manager.GetSubjectList()
+	if err != nil {
+		c.writeError(rw, http.StatusInternalServerError, subjectModel.CodeInternal, err)
+		return
+	}
+	sort.Slice(subjects, func(i, j int) bool {
+		return subjects[i].HashHex().String() < subjects[j].HashHex().String()
+	})
+
+	start, end := pageRange(len(subjects), offset, limit)
+	items := make([]map[string]interface{}, 0, end-start)
+	for _, s := range subjects[start:end] {
+		items = append(items, s.JSON())
+	}
+
+	c.writeResponse(rw, http.StatusOK, newPage(items, len(subjects), offset, limit))
+}
+
+func (c *Controller) propose(rw http.ResponseWriter, req *http.Request) {
+	var body subjectModel.ProposeBody
+	if !c.decodeBody(rw, req, &body) {
+		return
+	}
+	if 0 == len(body.Title) {
+		c.writeError(rw, http.StatusBadRequest, subjectModel.CodeInvalidRequest, fmt.Errorf("title is required"))
+		return
+	}
+	if !validIdentityCommitment(body.IdentityCommitment) {
+		c.writeError(rw, http.StatusBadRequest, subjectModel.CodeInvalidRequest, fmt.Errorf("invalid identity commitment"))
+		return
+	}
+
+	s, err := c.Propose(body.Title, body.Description, body.IdentityCommitment,
+		subject.WithOptions(body.Options...),
+		subject.WithPeriod(body.OpenAt, body.CloseAt),
+		subject.WithTreeLevel(body.TreeLevel, body.VerificationKey))
+	if err != nil {
+		c.writeManagerError(rw, err, http.StatusUnprocessableEntity, subjectModel.CodeProposalRejected)
+		return
+	}
+
+	rw.Header().Set("Location", operationID+"/"+s.HashHex().String())
+	c.writeResponse(rw, http.StatusCreated, s.JSON())
+}
+
+func (c *Controller) getSubject(rw http.ResponseWriter, req *http.Request) {
+	s, err := c.Manager.GetSubject(mux.Vars(req)["hash"])
+	if err != nil {
+		c.writeManagerError(rw, err, http.StatusInternalServerError, subjectModel.CodeInternal)
+		return
+	}
+
+	c.writeResponse(rw, http.StatusOK, s.JSON())
+}
+
+// listMembers lists the identity commitments in the order of the tree
+func (c *Controller) listMembers(rw http.ResponseWriter, req *http.Request) {
+	offset, limit, err := getPagination(req)
+	if err != nil {
+		c.writeError(rw, http.StatusBadRequest, subjectModel.CodeInvalidRequest, err)
+		return
+	}
+
+	subjectHash := mux.Vars(req)["hash"]
+	members, err := c.GetMembers(subjectHash)
+	if err != nil {
+		c.writeManagerError(rw, err, http.StatusInternalServerError, subjectModel.CodeInternal)
+		return
+	}
+
+	start, end := pageRange(len(members), offset, limit)
+	items := make([]*subjectModel.Member, 0, end-start)
+	for i := start; i < end; i++ {
+		items = append(items, &subjectModel.Member{
+			SubjectHash:        utils.Remove0x(subjectHash),
+			IdentityCommitment: members[i],
+			Index:              i,
+		})
+	}
+
+	c.writeResponse(rw, http.StatusOK, newPage(items, len(members), offset, limit))
+}
+
+// join inserts an identity, the response is 202 if the sequencer of the subject hasn't inserted it yet
+func (c *Controller) join(rw http.ResponseWriter, req *http.Request) {
+	var body subjectModel.MemberBody
+	if !c.decodeBody(rw, req, &body) {
+		return
+	}
+	if !validIdentityCommitment(body.IdentityCommitment) {
+		c.writeError(rw, http.StatusBadRequest, subjectModel.CodeInvalidRequest, fmt.Errorf("invalid identity commitment"))
+		return
+	}
+
+	subjectHash := utils.Remove0x(mux.Vars(req)["hash"])
+	presented := body.Credential
+	if 0 == len(presented) {
+		presented = body.Admission
+	}
+	err := c.Join(subjectHash, body.IdentityCommitment, presented)
+	if err != nil {
+		c.writeManagerError(rw, err, http.StatusUnprocessableEntity, subjectModel.CodeMembershipRejected)
+		return
+	}
+
+	member := &subjectModel.Member{SubjectHash: subjectHash, IdentityCommitment: body.IdentityCommitment, Index: -1}
+	members, _ := c.GetMembers(subjectHash)
+	identity := id.NewIdentity(body.IdentityCommitment)
+	for i, m := range members {
+		if identity.Equal(id.NewIdentity(m)) {
+			member.IdentityCommitment = m
+			member.Index = i
+			break
+		}
+	}
+	if 0 > member.Index {
+		c.writeResponse(rw, http.StatusAccepted, member)
+		return
+	}
+	c.writeResponse(rw, http.StatusCreated, member)
+}
+
+// vote casts a ballot, the body is the ballot with its proof
+func (c *Controller) vote(rw http.ResponseWriter, req *http.Request) {
+	var raw json.RawMessage
+	if !c.decodeBody(rw, req, &raw) {
+		return
+	}
+	ballot, err := ba.NewBallot(string(raw))
+	if err != nil {
+		c.writeError(rw, http.StatusBadRequest, subjectModel.CodeInvalidRequest, err)
+		return
+	}
+
+	subjectHash := utils.Remove0x(mux.Vars(req)["hash"])
+	err = c.Vote(subjectHash, string(raw))
+	if err != nil {
+		c.writeManagerError(rw, err, http.StatusUnprocessableEntity, subjectModel.CodeBallotRejected)
+		return
+	}
+
+	c.writeResponse(rw, http.StatusCreated, &subjectModel.BallotReceipt{
+		SubjectHash:   subjectHash,
+		NullifierHash: ballot.NullifierHash,
+	})
+}
+
+func (c *Controller) tally(rw http.ResponseWriter, req *http.Request) {
+	subjectHash := mux.Vars(req)["hash"]
+	s, err := c.Manager.GetSubject(subjectHash)
+	if err != nil {
+		c.writeManagerError(rw, err, http.StatusInternalServerError, subjectModel.CodeInternal)
+		return
+	}
+	votes, err := c.Open(subjectHash)
+	if err != nil {
+		c.writeManagerError(rw, err, http.StatusInternalServerError, subjectModel.CodeInternal)
+		return
+	}
+
+	tally := &subjectModel.Tally{
+		SubjectHash: s.HashHex().String(),
+		State:       string(s.GetState(time.Now())),
+		Options:     make([]*subjectModel.OptionTally, 0, len(votes)),
+	}
+	for i, o := range s.GetOptions() {
+		if i >= len(votes) {
+			break
+		}
+		tally.Options = append(tally.Options, &subjectModel.OptionTally{Option: o, Votes: votes[i]})
+		tally.Total += votes[i]
+	}
+
+	c.writeResponse(rw, http.StatusOK, tally)
+}
+
+//
+// internal functions
+//
+
+// decodeBody decodes a JSON body into v, an error response is written if it fails
+func (c *Controller) decodeBody(rw http.ResponseWriter, req *http.Request, v interface{}) bool {
+	if contentType := req.Header.Get("Content-Type"); 0 != len(contentType) {
+		mediaType, _, err := mime.ParseMediaType(contentType)
+		if err != nil || "application/json" != mediaType {
+			c.writeError(rw, http.StatusUnsupportedMediaType, subjectModel.CodeUnsupportedMediaType,
+				fmt.Errorf("content type must be application/json"))
+			return false
+		}
+	}
+
+	decoder := json.NewDecoder(http.MaxBytesReader(rw, req.Body, MAX_BODY_SIZE))
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(v)
+	if err != nil {
+		c.writeError(rw, http.StatusBadRequest, subjectModel.CodeInvalidRequest, fmt.Errorf("invalid body, %v", err))
+		return false
+	}
+	return true
+}
+
+// writeManagerError writes the error of the manager with its status, or the fallback for an unknown one
+func (c *Controller) writeManagerError(rw http.ResponseWriter, err error, fallbackStatus int, fallbackCode string) {
+	switch {
+	case errors.Is(err, manager.ErrSubjectNotFound):
+		c.writeError(rw, http.StatusNotFound, subjectModel.CodeSubjectNotFound, err)
+	case errors.Is(err, manager.ErrSubjectExisted):
+		c.writeError(rw, http.StatusConflict, subjectModel.CodeSubjectExists, err)
+	case errors.Is(err, manager.ErrSubjectClosed):
+		c.writeError(rw, http.StatusConflict, subjectModel.CodeSubjectClosed, err)
+	default:
+		c.writeError(rw, fallbackStatus, fallbackCode, err)
+	}
+}
+
+// writeError writes the error model with the status
+func (c *Controller) writeError(rw http.ResponseWriter, statusCode int, code string, err error) {
+	c.writeResponse(rw, statusCode, subjectModel.ErrorResponse{
+		Error: &subjectModel.Error{Code: code, Message: err.Error()},
+	})
+}
+
+// writeResponse writes interface value to response with the status
+func (c *Controller) writeResponse(rw http.ResponseWriter, statusCode int, v interface{}) {
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(statusCode)
+	err := json.NewEncoder(rw).Encode(v)
+	// as of now, just log errors for writing response
+	if err != nil {
+		utils.LogWarningf("Unable to send response, %s", err)
+	}
+}
+
+// getPagination returns the offset and limit of a list request
+func getPagination(req *http.Request) (int, int, error) {
+	offset, limit := 0, DEFAULT_LIMIT
+	var err error
+	query := req.URL.Query()
+	if v := query.Get("offset"); 0 != len(v) {
+		offset, err = strconv.Atoi(v)
+		if err != nil || 0 > offset {
+			return 0, 0, fmt.Errorf("invalid offset, %v", v)
+		}
+	}
+	if v := query.Get("limit"); 0 != len(v) {
+		limit, err = strconv.Atoi(v)
+		if err != nil || 0 >= limit || MAX_LIMIT < limit {
+			return 0, 0, fmt.Errorf("limit must be between 1 and %d", MAX_LIMIT)
+		}
+	}
+	return offset, limit, nil
+}
+
+// pageRange returns the bounds of a page in a list of total items
+func pageRange(total int, offset int, limit int) (int, int) {
+	if offset > total {
+		offset = total
+	}
+	end := offset + limit
+	if end > total {
+		end = total
+	}
+	return offset, end
+}
+
+func newPage(items interface{}, total int, offset int, limit int) *subjectModel.Page {
+	page := &subjectModel.Page{Items: items, Total: total, Offset: offset, Limit: limit}
+	if offset+limit < total {
+		next := offset + limit
+		page.Next = &next
+	}
+	return page
+}
+
+func validIdentityCommitment(identityCommitment string) bool {
+	identity := id.NewIdentity(identityCommitment)
+	return nil != identity && identity.IsValid()
+}
+
+// GetRESTHandlers get all controller API handler available for this protocol service
+func (c *Controller) GetRESTHandlers() []controller.Handler {
+	return c.handlers
+}
+
+// registerHandler register handlers to be exposed from this protocol service as REST API endpoints
+func (c *Controller) registerHandler() {
+	c.handlers = []controller.Handler{
+		controller.NewHTTPHandler(subjectsURL, http.MethodGet, c.listSubjects).WithDoc(listSubjectsDoc),
+		controller.NewHTTPHandler(subjectsURL, http.MethodPost, c.propose).WithDoc(proposeDoc).WithRole(auth.RoleProposer),
+		controller.NewHTTPHandler(subjectURL, http.MethodGet, c.getSubject).WithDoc(getSubjectDoc),
+		controller.NewHTTPHandler(membersURL, http.MethodGet, c.listMembers).WithDoc(listMembersDoc),
+		controller.NewHTTPHandler(membersURL, http.MethodPost, c.join).WithDoc(joinDoc).WithRole(auth.RoleVoter),
+		controller.NewHTTPHandler(ballotsURL, http.MethodPost, c.vote).WithDoc(voteDoc).WithRole(auth.RoleVoter),
+		controller.NewHTTPHandler(tallyURL, http.MethodGet, c.tally).WithDoc(tallyDoc),
+	}
+}
@@ -0,0 +1,67 @@
+package subject
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	subjectModel "github.com/unitychain/zkvote-node/restapi/model/v2/subject"
+)
+
+func TestPagination(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/v2/subjects?offset=20&limit=10", nil)
+	offset, limit, err := getPagination(req)
+	assert.Nil(t, err)
+	assert.Equal(t, 20, offset)
+	assert.Equal(t, 10, limit)
+
+	for _, q := range []string{"offset=-1", "limit=0", "limit=101", "offset=a"} {
+		_, _, err = getPagination(httptest.NewRequest(http.MethodGet, "/v2/subjects?"+q, nil))
+		assert.NotNil(t, err, q)
+	}
+
+	start, end := pageRange(25, 20, 10)
+	assert.Equal(t, 20, start)
+	assert.Equal(t, 25, end)
+	start, end = pageRange(5, 20, 10)
+	assert.Equal(t, 5, start)
+	assert.Equal(t, 5, end)
+
+	assert.Nil(t, newPage(nil, 25, 20, 10).Next)
+	assert.Equal(t, 10, *newPage(nil, 25, 0, 10).Next)
+}
+
+func TestDecodeBody(t *testing.T) {
+	c := &Controller{}
+	tests := []struct {
+		contentType string
+		body        string
+		status      int
+		code        string
+	}{
+		{"text/plain", `{"title":"a"}`, http.StatusUnsupportedMediaType, subjectModel.CodeUnsupportedMediaType},
+		{"application/json", `{"title":`, http.StatusBadRequest, subjectModel.CodeInvalidRequest},
+		{"application/json", `{"unknown":"a"}`, http.StatusBadRequest, subjectModel.CodeInvalidRequest},
+	}
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodPost, subjectsURL, strings.NewReader(test.body))
+		req.Header.Set("Content-Type", test.contentType)
+		rw := httptest.NewRecorder()
+
+		var body subjectModel.ProposeBody
+		assert.False(t, c.decodeBody(rw, req, &body))
+		assert.Equal(t, test.status, rw.Code)
+		var resp subjectModel.ErrorResponse
+		assert.Nil(t, json.Unmarshal(rw.Body.Bytes(), &resp))
+		assert.Equal(t, test.code, resp.Error.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, subjectsURL, strings.NewReader(`{"title":"a","options":["x","y"]}`))
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	var body subjectModel.ProposeBody
+	assert.True(t, c.decodeBody(httptest.NewRecorder(), req, &body))
+	assert.Equal(t, []string{"x", "y"}, body.Options)
+}
@@ -0,0 +1,77 @@
+package subject
+
+import (
+	"net/http"
+
+	"github.com/unitychain/zkvote-node/restapi/controller"
+	subjectModel "github.com/unitychain/zkvote-node/restapi/model/v2/subject"
+	ba "github.com/unitychain/zkvote-node/zkvote/model/ballot"
+)
+
+var tags = []string{"v2"}
+
+// pagination are the query parameters of a list
+type pagination struct {
+	Offset int `json:"offset,omitempty"`
+	Limit  int `json:"limit,omitempty"`
+}
+
+// withErrors adds the error model to the responses
+func withErrors(responses map[int]interface{}, statuses ...int) map[int]interface{} {
+	for _, status := range append(statuses, http.StatusInternalServerError) {
+		responses[status] = subjectModel.ErrorResponse{}
+	}
+	return responses
+}
+
+var listSubjectsDoc = &controller.Doc{
+	Summary:   "List the subjects ordered by hash",
+	Tags:      tags,
+	Query:     pagination{},
+	Responses: withErrors(map[int]interface{}{http.StatusOK: subjectModel.Page{}}, http.StatusBadRequest),
+}
+
+var proposeDoc = &controller.Doc{
+	Summary: "Propose a subject",
+	Tags:    tags,
+	Body:    subjectModel.ProposeBody{},
+	Responses: withErrors(map[int]interface{}{http.StatusCreated: map[string]interface{}{}},
+		http.StatusBadRequest, http.StatusConflict, http.StatusUnsupportedMediaType, http.StatusUnprocessableEntity),
+}
+
+var getSubjectDoc = &controller.Doc{
+	Summary:   "Get a subject",
+	Tags:      tags,
+	Responses: withErrors(map[int]interface{}{http.StatusOK: map[string]interface{}{}}, http.StatusNotFound),
+}
+
+var listMembersDoc = &controller.Doc{
+	Summary:   "List the identity commitments of a subject in the order of the tree",
+	Tags:      tags,
+	Query:     pagination{},
+	Responses: withErrors(map[int]interface{}{http.StatusOK: subjectModel.Page{}}, http.StatusBadRequest, http.StatusNotFound),
+}
+
+var joinDoc = &controller.Doc{
+	Summary: "Join a subject, 202 if the identity commitment is waiting for the sequencer",
+	Tags:    tags,
+	Body:    subjectModel.MemberBody{},
+	Responses: withErrors(map[int]interface{}{
+		http.StatusCreated:  subjectModel.Member{},
+		http.StatusAccepted: subjectModel.Member{},
+	}, http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusUnsupportedMediaType, http.StatusUnprocessableEntity),
+}
+
+var voteDoc = &controller.Doc{
+	Summary: "Cast a ballot with its proof",
+	Tags:    tags,
+	Body:    ba.Ballot{},
+	Responses: withErrors(map[int]interface{}{http.StatusCreated: subjectModel.BallotReceipt{}},
+		http.StatusBadRequest, http.StatusNotFound, http.StatusUnsupportedMediaType, http.StatusUnprocessableEntity),
+}
+
+var tallyDoc = &controller.Doc{
+	Summary:   "Get the tally of a subject",
+	Tags:      tags,
+	Responses: withErrors(map[int]interface{}{http.StatusOK: subjectModel.Tally{}}, http.StatusNotFound),
+}
@@ -0,0 +1,9 @@
+package admin
+
+// Error is the body of a failed request
+//
+// swagger:response errorResponse
+type Error struct {
+	// in: body
+	Message string `json:"message"`
+}
@@ -0,0 +1,67 @@
+package rollup
+
+// A GenericError is the default error message that is generated.
+// For certain status codes there are more appropriate error structures.
+//
+// swagger:response genericError
+type GenericError struct {
+	// in: body
+	Body struct {
+		Code    int32  `json:"code"`
+		Message string `json:"message"`
+	} `json:"body"`
+}
+
+// IndexRequest ...
+type IndexRequest struct{}
+
+// SubmitRequest ...
+type SubmitRequest struct {
+	*SubmitParams
+}
+
+// TallyRequest ...
+type TallyRequest struct {
+	*TallyParams
+}
+
+// SubmitParams ...
+type SubmitParams struct {
+	SubjectHash string `json:"subjectHash"`
+	PrevRoot    string `json:"prevRoot"`
+	Proof       string `json:"proof"`
+}
+
+// TallyParams ...
+type TallyParams struct {
+	SubjectHash string `json:"subjectHash"`
+}
+
+// IndexResponse ...
+type IndexResponse struct {
+	// in: body
+	Results struct {
+		Root     string          `json:"root"`
+		Subjects []*SubjectTally `json:"subjects"`
+	} `json:"results"`
+}
+
+// SubmitResponse ...
+type SubmitResponse struct {
+	// in: body
+	Results struct {
+		Root string `json:"root"`
+	} `json:"results"`
+}
+
+// TallyResponse ...
+type TallyResponse struct {
+	// in: body
+	Results *SubjectTally `json:"results"`
+}
+
+// SubjectTally ...
+type SubjectTally struct {
+	SubjectHash string `json:"subjectHash"`
+	Ballots     []int  `json:"ballots"`
+}
@@ -1,5 +1,10 @@
 package subject
 
+import (
+	"github.com/unitychain/zkvote-node/zkvote/audit"
+	"github.com/unitychain/zkvote-node/zkvote/operator/service/manager/voter"
+)
+
 // A GenericError is the default error message that is generated.
 // For certain status codes there are more appropriate error structures.
 //
@@ -25,6 +30,21 @@ type JoinRequest struct {
 	*JoinParams
 }
 
+// AdmitRequest ...
+type AdmitRequest struct {
+	*AdmitParams
+}
+
+// IssueCredentialRequest ...
+type IssueCredentialRequest struct {
+	*IssueCredentialParams
+}
+
+// RevokeCredentialRequest ...
+type RevokeCredentialRequest struct {
+	*RevokeCredentialParams
+}
+
 // VoteRequest ...
 type VoteRequest struct {
 	*VoteParams
@@ -40,17 +60,66 @@ type GetIdentityPathRequest struct {
 	*GetIdentityPathParams
 }
 
+// ExportRequest ...
+type ExportRequest struct {
+	*ExportParams
+}
+
+// AuditRequest ...
+type AuditRequest struct {
+	*AuditParams
+}
+
+// ConflictsRequest ...
+type ConflictsRequest struct {
+	*ConflictsParams
+}
+
+// EventsRequest ...
+type EventsRequest struct {
+	*EventsParams
+}
+
 // ProposeParams ...
 type ProposeParams struct {
-	Title              string `json:"title"`
-	Description        string `json:"description"`
-	IdentityCommitment string `json:"identityCommitment"`
+	Title              string   `json:"title"`
+	Description        string   `json:"description"`
+	IdentityCommitment string   `json:"identityCommitment"`
+	Options            []string `json:"-"`
+	OpenAt             int64    `json:"openAt,string,omitempty"`
+	CloseAt            int64    `json:"closeAt,string,omitempty"`
+	TreeLevel          uint8    `json:"treeLevel,string,omitempty"`
+	VerificationKey    string   `json:"verificationKey"`
 }
 
 // JoinParams ...
+// Credential is a verifiable credential in JSON-LD or JWT issued by the proposer of the subject,
+// Admission is used if there isn't one
 type JoinParams struct {
 	SubjectHash        string `json:"subjectHash"`
 	IdentityCommitment string `json:"identityCommitment"`
+	Credential         string `json:"credential"`
+	Admission          string `json:"admission"`
+}
+
+// AdmitParams ...
+type AdmitParams struct {
+	SubjectHash        string `json:"subjectHash"`
+	IdentityCommitment string `json:"identityCommitment"`
+}
+
+// IssueCredentialParams ...
+// Format is jsonld or jwt, ExpiresAt is in unix time and 0 for never
+type IssueCredentialParams struct {
+	SubjectHash        string `json:"subjectHash"`
+	IdentityCommitment string `json:"identityCommitment"`
+	Format             string `json:"format"`
+	ExpiresAt          int64  `json:"expiresAt,string,omitempty"`
+}
+
+// RevokeCredentialParams ...
+type RevokeCredentialParams struct {
+	CredentialID string `json:"credentialId"`
 }
 
 // VoteParams ...
@@ -70,10 +139,32 @@ type GetIdentityPathParams struct {
 	IdentityCommitment string `json:"identityCommitment"`
 }
 
+// ExportParams ...
+type ExportParams struct {
+	SubjectHash string `json:"subjectHash"`
+}
+
+// AuditParams ...
+type AuditParams struct {
+	SubjectHash string `json:"subjectHash"`
+}
+
+// ConflictsParams ...
+type ConflictsParams struct {
+	SubjectHash string `json:"subjectHash"`
+}
+
+// EventsParams ...
+// SubjectHash filters the events, Cursor is the id of the last event received
+type EventsParams struct {
+	SubjectHash string `json:"subjectHash"`
+	Cursor      string `json:"cursor"`
+}
+
 // IndexResponse ...
 type IndexResponse struct {
 	// in: body
-	Results []map[string]string `json:"results"`
+	Results []map[string]interface{} `json:"results"`
 }
 
 // ProposeResponse ...
@@ -88,6 +179,24 @@ type JoinResponse struct {
 	Results string `json:"results"`
 }
 
+// AdmitResponse ...
+type AdmitResponse struct {
+	// in: body
+	Results string `json:"results"`
+}
+
+// IssueCredentialResponse ...
+type IssueCredentialResponse struct {
+	// in: body
+	Results string `json:"results"`
+}
+
+// RevokeCredentialResponse ...
+type RevokeCredentialResponse struct {
+	// in: body
+	Results string `json:"results"`
+}
+
 // VoteResponse ...
 type VoteResponse struct {
 	// in: body
@@ -97,10 +206,13 @@ type VoteResponse struct {
 // OpenResponse ...
 type OpenResponse struct {
 	// in: body
-	Results struct {
-		Yes int `json:"yes"`
-		No  int `json:"no"`
-	} `json:"results"`
+	Results []*OptionTally `json:"results"`
+}
+
+// OptionTally ...
+type OptionTally struct {
+	Option string `json:"option"`
+	Votes  int    `json:"votes"`
 }
 
 // GetIdentityPathResponse ...
@@ -112,3 +224,21 @@ type GetIdentityPathResponse struct {
 		Root  string   `json:"root"`
 	} `json:"results"`
 }
+
+// ImportResponse ...
+type ImportResponse struct {
+	// in: body
+	Results map[string]interface{} `json:"results"`
+}
+
+// AuditResponse ...
+type AuditResponse struct {
+	// in: body
+	Results *audit.Report `json:"results"`
+}
+
+// ConflictsResponse ...
+type ConflictsResponse struct {
+	// in: body
+	Results []*voter.Conflict `json:"results"`
+}
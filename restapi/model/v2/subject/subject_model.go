@@ -0,0 +1,88 @@
+package subject
+
+// Codes of the errors, which clients can rely on instead of the messages
+const (
+	CodeInvalidRequest       = "invalid_request"
+	CodeUnsupportedMediaType = "unsupported_media_type"
+	CodeSubjectNotFound      = "subject_not_found"
+	CodeSubjectExists        = "subject_exists"
+	CodeSubjectClosed        = "subject_closed"
+	CodeProposalRejected     = "proposal_rejected"
+	CodeMembershipRejected   = "membership_rejected"
+	CodeBallotRejected       = "ballot_rejected"
+	CodeInternal             = "internal_error"
+)
+
+// Error is the body of every failed request
+//
+// swagger:response errorResponse
+type ErrorResponse struct {
+	// in: body
+	Error *Error `json:"error"`
+}
+
+// Error ...
+// Code is machine-readable, Message is for humans and may change
+type Error struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// ProposeBody is the body of POST /v2/subjects
+// OpenAt and CloseAt are in unix time and 0 for unbounded
+type ProposeBody struct {
+	Title              string   `json:"title"`
+	Description        string   `json:"description"`
+	IdentityCommitment string   `json:"identityCommitment"`
+	Options            []string `json:"options,omitempty"`
+	OpenAt             int64    `json:"openAt,omitempty"`
+	CloseAt            int64    `json:"closeAt,omitempty"`
+	TreeLevel          uint8    `json:"treeLevel,omitempty"`
+	VerificationKey    string   `json:"verificationKey,omitempty"`
+}
+
+// MemberBody is the body of POST /v2/subjects/{hash}/members
+// Credential is a verifiable credential in JSON-LD or JWT issued by the proposer of the subject,
+// Admission is used if there isn't one
+type MemberBody struct {
+	IdentityCommitment string `json:"identityCommitment"`
+	Credential         string `json:"credential,omitempty"`
+	Admission          string `json:"admission,omitempty"`
+}
+
+// Page is a paginated list
+// Next is the offset of the next page, omitted on the last page
+type Page struct {
+	Items  interface{} `json:"items"`
+	Total  int         `json:"total"`
+	Offset int         `json:"offset"`
+	Limit  int         `json:"limit"`
+	Next   *int        `json:"next,omitempty"`
+}
+
+// Member ...
+type Member struct {
+	SubjectHash        string `json:"subjectHash"`
+	IdentityCommitment string `json:"identityCommitment"`
+	Index              int    `json:"index"`
+}
+
+// BallotReceipt ...
+type BallotReceipt struct {
+	SubjectHash   string `json:"subjectHash"`
+	NullifierHash string `json:"nullifierHash"`
+}
+
+// Tally ...
+type Tally struct {
+	SubjectHash string         `json:"subjectHash"`
+	State       string         `json:"state"`
+	Total       int            `json:"total"`
+	Options     []*OptionTally `json:"options"`
+}
+
+// OptionTally ...
+type OptionTally struct {
+	Option string `json:"option"`
+	Votes  int    `json:"votes"`
+}
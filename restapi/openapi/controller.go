@@ -0,0 +1,72 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/unitychain/zkvote-node/restapi/auth"
+	"github.com/unitychain/zkvote-node/restapi/controller"
+	"github.com/unitychain/zkvote-node/zkvote/common/utils"
+)
+
+const (
+	specURL     = "/openapi.json"
+	explorerURL = "/docs"
+)
+
+// Controller serves the specification of the REST API and an explorer of it
+type Controller struct {
+	handlers []controller.Handler
+	spec     []byte
+}
+
+// New generates the specification of the handlers and of its own
+func New(title string, handlers []controller.Handler) (*Controller, error) {
+	c := &Controller{}
+	c.registerHandler()
+
+	spec, undocumented := Generate(title, utils.ClientVersion, append(handlers, c.handlers...))
+	for _, route := range undocumented {
+		utils.LogWarningf("OpenAPI, undocumented route %v", route)
+	}
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate OpenAPI specification: %w", err)
+	}
+	c.spec = data
+	return c, nil
+}
+
+func (c *Controller) getSpec(rw http.ResponseWriter, req *http.Request) {
+	rw.Header().Set("Content-Type", "application/json")
+	rw.Write(c.spec)
+}
+
+func (c *Controller) explore(rw http.ResponseWriter, req *http.Request) {
+	rw.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprint(rw, explorerPage)
+}
+
+// GetRESTHandlers get all controller API handler available for this protocol service
+func (c *Controller) GetRESTHandlers() []controller.Handler {
+	return c.handlers
+}
+
+// registerHandler register handlers to be exposed from this protocol service as REST API endpoints
+func (c *Controller) registerHandler() {
+	tags := []string{"docs"}
+	c.handlers = []controller.Handler{
+		controller.NewHTTPHandler(specURL, http.MethodGet, c.getSpec).WithDoc(&controller.Doc{
+			Summary:   "Get the OpenAPI specification of this node",
+			Tags:      tags,
+			Responses: map[int]interface{}{http.StatusOK: map[string]interface{}{}},
+		}).WithRole(auth.RolePublic),
+		controller.NewHTTPHandler(explorerURL, http.MethodGet, c.explore).WithDoc(&controller.Doc{
+			Summary:     "Explore the REST API of this node",
+			Tags:        tags,
+			ContentType: "text/html",
+			Responses:   map[int]interface{}{http.StatusOK: ""},
+		}).WithRole(auth.RolePublic),
+	}
+}
@@ -0,0 +1,135 @@
+package openapi
+
+// explorerPage lists the operations of /openapi.json and sends requests to them,
+// it's self-contained so it works on nodes without internet access
+const explorerPage = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>zkvote API explorer</title>
+<style>
+body { font-family: sans-serif; margin: 2em; max-width: 960px; }
+h2 { border-bottom: 1px solid #ccc; text-transform: capitalize; }
+details { border: 1px solid #ddd; border-radius: 4px; margin: .5em 0; padding: .5em; }
+summary { cursor: pointer; }
+.method { display: inline-block; width: 4em; font-weight: bold; text-transform: uppercase; }
+.get { color: #2a7ae2; } .post { color: #2a9d4a; } .put { color: #c98a00; } .delete { color: #c0392b; }
+label { display: block; margin: .3em 0; }
+label span { display: inline-block; width: 12em; }
+textarea { width: 100%; height: 8em; font-family: monospace; }
+pre { background: #f6f6f6; padding: .5em; overflow: auto; max-height: 20em; }
+</style>
+</head>
+<body>
+<h1>zkvote API explorer</h1>
+<p>Generated from <a href="/openapi.json">/openapi.json</a></p>
+<label><span>API key</span><input id="apiKey" type="password"></label>
+<label><span>Bearer token</span><input id="bearer" type="password"></label>
+<div id="operations"></div>
+<script>
+function resolve(spec, schema) {
+  if (schema && schema.$ref) {
+    return spec.components.schemas[schema.$ref.split('/').pop()];
+  }
+  return schema || {};
+}
+
+function example(spec, schema, depth) {
+  schema = resolve(spec, schema);
+  if (depth > 4) { return null; }
+  switch (schema.type) {
+  case 'object':
+    var o = {};
+    Object.keys(schema.properties || {}).forEach(function (k) { o[k] = example(spec, schema.properties[k], depth + 1); });
+    return o;
+  case 'array': return [];
+  case 'integer': case 'number': return 0;
+  case 'boolean': return false;
+  case 'string': return '';
+  }
+  return null;
+}
+
+function field(form, name, type) {
+  var label = document.createElement('label');
+  label.innerHTML = '<span></span>';
+  label.firstChild.textContent = name;
+  var input = document.createElement('input');
+  input.name = name;
+  input.type = type;
+  label.appendChild(input);
+  form.appendChild(label);
+}
+
+function render(spec, path, method, op) {
+  var d = document.createElement('details');
+  var s = document.createElement('summary');
+  s.innerHTML = '<span class="method ' + method + '">' + method + '</span> <code></code> ';
+  s.querySelector('code').textContent = path;
+  s.appendChild(document.createTextNode(op.summary || ''));
+  d.appendChild(s);
+
+  var form = document.createElement('form');
+  (op.parameters || []).forEach(function (p) { field(form, p.in + ':' + p.name, 'text'); });
+  var content = op.requestBody ? op.requestBody.content : {};
+  var body = null;
+  if (content['application/json']) {
+    body = document.createElement('textarea');
+    body.value = JSON.stringify(example(spec, content['application/json'].schema, 0), null, 2);
+    form.appendChild(body);
+  } else if (content['multipart/form-data']) {
+    var props = resolve(spec, content['multipart/form-data'].schema).properties || {};
+    Object.keys(props).forEach(function (k) { field(form, 'form:' + k, props[k].format === 'binary' ? 'file' : 'text'); });
+  }
+  var send = document.createElement('button');
+  send.textContent = 'Send';
+  form.appendChild(send);
+  var out = document.createElement('pre');
+  form.appendChild(out);
+  d.appendChild(form);
+
+  form.onsubmit = function (e) {
+    e.preventDefault();
+    var url = path, query = new URLSearchParams(), data = new FormData(), hasForm = false;
+    Array.prototype.forEach.call(form.querySelectorAll('input'), function (input) {
+      var parts = input.name.split(':'), value = input.type === 'file' ? input.files[0] : input.value;
+      if (!value) { return; }
+      if (parts[0] === 'path') { url = url.replace('{' + parts[1] + '}', encodeURIComponent(value)); }
+      if (parts[0] === 'query') { query.append(parts[1], value); }
+      if (parts[0] === 'form') { data.append(parts[1], value); hasForm = true; }
+    });
+    var init = { method: method.toUpperCase(), headers: {} };
+    var apiKey = document.getElementById('apiKey').value, bearer = document.getElementById('bearer').value;
+    if (apiKey) { init.headers['X-Api-Key'] = apiKey; }
+    if (bearer) { init.headers['Authorization'] = 'Bearer ' + bearer; }
+    if (body) { init.body = body.value; init.headers['Content-Type'] = 'application/json'; }
+    if (hasForm) { init.body = data; }
+    var qs = query.toString();
+    out.textContent = '...';
+    fetch(url + (qs ? '?' + qs : ''), init).then(function (resp) {
+      return resp.text().then(function (text) { out.textContent = resp.status + ' ' + resp.statusText + '\n\n' + text; });
+    }).catch(function (err) { out.textContent = err; });
+  };
+  return d;
+}
+
+fetch('/openapi.json').then(function (resp) { return resp.json(); }).then(function (spec) {
+  var groups = {};
+  Object.keys(spec.paths).sort().forEach(function (path) {
+    Object.keys(spec.paths[path]).forEach(function (method) {
+      var op = spec.paths[path][method], tag = (op.tags || ['default'])[0];
+      (groups[tag] = groups[tag] || []).push(render(spec, path, method, op));
+    });
+  });
+  var root = document.getElementById('operations');
+  Object.keys(groups).sort().forEach(function (tag) {
+    var h = document.createElement('h2');
+    h.textContent = tag;
+    root.appendChild(h);
+    groups[tag].forEach(function (d) { root.appendChild(d); });
+  });
+});
+</script>
+</body>
+</html>
+`
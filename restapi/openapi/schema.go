@@ -0,0 +1,130 @@
+package openapi
+
+import (
+	"encoding/json"
+	"path"
+	"reflect"
+	"strings"
+)
+
+// Schema is a JSON schema of a model
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+}
+
+// generator collects the schemas of the named structs as components
+type generator struct {
+	schemas map[string]*Schema
+}
+
+var rawMessageType = reflect.TypeOf(json.RawMessage{})
+
+// schemaOf returns the schema of a type, a named struct is referred to as a component
+func (g *generator) schemaOf(t reflect.Type) *Schema {
+	for reflect.Ptr == t.Kind() {
+		t = t.Elem()
+	}
+	if rawMessageType == t {
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if 0 == len(t.Name()) {
+			return g.inline(t, false)
+		}
+		name := schemaName(t)
+		if _, ok := g.schemas[name]; !ok {
+			// Reserve the name first for recursive models
+			g.schemas[name] = &Schema{}
+			g.schemas[name] = g.inline(t, false)
+		}
+		return &Schema{Ref: "#/components/schemas/" + name}
+	case reflect.Slice, reflect.Array:
+		if reflect.Uint8 == t.Elem().Kind() {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: g.schemaOf(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schemaOf(t.Elem())}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	}
+	// interface{} is any value
+	return &Schema{}
+}
+
+// inline returns the object schema of a struct, the fields of embedded structs are promoted.
+// Bytes are binary in a form, e.g. an uploaded file.
+func (g *generator) inline(t reflect.Type, form bool) *Schema {
+	for reflect.Ptr == t.Kind() {
+		t = t.Elem()
+	}
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	if reflect.Struct != t.Kind() {
+		return g.schemaOf(t)
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := strings.Split(f.Tag.Get("json"), ",")
+		if "-" == tag[0] {
+			continue
+		}
+		if f.Anonymous && 0 == len(tag[0]) {
+			for name, p := range g.inline(f.Type, form).Properties {
+				s.Properties[name] = p
+			}
+			continue
+		}
+		if 0 != len(f.PkgPath) {
+			continue
+		}
+
+		name := f.Name
+		if 0 != len(tag[0]) {
+			name = tag[0]
+		}
+		p := g.schemaOf(f.Type)
+		for _, option := range tag[1:] {
+			if "string" == option {
+				p = &Schema{Type: "string"}
+			}
+		}
+		if form && "byte" == p.Format {
+			p = &Schema{Type: "string", Format: "binary"}
+		}
+		s.Properties[name] = p
+	}
+	return s
+}
+
+// schemaName names a model by its package, e.g. V2SubjectTally for restapi/model/v2/subject.Tally
+func schemaName(t reflect.Type) string {
+	pkg := path.Base(t.PkgPath())
+	if i := strings.Index(t.PkgPath(), "/restapi/model/"); 0 <= i {
+		pkg = t.PkgPath()[i+len("/restapi/model/"):]
+	}
+
+	name := ""
+	for _, p := range strings.Split(pkg, "/") {
+		name += strings.Title(p)
+	}
+	if strings.HasPrefix(t.Name(), strings.Title(path.Base(pkg))) {
+		name = strings.TrimSuffix(name, strings.Title(path.Base(pkg)))
+	}
+	return name + t.Name()
+}
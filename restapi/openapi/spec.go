@@ -0,0 +1,200 @@
+package openapi
+
+import (
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/unitychain/zkvote-node/restapi/auth"
+	"github.com/unitychain/zkvote-node/restapi/controller"
+)
+
+// VERSION is the version of the OpenAPI specification
+const VERSION = "3.0.3"
+
+// pathParam matches the variables of a mux path, e.g. {hash} or {id:[0-9]+}
+var pathParam = regexp.MustCompile(`\{([^}:]+)(:[^}]*)?\}`)
+
+// Spec is an OpenAPI document
+type Spec struct {
+	OpenAPI    string                           `json:"openapi"`
+	Info       *Info                            `json:"info"`
+	Paths      map[string]map[string]*Operation `json:"paths"`
+	Components *Components                      `json:"components"`
+}
+
+// Info ...
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+// Components ...
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes"`
+}
+
+// SecurityScheme ...
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	In           string `json:"in,omitempty"`
+	Name         string `json:"name,omitempty"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+}
+
+// securitySchemes are the credentials of auth.APIKeys and auth.JWT, client certificates are out of the scope of OpenAPI 3.0
+var securitySchemes = map[string]*SecurityScheme{
+	"apiKey": {Type: "apiKey", In: "header", Name: auth.HEADER_API_KEY},
+	"bearer": {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+}
+
+// Operation ...
+type Operation struct {
+	Summary     string                `json:"summary,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	OperationID string                `json:"operationId"`
+	Parameters  []*Parameter          `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]*Response  `json:"responses"`
+	Security    []map[string][]string `json:"security"`
+	Role        auth.Role             `json:"x-role,omitempty"` // required by the operation
+}
+
+// Parameter ...
+type Parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required,omitempty"`
+	Schema   *Schema `json:"schema"`
+}
+
+// RequestBody ...
+type RequestBody struct {
+	Required bool                  `json:"required,omitempty"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+// Response ...
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+// MediaType ...
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Generate documents the handlers, the undocumented ones are left out and returned by "METHOD path"
+func Generate(title string, version string, handlers []controller.Handler) (*Spec, []string) {
+	spec := &Spec{
+		OpenAPI:    VERSION,
+		Info:       &Info{Title: title, Version: version},
+		Paths:      make(map[string]map[string]*Operation),
+		Components: &Components{Schemas: make(map[string]*Schema), SecuritySchemes: securitySchemes},
+	}
+	g := &generator{schemas: spec.Components.Schemas}
+
+	undocumented := make([]string, 0)
+	for _, h := range handlers {
+		var doc *controller.Doc
+		if d, ok := h.(controller.Documented); ok {
+			doc = d.Doc()
+		}
+		if nil == doc {
+			undocumented = append(undocumented, h.Method()+" "+h.Path())
+			continue
+		}
+
+		path := pathParam.ReplaceAllString(h.Path(), "{$1}")
+		if _, ok := spec.Paths[path]; !ok {
+			spec.Paths[path] = make(map[string]*Operation)
+		}
+		spec.Paths[path][strings.ToLower(h.Method())] = g.operation(h, doc)
+	}
+	sort.Strings(undocumented)
+	return spec, undocumented
+}
+
+func (g *generator) operation(h controller.Handler, doc *controller.Doc) *Operation {
+	op := &Operation{
+		Summary:     doc.Summary,
+		Tags:        doc.Tags,
+		OperationID: operationID(h.Method(), h.Path()),
+		Parameters:  make([]*Parameter, 0),
+		Responses:   make(map[string]*Response),
+	}
+
+	for _, m := range pathParam.FindAllStringSubmatch(h.Path(), -1) {
+		op.Parameters = append(op.Parameters, &Parameter{Name: m[1], In: "path", Required: true, Schema: &Schema{Type: "string"}})
+	}
+	if nil != doc.Query {
+		query := g.inline(reflect.TypeOf(doc.Query), false)
+		for _, name := range sortedKeys(query.Properties) {
+			op.Parameters = append(op.Parameters, &Parameter{Name: name, In: "query", Schema: query.Properties[name]})
+		}
+	}
+	if nil != doc.Form {
+		op.RequestBody = &RequestBody{Content: map[string]*MediaType{
+			"multipart/form-data": {Schema: g.inline(reflect.TypeOf(doc.Form), true)},
+		}}
+	}
+	if nil != doc.Body {
+		op.RequestBody = &RequestBody{Required: true, Content: map[string]*MediaType{
+			"application/json": {Schema: g.schemaOf(reflect.TypeOf(doc.Body))},
+		}}
+	}
+
+	// Either credential is accepted, a public operation requires none
+	op.Security = make([]map[string][]string, 0)
+	if a, ok := h.(controller.Authorized); ok {
+		op.Role = a.Role()
+	} else {
+		op.Role = auth.DefaultRole(h.Method())
+	}
+	if auth.RolePublic != op.Role {
+		op.Security = append(op.Security, map[string][]string{"apiKey": {}}, map[string][]string{"bearer": {}})
+	}
+
+	for status, model := range doc.Responses {
+		key, description := "default", "Error"
+		if 0 != status {
+			key, description = strconv.Itoa(status), http.StatusText(status)
+		}
+		resp := &Response{Description: description}
+		if nil != model {
+			contentType := "application/json"
+			if 200 <= status && 300 > status && 0 != len(doc.ContentType) {
+				contentType = doc.ContentType
+			}
+			resp.Content = map[string]*MediaType{contentType: {Schema: g.schemaOf(reflect.TypeOf(model))}}
+		}
+		op.Responses[key] = resp
+	}
+	return op
+}
+
+// operationID is unique by method and path, e.g. post_v2_subjects_hash_ballots
+func operationID(method string, path string) string {
+	id := strings.ToLower(method)
+	for _, s := range strings.FieldsFunc(pathParam.ReplaceAllString(path, "$1"), func(r rune) bool {
+		return !('a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9')
+	}) {
+		id += "_" + s
+	}
+	return id
+}
+
+func sortedKeys(m map[string]*Schema) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -3,15 +3,32 @@ package restapi
 import (
 	"fmt"
 
+	"github.com/unitychain/zkvote-node/restapi/auth"
 	"github.com/unitychain/zkvote-node/restapi/controller"
+	adminController "github.com/unitychain/zkvote-node/restapi/controller/admin"
 	identityController "github.com/unitychain/zkvote-node/restapi/controller/identity"
+	metricsController "github.com/unitychain/zkvote-node/restapi/controller/metrics"
+	rollupController "github.com/unitychain/zkvote-node/restapi/controller/rollup"
 	subjectController "github.com/unitychain/zkvote-node/restapi/controller/subject"
+	subjectV2Controller "github.com/unitychain/zkvote-node/restapi/controller/v2/subject"
+	"github.com/unitychain/zkvote-node/restapi/openapi"
+	"github.com/unitychain/zkvote-node/restapi/webhook"
+	"github.com/unitychain/zkvote-node/zkvote/node"
 	zkvote "github.com/unitychain/zkvote-node/zkvote/operator"
 )
 
 type allOpts struct {
-	webhookURLs  []string
-	defaultLabel string
+	webhookURLs   []string
+	webhookSecret string
+	defaultLabel  string
+
+	authenticators []auth.Authenticator
+	anonymousRole  auth.Role
+	insecure       bool
+	corsOrigins    []string
+	tlsCertFile    string
+	tlsKeyFile     string
+	clientCAFile   string
 }
 
 // Opt represents a REST Api option.
@@ -19,7 +36,9 @@ type Opt func(opts *allOpts)
 
 // RESTAPI contains handlers for REST API
 type RESTAPI struct {
-	handlers []controller.Handler
+	handlers   []controller.Handler
+	dispatcher *webhook.Dispatcher
+	opts       *allOpts
 }
 
 // GetHandlers returns all controller REST API endpoints
@@ -47,18 +66,120 @@ func NewRESTAPI(op *zkvote.Operator, opts ...Opt) (*RESTAPI, error) {
 		fmt.Print(err)
 	}
 
+	sc2, err := subjectV2Controller.New(op)
+	if err != nil {
+		fmt.Print(err)
+	}
+
 	allHandlers = append(allHandlers, sc.GetRESTHandlers()...)
 	allHandlers = append(allHandlers, ic.GetRESTHandlers()...)
+	allHandlers = append(allHandlers, sc2.GetRESTHandlers()...)
+
+	ac, err := adminController.New(op)
+	if err != nil {
+		return nil, err
+	}
+	allHandlers = append(allHandlers, ac.GetRESTHandlers()...)
+
+	mc, err := metricsController.New()
+	if err != nil {
+		return nil, err
+	}
+	allHandlers = append(allHandlers, mc.GetRESTHandlers()...)
+
+	oc, err := openapi.New("zkvote operator", allHandlers)
+	if err != nil {
+		return nil, err
+	}
+	allHandlers = append(allHandlers, oc.GetRESTHandlers()...)
+
+	dispatcher, err := newDispatcher(op, restAPIOpts)
+	if err != nil {
+		return nil, err
+	}
 
-	return &RESTAPI{handlers: allHandlers}, nil
+	return &RESTAPI{handlers: allHandlers, dispatcher: dispatcher, opts: restAPIOpts}, nil
 }
 
-// // WithWebhookURLs is an option for setting up a webhook dispatcher which will notify clients of events
-// func WithWebhookURLs(webhookURLs ...string) Opt {
-// 	return func(opts *allOpts) {
-// 		opts.webhookURLs = webhookURLs
-// 	}
-// }
+// NewNodeRESTAPI returns new REST API instance of a rollup node.
+func NewNodeRESTAPI(n *node.Node, opts ...Opt) (*RESTAPI, error) {
+	restAPIOpts := &allOpts{}
+	for _, opt := range opts {
+		opt(restAPIOpts)
+	}
+
+	rc, err := rollupController.New(n)
+	if err != nil {
+		return nil, err
+	}
+
+	mc, err := metricsController.New()
+	if err != nil {
+		return nil, err
+	}
+	allHandlers := append(rc.GetRESTHandlers(), mc.GetRESTHandlers()...)
+
+	oc, err := openapi.New("zkvote node", allHandlers)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RESTAPI{handlers: append(allHandlers, oc.GetRESTHandlers()...), opts: restAPIOpts}, nil
+}
+
+// WithWebhookURLs is an option for setting up a webhook dispatcher which will notify clients of events.
+// A URL can be prefixed with the event types it's notified of, e.g. "ballot_accepted,tally_changed=http://...".
+func WithWebhookURLs(webhookURLs ...string) Opt {
+	return func(opts *allOpts) {
+		opts.webhookURLs = webhookURLs
+	}
+}
+
+// WithWebhookSecret is an option for signing the webhook payloads with HMAC-SHA256
+func WithWebhookSecret(secret string) Opt {
+	return func(opts *allOpts) {
+		opts.webhookSecret = secret
+	}
+}
+
+// WithAuthenticators is an option for requiring the clients to authenticate,
+// a request is authorized by the role of the first authenticator which recognizes its credentials
+func WithAuthenticators(authenticators ...auth.Authenticator) Opt {
+	return func(opts *allOpts) {
+		opts.authenticators = authenticators
+	}
+}
+
+// WithAnonymousRole is an option for the role of the clients without credentials, none by default
+func WithAnonymousRole(role auth.Role) Opt {
+	return func(opts *allOpts) {
+		opts.anonymousRole = role
+	}
+}
+
+// WithInsecure is an option for serving without authentication if there is neither an authenticator nor an anonymous role,
+// every client is an admin then. The server refuses to start in that case otherwise.
+func WithInsecure() Opt {
+	return func(opts *allOpts) {
+		opts.insecure = true
+	}
+}
+
+// WithCORSOrigins is an option for the origins allowed to make cross-origin requests, none by default and "*" for all
+func WithCORSOrigins(origins ...string) Opt {
+	return func(opts *allOpts) {
+		opts.corsOrigins = origins
+	}
+}
+
+// WithTLS is an option for serving HTTPS, the client certificates signed by clientCAFile are verified if it's given
+func WithTLS(certFile string, keyFile string, clientCAFile string) Opt {
+	return func(opts *allOpts) {
+		opts.tlsCertFile = certFile
+		opts.tlsKeyFile = keyFile
+		opts.clientCAFile = clientCAFile
+	}
+}
 
 // // WithDefaultLabel is an option allowing for the defaultLabel to be set.
 // func WithDefaultLabel(defaultLabel string) Opt {
@@ -66,3 +187,24 @@ func NewRESTAPI(op *zkvote.Operator, opts ...Opt) (*RESTAPI, error) {
 // 		opts.defaultLabel = defaultLabel
 // 	}
 // }
+
+func newDispatcher(op *zkvote.Operator, opts *allOpts) (*webhook.Dispatcher, error) {
+	if 0 == len(opts.webhookURLs) {
+		return nil, nil
+	}
+
+	hooks := make([]*webhook.Hook, len(opts.webhookURLs))
+	for i, u := range opts.webhookURLs {
+		h, err := webhook.ParseHook(u)
+		if err != nil {
+			return nil, err
+		}
+		hooks[i] = h
+	}
+	d, err := webhook.NewDispatcher(op.Events, op.Store, hooks, opts.webhookSecret)
+	if err != nil {
+		return nil, err
+	}
+	d.Start()
+	return d, nil
+}
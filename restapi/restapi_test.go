@@ -0,0 +1,117 @@
+package restapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/unitychain/zkvote-node/restapi/auth"
+	"github.com/unitychain/zkvote-node/restapi/openapi"
+)
+
+// TestRoutesDocumented fails when a route is registered without a controller.Doc
+func TestRoutesDocumented(t *testing.T) {
+	opAPI, err := NewRESTAPI(nil)
+	assert.Nil(t, err)
+	nodeAPI, err := NewNodeRESTAPI(nil)
+	assert.Nil(t, err)
+
+	for _, api := range []*RESTAPI{opAPI, nodeAPI} {
+		spec, undocumented := openapi.Generate("test", "test", api.GetHandlers())
+		assert.Empty(t, undocumented, "document the routes with WithDoc")
+
+		for _, h := range api.GetHandlers() {
+			op, ok := spec.Paths[h.Path()][strings.ToLower(h.Method())]
+			if assert.True(t, ok, h.Path()) {
+				assert.NotEmpty(t, op.Summary, h.Path())
+				assert.NotEmpty(t, op.Responses, h.Path())
+			}
+		}
+	}
+}
+
+func TestServeSpec(t *testing.T) {
+	api, err := NewRESTAPI(nil, WithInsecure())
+	assert.Nil(t, err)
+	server, err := newServer(api, "")
+	assert.Nil(t, err)
+
+	rw := httptest.NewRecorder()
+	server.router.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	assert.Equal(t, http.StatusOK, rw.Code)
+	var spec openapi.Spec
+	assert.Nil(t, json.Unmarshal(rw.Body.Bytes(), &spec))
+	assert.Equal(t, openapi.VERSION, spec.OpenAPI)
+	assert.Contains(t, spec.Paths, "/v2/subjects/{hash}/ballots")
+	assert.Contains(t, spec.Components.Schemas, "V2SubjectTally")
+
+	rw = httptest.NewRecorder()
+	server.router.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/docs", nil))
+	assert.Equal(t, http.StatusOK, rw.Code)
+	assert.Contains(t, rw.Body.String(), "/openapi.json")
+}
+
+func TestServeUnauthenticated(t *testing.T) {
+	// Refused unless it's asked for
+	api, err := NewRESTAPI(nil)
+	assert.Nil(t, err)
+	_, err = newServer(api, "")
+	assert.NotNil(t, err)
+
+	api, err = NewRESTAPI(nil, WithAnonymousRole(auth.RoleReadOnly))
+	assert.Nil(t, err)
+	server, err := newServer(api, "")
+	assert.Nil(t, err)
+	req := httptest.NewRequest(http.MethodGet, "/openapi.json", nil)
+	req.Header.Set("Origin", "https://evil.example")
+	rw := httptest.NewRecorder()
+	server.router.ServeHTTP(rw, req)
+	assert.Equal(t, http.StatusOK, rw.Code)
+	// No cross-origin requests by default
+	assert.Empty(t, rw.Header().Get("Access-Control-Allow-Origin"))
+}
+
+func TestServeAuthorized(t *testing.T) {
+	keys := auth.NewAPIKeys()
+	keys.Add("voter-key", "voter", auth.RoleVoter)
+	api, err := NewRESTAPI(nil, WithAuthenticators(keys), WithCORSOrigins("https://vote.example"))
+	assert.Nil(t, err)
+	server, err := newServer(api, "")
+	assert.Nil(t, err)
+
+	serve := func(method string, path string, key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader("{}"))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Origin", "https://evil.example")
+		if 0 != len(key) {
+			req.Header.Set(auth.HEADER_API_KEY, key)
+		}
+		rw := httptest.NewRecorder()
+		server.router.ServeHTTP(rw, req)
+		return rw
+	}
+
+	rw := serve(http.MethodGet, "/openapi.json", "")
+	assert.Equal(t, http.StatusOK, rw.Code)
+	assert.Empty(t, rw.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, http.StatusUnauthorized, serve(http.MethodPost, "/v2/subjects", "").Code)
+	assert.Equal(t, http.StatusForbidden, serve(http.MethodPost, "/v2/subjects", "voter-key").Code)
+	assert.Equal(t, http.StatusForbidden, serve(http.MethodGet, "/admin/peers", "voter-key").Code)
+}
+
+func TestServeMetrics(t *testing.T) {
+	api, err := NewNodeRESTAPI(nil, WithInsecure())
+	assert.Nil(t, err)
+	server, err := newServer(api, "")
+	assert.Nil(t, err)
+
+	server.router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	rw := httptest.NewRecorder()
+	server.router.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusOK, rw.Code)
+	assert.Contains(t, rw.Body.String(), `zkvote_http_request_duration_seconds_count{method="GET",route="/openapi.json",code="200"}`)
+	assert.Contains(t, rw.Body.String(), "zkvote_proof_verification_failures_total 0")
+}
@@ -1,48 +1,178 @@
 package restapi
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
+	"io/ioutil"
+	"net"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/rs/cors"
+	"github.com/unitychain/zkvote-node/restapi/auth"
+	"github.com/unitychain/zkvote-node/restapi/controller"
+	"github.com/unitychain/zkvote-node/zkvote/common/metrics"
+	"github.com/unitychain/zkvote-node/zkvote/common/utils"
+	"github.com/unitychain/zkvote-node/zkvote/node"
 	zkvote "github.com/unitychain/zkvote-node/zkvote/operator"
 )
 
+var requestDuration = metrics.NewHistogram("zkvote_http_request_duration_seconds",
+	"Latency of REST requests by method, route and status code", metrics.DEFAULT_BUCKETS, "method", "route", "code")
+
 // Server ...
 type Server struct {
 	*RESTAPI
-	router http.Handler
-	addr   string
+	router     http.Handler
+	addr       string
+	httpServer *http.Server
+
+	// ctx of the requests is cancelled on shutdown, so that the event streams end
+	ctx    context.Context
+	cancel context.CancelFunc
 }
 
 // NewServer ...
-func NewServer(op *zkvote.Operator, serverAddr string) (*Server, error) {
+func NewServer(op *zkvote.Operator, serverAddr string, opts ...Opt) (*Server, error) {
 	// get all HTTP REST API handlers available for controller API
-	restService, err := NewRESTAPI(op)
+	restService, err := NewRESTAPI(op, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start server:  %w", err)
+	}
+
+	return newServer(restService, serverAddr)
+}
+
+// NewNodeServer returns the server of a rollup node
+func NewNodeServer(n *node.Node, serverAddr string, opts ...Opt) (*Server, error) {
+	restService, err := NewNodeRESTAPI(n, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to start server:  %w", err)
 	}
 
+	return newServer(restService, serverAddr)
+}
+
+func newServer(restService *RESTAPI, serverAddr string) (*Server, error) {
 	handlers := restService.GetHandlers()
+	opts := restService.opts
 	router := mux.NewRouter()
 
+	// Authentication is disabled only if there is neither an authenticator nor an anonymous role and it's asked for
+	secured := 0 != len(opts.authenticators) || auth.RolePublic != opts.anonymousRole
+	if !secured && !opts.insecure {
+		return nil, fmt.Errorf("no authenticator or anonymous role, refuse to serve without authentication")
+	}
+	if !secured {
+		utils.LogWarningf("!!! REST API is served WITHOUT authentication, every client is an admin !!!")
+	} else if auth.RolePublic != opts.anonymousRole {
+		utils.LogWarningf("REST API serves the clients without credentials as %v", opts.anonymousRole)
+	}
 	for _, handler := range handlers {
-		router.HandleFunc(handler.Path(), handler.Handle()).Methods(handler.Method())
+		handle := handler.Handle()
+		if secured {
+			role := auth.DefaultRole(handler.Method())
+			if a, ok := handler.(controller.Authorized); ok {
+				role = a.Role()
+			}
+			handle = auth.Authorize(opts.authenticators, opts.anonymousRole, role, handle)
+		}
+		handle = instrument(handler.Method(), handler.Path(), handle)
+		router.HandleFunc(handler.Path(), handle).Methods(handler.Method())
 	}
 
-	handler := cors.AllowAll().Handler(router)
+	// Browsers only allow the same origin if none is given
+	var handler http.Handler = router
+	if 0 != len(opts.corsOrigins) {
+		handler = cors.New(cors.Options{
+			AllowedOrigins:   opts.corsOrigins,
+			AllowedMethods:   []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete},
+			AllowedHeaders:   []string{"Authorization", "Content-Type", "Last-Event-ID", auth.HEADER_API_KEY},
+			ExposedHeaders:   []string{"Location"},
+			AllowCredentials: true,
+		}).Handler(router)
+	}
 
-	server := &Server{
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &Server{
 		RESTAPI: restService,
 		router:  handler,
 		addr:    serverAddr,
+		ctx:     ctx,
+		cancel:  cancel,
 	}
-
-	return server, nil
+	s.httpServer = &http.Server{
+		Addr:        serverAddr,
+		Handler:     handler,
+		BaseContext: func(net.Listener) context.Context { return s.ctx },
+	}
+	return s, nil
 }
 
 // ListenAndServe starts the server using the standard Go HTTP server implementation.
+// It serves HTTPS if a certificate is given, http.ErrServerClosed is returned after Shutdown.
 func (s *Server) ListenAndServe() error {
-	return http.ListenAndServe(s.addr, s.router)
+	if 0 == len(s.opts.tlsCertFile) {
+		return s.httpServer.ListenAndServe()
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if 0 != len(s.opts.clientCAFile) {
+		pem, err := ioutil.ReadFile(s.opts.clientCAFile)
+		if err != nil {
+			return err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no certificate in %v", s.opts.clientCAFile)
+		}
+		// Clients without a certificate can still authenticate in other ways
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+	s.httpServer.TLSConfig = tlsConfig
+	return s.httpServer.ListenAndServeTLS(s.opts.tlsCertFile, s.opts.tlsKeyFile)
+}
+
+// Shutdown stops accepting connections and waits for the active requests until ctx is done,
+// and then stops the webhook dispatcher
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.cancel()
+	err := s.httpServer.Shutdown(ctx)
+	if nil != s.dispatcher {
+		s.dispatcher.Stop()
+	}
+	return err
+}
+
+// statusRecorder records the status code of a response
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Flush keeps the event streams working
+func (r *statusRecorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// instrument observes the latency of the requests of a route
+func instrument(method string, route string, next http.HandlerFunc) http.HandlerFunc {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: rw, status: http.StatusOK}
+		next(recorder, req)
+		requestDuration.Since(start, method, route, strconv.Itoa(recorder.status))
+	}
 }
@@ -0,0 +1,363 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	uuid "github.com/google/uuid"
+	"github.com/unitychain/zkvote-node/zkvote/common/event"
+	"github.com/unitychain/zkvote-node/zkvote/common/store"
+	"github.com/unitychain/zkvote-node/zkvote/common/utils"
+)
+
+// KEY_OUTBOX is the key of the pending deliveries in the local store
+const KEY_OUTBOX = "webhook-outbox"
+
+// MAX_ATTEMPTS is the number of attempts before a delivery is dropped
+const MAX_ATTEMPTS = 10
+
+// Backoff between the attempts of a delivery, doubled after every failure
+const (
+	BASE_BACKOFF = time.Second
+	MAX_BACKOFF  = 10 * time.Minute
+)
+
+// REQUEST_TIMEOUT is the timeout of a single attempt
+const REQUEST_TIMEOUT = 10 * time.Second
+
+// Headers of a delivery
+const (
+	HEADER_EVENT     = "X-Zkvote-Event"
+	HEADER_DELIVERY  = "X-Zkvote-Delivery"
+	HEADER_SIGNATURE = "X-Zkvote-Signature"
+)
+
+// DEFAULT_TYPES are the events notified to a hook without types:
+// new subjects, new members, ballots and tally changes
+var DEFAULT_TYPES = []event.Type{
+	event.SubjectCreated,
+	event.SubjectCollected,
+	event.IdentityInserted,
+	event.BallotAccepted,
+	event.BallotRejected,
+	event.TallyChanged,
+	event.SubjectClosed,
+}
+
+// Hook is a URL notified of the events of its types
+type Hook struct {
+	URL   string
+	Types []event.Type
+}
+
+// ParseHook parses a hook in the form of "url" or "type,type=url",
+// e.g. "ballot_accepted,tally_changed=http://localhost:8080/zkvote"
+func ParseHook(spec string) (*Hook, error) {
+	types := DEFAULT_TYPES
+	rawURL := strings.TrimSpace(spec)
+	if i := strings.Index(rawURL, "="); i > 0 && !strings.Contains(rawURL[:i], "://") {
+		types = make([]event.Type, 0)
+		for _, t := range strings.Split(rawURL[:i], ",") {
+			if t = strings.TrimSpace(t); 0 != len(t) {
+				types = append(types, event.Type(t))
+			}
+		}
+		rawURL = strings.TrimSpace(rawURL[i+1:])
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil || ("http" != u.Scheme && "https" != u.Scheme) || 0 == len(u.Host) {
+		return nil, fmt.Errorf("invalid webhook URL, %v", rawURL)
+	}
+	if 0 == len(types) {
+		return nil, fmt.Errorf("no event type of webhook, %v", spec)
+	}
+	return &Hook{URL: rawURL, Types: types}, nil
+}
+
+func (h *Hook) match(t event.Type) bool {
+	for _, ht := range h.Types {
+		if ht == t {
+			return true
+		}
+	}
+	return false
+}
+
+// delivery is a pending notification of an event to a hook
+type delivery struct {
+	ID          string     `json:"id"`
+	URL         string     `json:"url"`
+	Type        event.Type `json:"type"`
+	Body        string     `json:"body"`
+	Attempts    int        `json:"attempts"`
+	NextAttempt int64      `json:"nextAttempt"` // unix time in nanoseconds
+}
+
+// Dispatcher notifies the hooks of the events on the bus.
+// Pending deliveries are kept in the local store, so they survive restarts.
+type Dispatcher struct {
+	bus     *event.Bus
+	store   *store.Store
+	hooks   []*Hook
+	secret  []byte
+	client  *http.Client
+	backoff time.Duration
+
+	lock   sync.Mutex
+	outbox []*delivery
+	wake   chan struct{}
+	quit   chan struct{}
+	done   sync.WaitGroup
+}
+
+// NewDispatcher loads the pending deliveries, payloads are signed with HMAC-SHA256 if secret isn't empty
+func NewDispatcher(bus *event.Bus, s *store.Store, hooks []*Hook, secret string) (*Dispatcher, error) {
+	if nil == bus || nil == s {
+		return nil, fmt.Errorf("invalid input")
+	}
+	d := &Dispatcher{
+		bus:     bus,
+		store:   s,
+		hooks:   hooks,
+		secret:  []byte(secret),
+		client:  &http.Client{Timeout: REQUEST_TIMEOUT},
+		backoff: BASE_BACKOFF,
+		outbox:  make([]*delivery, 0),
+		wake:    make(chan struct{}, 1),
+		quit:    make(chan struct{}),
+	}
+
+	value, err := s.GetLocal(KEY_OUTBOX)
+	if nil == err && 0 != len(value) {
+		err = json.Unmarshal([]byte(value), &d.outbox)
+		if err != nil {
+			return nil, fmt.Errorf("unmarshal webhook outbox error, %v", err)
+		}
+		utils.LogInfof("Webhook, %d pending deliveries", len(d.outbox))
+	}
+	return d, nil
+}
+
+// Start listens to the events after now and delivers the pending ones
+func (d *Dispatcher) Start() {
+	cursor := d.bus.LastID()
+	d.done.Add(2)
+	go d.listen(cursor)
+	go d.deliver()
+}
+
+// Stop the dispatcher, the pending deliveries are resumed by the next start
+func (d *Dispatcher) Stop() {
+	close(d.quit)
+	d.done.Wait()
+}
+
+// Sign returns the signature header of a payload
+func Sign(secret []byte, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+//
+// internal functions
+//
+
+func (d *Dispatcher) listen(cursor uint64) {
+	defer d.done.Done()
+
+	for {
+		replay, sub, err := d.bus.Subscribe("", cursor)
+		if event.ErrCursorExpired == err {
+			utils.LogWarningf("Webhook, events after %d have been dropped", cursor)
+			cursor = d.bus.LastID()
+			continue
+		} else if err != nil {
+			utils.LogErrorf("Webhook, subscribe error, %v", err)
+			return
+		}
+		for _, e := range replay {
+			d.enqueue(e)
+			cursor = e.ID
+		}
+
+		for dropped := false; !dropped; {
+			select {
+			case e, ok := <-sub.C:
+				if !ok {
+					// Resume from the last event
+					dropped = true
+					break
+				}
+				d.enqueue(e)
+				cursor = e.ID
+			case <-d.quit:
+				sub.Close()
+				return
+			}
+		}
+	}
+}
+
+func (d *Dispatcher) enqueue(e *event.Event) {
+	hooks := make([]*Hook, 0)
+	for _, h := range d.hooks {
+		if h.match(e.Type) {
+			hooks = append(hooks, h)
+		}
+	}
+	if 0 == len(hooks) {
+		return
+	}
+
+	body, err := json.Marshal(e)
+	if err != nil {
+		utils.LogErrorf("Webhook, marshal event error, %v", err)
+		return
+	}
+	d.lock.Lock()
+	for _, h := range hooks {
+		d.outbox = append(d.outbox, &delivery{
+			ID:          uuid.New().String(),
+			URL:         h.URL,
+			Type:        e.Type,
+			Body:        string(body),
+			NextAttempt: time.Now().UnixNano(),
+		})
+	}
+	d.save()
+	d.lock.Unlock()
+
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (d *Dispatcher) deliver() {
+	defer d.done.Done()
+
+	for {
+		for _, dl := range d.due() {
+			select {
+			case <-d.quit:
+				return
+			default:
+			}
+			d.complete(dl, d.post(dl))
+		}
+
+		timer := time.NewTimer(d.untilNext())
+		select {
+		case <-d.wake:
+		case <-timer.C:
+		case <-d.quit:
+			timer.Stop()
+			return
+		}
+		timer.Stop()
+	}
+}
+
+// due returns the deliveries whose next attempt has come, oldest first
+func (d *Dispatcher) due() []*delivery {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	now := time.Now().UnixNano()
+	due := make([]*delivery, 0)
+	for _, dl := range d.outbox {
+		if dl.NextAttempt <= now {
+			due = append(due, dl)
+		}
+	}
+	return due
+}
+
+// untilNext returns the duration until the next attempt, MAX_BACKOFF if there isn't any
+func (d *Dispatcher) untilNext() time.Duration {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	next := time.Now().Add(MAX_BACKOFF).UnixNano()
+	for _, dl := range d.outbox {
+		if dl.NextAttempt < next {
+			next = dl.NextAttempt
+		}
+	}
+	return time.Until(time.Unix(0, next))
+}
+
+func (d *Dispatcher) post(dl *delivery) error {
+	req, err := http.NewRequest(http.MethodPost, dl.URL, strings.NewReader(dl.Body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HEADER_EVENT, string(dl.Type))
+	req.Header.Set(HEADER_DELIVERY, dl.ID)
+	if 0 != len(d.secret) {
+		req.Header.Set(HEADER_SIGNATURE, Sign(d.secret, []byte(dl.Body)))
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// complete removes a delivered or exhausted delivery, or schedules its next attempt
+func (d *Dispatcher) complete(dl *delivery, err error) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	if err != nil {
+		dl.Attempts++
+		if dl.Attempts < MAX_ATTEMPTS {
+			backoff := d.backoff << uint(dl.Attempts-1)
+			if backoff > MAX_BACKOFF || backoff <= 0 {
+				backoff = MAX_BACKOFF
+			}
+			utils.LogWarningf("Webhook, deliver %v to %v error, %v, retry in %v", dl.ID, dl.URL, err, backoff)
+			dl.NextAttempt = time.Now().Add(backoff).UnixNano()
+			d.save()
+			return
+		}
+		utils.LogErrorf("Webhook, drop %v to %v after %d attempts, %v", dl.ID, dl.URL, dl.Attempts, err)
+	}
+
+	for i, o := range d.outbox {
+		if o == dl {
+			d.outbox = append(d.outbox[:i], d.outbox[i+1:]...)
+			break
+		}
+	}
+	d.save()
+}
+
+// save must be called with lock held
+func (d *Dispatcher) save() {
+	b, err := json.Marshal(d.outbox)
+	if err != nil {
+		utils.LogErrorf("Webhook, marshal outbox error, %v", err)
+		return
+	}
+	err = d.store.PutLocal(KEY_OUTBOX, string(b))
+	if err != nil {
+		utils.LogErrorf("Webhook, save outbox error, %v", err)
+	}
+}
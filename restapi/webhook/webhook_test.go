@@ -0,0 +1,94 @@
+package webhook
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	"github.com/stretchr/testify/assert"
+	"github.com/unitychain/zkvote-node/zkvote/common/event"
+	"github.com/unitychain/zkvote-node/zkvote/common/store"
+)
+
+func TestParseHook(t *testing.T) {
+	h, err := ParseHook("http://localhost:8080/hook?a=b")
+	assert.Nil(t, err)
+	assert.Equal(t, "http://localhost:8080/hook?a=b", h.URL)
+	assert.Equal(t, DEFAULT_TYPES, h.Types)
+
+	h, err = ParseHook("ballot_accepted, tally_changed=https://example.com/hook")
+	assert.Nil(t, err)
+	assert.Equal(t, "https://example.com/hook", h.URL)
+	assert.Equal(t, []event.Type{event.BallotAccepted, event.TallyChanged}, h.Types)
+
+	_, err = ParseHook("ballot_accepted=ftp://example.com")
+	assert.NotNil(t, err)
+	_, err = ParseHook("=http://example.com")
+	assert.NotNil(t, err)
+}
+
+func TestDispatch(t *testing.T) {
+	received := make(chan *http.Request, 10)
+	bodies := make(chan []byte, 10)
+	failures := 1
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if 0 < failures {
+			failures--
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		received <- r
+		bodies <- body
+	}))
+	defer server.Close()
+
+	s, _ := store.NewStore(nil, dssync.MutexWrap(datastore.NewMapDatastore()))
+	bus := event.NewBus(event.DEFAULT_CAPACITY)
+	h, err := ParseHook("tally_changed=" + server.URL)
+	assert.Nil(t, err)
+	d, err := NewDispatcher(bus, s, []*Hook{h}, "secret")
+	assert.Nil(t, err)
+	d.backoff = 10 * time.Millisecond
+	d.Start()
+
+	bus.Publish(event.BallotAccepted, "0x01", nil)
+	bus.Publish(event.TallyChanged, "0x01", map[string]interface{}{"tally": []int{1, 0}})
+
+	select {
+	case r := <-received:
+		body := <-bodies
+		assert.Equal(t, string(event.TallyChanged), r.Header.Get(HEADER_EVENT))
+		assert.Equal(t, Sign([]byte("secret"), body), r.Header.Get(HEADER_SIGNATURE))
+		var e event.Event
+		assert.Nil(t, json.Unmarshal(body, &e))
+		assert.Equal(t, "0x01", e.SubjectHash)
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook isn't delivered")
+	}
+	d.Stop()
+	assert.Equal(t, 0, len(received))
+
+	// A pending delivery survives a restart
+	d.lock.Lock()
+	d.outbox = append(d.outbox, &delivery{ID: "1", URL: server.URL, Type: event.TallyChanged, Body: "{}"})
+	d.save()
+	d.lock.Unlock()
+	d, err = NewDispatcher(bus, s, []*Hook{h}, "")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(d.outbox))
+	d.Start()
+	defer d.Stop()
+	select {
+	case r := <-received:
+		assert.Equal(t, "1", r.Header.Get(HEADER_DELIVERY))
+		assert.Equal(t, "", r.Header.Get(HEADER_SIGNATURE))
+	case <-time.After(5 * time.Second):
+		t.Fatal("pending webhook isn't delivered")
+	}
+}
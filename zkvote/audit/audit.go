@@ -0,0 +1,252 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/unitychain/zkvote-node/zkvote/common/utils"
+	"github.com/unitychain/zkvote-node/zkvote/model/archive"
+	ba "github.com/unitychain/zkvote-node/zkvote/model/ballot"
+	id "github.com/unitychain/zkvote-node/zkvote/model/identity"
+	"github.com/unitychain/zkvote-node/zkvote/snark"
+)
+
+// Discrepancy is a problem found by the audit.
+// Nullifier is empty if the problem isn't about a ballot.
+type Discrepancy struct {
+	Nullifier string `json:"nullifier,omitempty"`
+	Reason    string `json:"reason"`
+}
+
+// Report is the result of an audit, signed by the auditor
+type Report struct {
+	SubjectHash   string         `json:"subjectHash"`
+	Options       []string       `json:"options"`
+	Tally         []int          `json:"tally"`
+	Ballots       int            `json:"ballots"`
+	Accepted      int            `json:"accepted"`
+	Root          string         `json:"root"`
+	Discrepancies []*Discrepancy `json:"discrepancies"`
+	AuditedAt     int64          `json:"auditedAt"` // unix time
+
+	Auditor   string `json:"auditor,omitempty"`
+	PubKey    []byte `json:"pubKey,omitempty"`
+	Signature []byte `json:"signature,omitempty"`
+}
+
+// Audit recomputes the tally of an archive.
+// The identity tree is rebuilt from the identities, and every ballot is checked
+// against the rebuilt root history and verified with the verification key.
+// The key declared by the subject takes precedence over vkString.
+func Audit(a *archive.Archive, vkString string) *Report {
+	s := a.Subject
+	r := &Report{
+		SubjectHash:   s.HashHex().String(),
+		Options:       s.GetOptions(),
+		Tally:         make([]int, len(s.GetOptions())),
+		Ballots:       len(a.Ballots),
+		Discrepancies: []*Discrepancy{},
+		AuditedAt:     time.Now().Unix(),
+	}
+
+	if 0 != len(s.VerificationKey) {
+		vkString = s.VerificationKey
+	}
+	if 0 == len(vkString) {
+		vkString = a.VerificationKey
+		r.addDiscrepancy("", "no trusted verification key, the one of the archive is used")
+	}
+
+	roots, err := r.rebuildRoots(a)
+	if err != nil {
+		r.addDiscrepancy("", err.Error())
+		return r
+	}
+	r.Root = roots[len(roots)-1].Hex()
+
+	// TODO: Div(8) is a workaround because a bits conversion issue in circom, the same as Proposal
+	externalNullifier := utils.GetBigIntFromHexString(s.HashHex().String())
+	externalNullifier.Div(externalNullifier, big.NewInt(8))
+
+	nullifiers := make(map[string]bool)
+	for _, b := range a.Ballots {
+		// the nullifier committed by the proof
+		nullifier := b.PublicSignal[1]
+		if nullifiers[nullifier] {
+			r.addDiscrepancy(nullifier, "nullifier is used more than once")
+			continue
+		}
+		nullifiers[nullifier] = true
+
+		option, err := checkBallot(b, roots, externalNullifier, s.GetOptions())
+		if err != nil {
+			r.addDiscrepancy(nullifier, err.Error())
+			continue
+		}
+		if !snark.Verify(vkString, b.Proof, b.PublicSignal) {
+			r.addDiscrepancy(nullifier, "invalid proof")
+			continue
+		}
+		r.Tally[option]++
+		r.Accepted++
+	}
+	return r
+}
+
+// Compare adds a discrepancy if the tally differs from the claimed one
+func (r *Report) Compare(claimed []int) {
+	if len(claimed) != len(r.Tally) {
+		r.addDiscrepancy("", fmt.Sprintf("claimed tally %v has %d options, expected %d", claimed, len(claimed), len(r.Tally)))
+		return
+	}
+	for i, c := range claimed {
+		if c != r.Tally[i] {
+			r.addDiscrepancy("", fmt.Sprintf("claimed tally %v doesn't match the recomputed one %v", claimed, r.Tally))
+			return
+		}
+	}
+}
+
+// Sign the report with the key of the auditor
+func (r *Report) Sign(prvKey crypto.PrivKey) error {
+	p, err := peer.IDFromPrivateKey(prvKey)
+	if err != nil {
+		return err
+	}
+	r.Auditor = p.Pretty()
+	r.PubKey, err = prvKey.GetPublic().Bytes()
+	if err != nil {
+		return err
+	}
+
+	data, err := r.signedData()
+	if err != nil {
+		return err
+	}
+	r.Signature, err = prvKey.Sign(data)
+	return err
+}
+
+// Verify checks the report is signed by its auditor
+func (r *Report) Verify() error {
+	if 0 == len(r.Signature) {
+		return fmt.Errorf("report is not signed")
+	}
+	pubKey, err := crypto.UnmarshalPublicKey(r.PubKey)
+	if err != nil {
+		return fmt.Errorf("invalid public key, %v", err)
+	}
+	p, err := peer.IDFromPublicKey(pubKey)
+	if err != nil || p.Pretty() != r.Auditor {
+		return fmt.Errorf("auditor doesn't match the public key")
+	}
+	data, err := r.signedData()
+	if err != nil {
+		return err
+	}
+	ok, err := pubKey.Verify(data, r.Signature)
+	if err != nil || !ok {
+		return fmt.Errorf("invalid signature")
+	}
+	return nil
+}
+
+// Byte ...
+func (r *Report) Byte() ([]byte, error) {
+	return json.MarshalIndent(r, "", "  ")
+}
+
+//
+// Internal functions
+//
+func (r *Report) addDiscrepancy(nullifier string, reason string) {
+	utils.LogWarningf("audit %v: %v %v", r.SubjectHash, nullifier, reason)
+	r.Discrepancies = append(r.Discrepancies, &Discrepancy{Nullifier: nullifier, Reason: reason})
+}
+
+// signedData is the report without signature
+func (r *Report) signedData() ([]byte, error) {
+	unsigned := *r
+	unsigned.Signature = nil
+	return json.Marshal(unsigned)
+}
+
+// rebuildRoots inserts the identities into a new tree and returns the root history.
+// A root history different from the one of the archive
+// and identities which aren't admitted by the proposer are reported.
+func (r *Report) rebuildRoots(a *archive.Archive) ([]*id.TreeContent, error) {
+	tree, err := id.NewMerkleTree(a.Subject.GetTreeLevel())
+	if err != nil {
+		return nil, err
+	}
+	roots := []*id.TreeContent{tree.GetRoot()}
+	for i, h := range a.Identities {
+		value := utils.GetBigIntFromHexString(h)
+		if nil == value {
+			return nil, fmt.Errorf("invalid identity commitment, %v", h)
+		}
+		var admission string
+		if i < len(a.Admissions) {
+			admission = a.Admissions[i]
+		}
+		err = a.Subject.VerifyAdmission(id.NewIdentityFromBytes(value.Bytes()), admission)
+		if err != nil {
+			r.addDiscrepancy("", err.Error())
+		}
+		_, err = tree.Insert(*id.NewTreeContent(value))
+		if err != nil {
+			return nil, fmt.Errorf("insert identity commitment %v error, %v", h, err)
+		}
+		roots = append(roots, tree.GetRoot())
+	}
+
+	if len(roots) != len(a.Roots) {
+		r.addDiscrepancy("", fmt.Sprintf("root history has %d roots, expected %d", len(a.Roots), len(roots)))
+		return roots, nil
+	}
+	for i, root := range roots {
+		claimed := utils.GetBigIntFromHexString(a.Roots[i])
+		if nil == claimed || 0 != root.BigInt().Cmp(claimed) {
+			r.addDiscrepancy("", fmt.Sprintf("root %d doesn't match (%v)/(%v)", i, root.Hex(), a.Roots[i]))
+		}
+	}
+	return roots, nil
+}
+
+// checkBallot checks everything of a ballot except its proof,
+// and returns the index of its option
+func checkBallot(b *ba.Ballot, roots []*id.TreeContent, externalNullifier *big.Int, options []string) (int, error) {
+	// the root committed by the proof
+	root, _ := big.NewInt(0).SetString(b.PublicSignal[0], 10)
+	claimed, _ := big.NewInt(0).SetString(b.Root, 10)
+	if nil == root || nil == claimed || 0 != root.Cmp(claimed) {
+		return -1, fmt.Errorf("root doesn't match the proof (%v)/(%v)", b.Root, b.PublicSignal[0])
+	}
+	member := false
+	for _, r := range roots {
+		if 0 == r.BigInt().Cmp(root) {
+			member = true
+			break
+		}
+	}
+	if !member {
+		return -1, fmt.Errorf("root %v is not in the root history", b.Root)
+	}
+
+	bigExternalNull, _ := big.NewInt(0).SetString(b.PublicSignal[3], 10)
+	if nil == bigExternalNull || 0 != externalNullifier.Cmp(bigExternalNull) {
+		return -1, fmt.Errorf("subject doesn't match (%v)/(%v)", externalNullifier, b.PublicSignal[3])
+	}
+
+	signalHash := b.SignalHash()
+	for i := range options {
+		if ba.SignalHash(i) == signalHash {
+			return i, nil
+		}
+	}
+	return -1, fmt.Errorf("not a valid vote hash, %v", signalHash)
+}
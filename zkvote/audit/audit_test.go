@@ -0,0 +1,93 @@
+package audit
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/unitychain/zkvote-node/zkvote/common/utils"
+	"github.com/unitychain/zkvote-node/zkvote/model/archive"
+	ba "github.com/unitychain/zkvote-node/zkvote/model/ballot"
+	id "github.com/unitychain/zkvote-node/zkvote/model/identity"
+	"github.com/unitychain/zkvote-node/zkvote/model/subject"
+)
+
+func newTestArchive(t *testing.T, identities ...string) *archive.Archive {
+	s := subject.NewSubject("title", "description", id.NewIdentity("0x1f"))
+	tree, err := id.NewMerkleTree(s.GetTreeLevel())
+	assert.Nil(t, err)
+	roots := []string{tree.GetRoot().Hex()}
+	for _, h := range identities {
+		_, err = tree.Insert(*id.NewTreeContent(utils.GetBigIntFromHexString(h)))
+		assert.Nil(t, err)
+		roots = append(roots, tree.GetRoot().Hex())
+	}
+	return &archive.Archive{
+		Format:     archive.FORMAT,
+		Version:    archive.VERSION,
+		Subject:    s,
+		Identities: identities,
+		Roots:      roots,
+		Ballots:    []*ba.Ballot{},
+	}
+}
+
+func newTestBallot(a *archive.Archive, root string, nullifier string, option int) *ba.Ballot {
+	externalNullifier := utils.GetBigIntFromHexString(a.Subject.HashHex().String())
+	externalNullifier.Div(externalNullifier, big.NewInt(8))
+	root = utils.GetBigIntFromHexString(root).String()
+	b, _ := ba.NewBallot(`{"root":"` + root + `","nullifier_hash":"` + nullifier + `","proof":{},"public_signal":["` +
+		root + `","` + nullifier + `","` + ba.SignalHash(option) + `","` + externalNullifier.String() + `"]}`)
+	return b
+}
+
+func TestAudit(t *testing.T) {
+	a := newTestArchive(t, "0x1234", "0x5678")
+	r := Audit(a, "{}")
+	assert.Equal(t, []int{0, 0}, r.Tally)
+	assert.Equal(t, 0, len(r.Discrepancies))
+	assert.Equal(t, a.Roots[2], r.Root)
+
+	a.Ballots = []*ba.Ballot{
+		newTestBallot(a, a.Roots[1], "1", 0),
+		newTestBallot(a, a.Roots[1], "1", 1),
+		newTestBallot(a, "0x1", "2", 0),
+	}
+	r = Audit(a, "{}")
+	assert.Equal(t, []int{0, 0}, r.Tally)
+	assert.Equal(t, 3, len(r.Discrepancies))
+	assert.Equal(t, "invalid proof", r.Discrepancies[0].Reason)
+	assert.Equal(t, "nullifier is used more than once", r.Discrepancies[1].Reason)
+	assert.Equal(t, "2", r.Discrepancies[2].Nullifier)
+}
+
+func TestAudit_RootHistory(t *testing.T) {
+	a := newTestArchive(t, "0x1234", "0x5678")
+	a.Roots[1], a.Roots[2] = a.Roots[2], a.Roots[1]
+	assert.Equal(t, 2, len(Audit(a, "{}").Discrepancies))
+
+	a.Roots = a.Roots[:2]
+	assert.Equal(t, 1, len(Audit(a, "{}").Discrepancies))
+}
+
+func TestCompare(t *testing.T) {
+	r := Audit(newTestArchive(t), "{}")
+	r.Compare([]int{0, 0})
+	assert.Equal(t, 0, len(r.Discrepancies))
+	r.Compare([]int{1, 0})
+	assert.Equal(t, 1, len(r.Discrepancies))
+}
+
+func TestSign(t *testing.T) {
+	prvKey, _, err := crypto.GenerateKeyPair(crypto.ECDSA, 0)
+	assert.Nil(t, err)
+
+	r := Audit(newTestArchive(t, "0x1234"), "{}")
+	assert.NotNil(t, r.Verify())
+	assert.Nil(t, r.Sign(prvKey))
+	assert.Nil(t, r.Verify())
+
+	r.Tally[0] = 10
+	assert.NotNil(t, r.Verify())
+}
@@ -0,0 +1,150 @@
+package event
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DEFAULT_CAPACITY is the number of the latest events kept for resuming
+const DEFAULT_CAPACITY = 1024
+
+// SUBSCRIPTION_BUFFER is the number of events a subscriber can fall behind before it's dropped
+const SUBSCRIPTION_BUFFER = 256
+
+// Type of an event
+type Type string
+
+// Types of the activity of subjects
+const (
+	SubjectCreated   Type = "subject_created"
+	SubjectCollected Type = "subject_collected"
+	SubjectImported  Type = "subject_imported"
+	SubjectJoined    Type = "subject_joined"
+	SubjectClosed    Type = "subject_closed"
+	IdentityInserted Type = "identity_inserted"
+	IdentityConflict Type = "identity_conflict"
+	RootChanged      Type = "root_changed"
+	BallotAccepted   Type = "ballot_accepted"
+	BallotRejected   Type = "ballot_rejected"
+	TallyChanged     Type = "tally_changed"
+)
+
+// ErrCursorExpired means the events after the cursor aren't kept anymore,
+// the subscriber should reload the state and subscribe without a cursor.
+var ErrCursorExpired = fmt.Errorf("events after the cursor have been dropped")
+
+// Event is an activity of a subject, ID is the cursor to resume from
+type Event struct {
+	ID          uint64                 `json:"id"`
+	Type        Type                   `json:"type"`
+	SubjectHash string                 `json:"subjectHash,omitempty"`
+	Time        int64                  `json:"time"` // unix time
+	Data        map[string]interface{} `json:"data,omitempty"`
+}
+
+// Bus delivers events to subscribers and keeps the latest ones for resuming
+type Bus struct {
+	lock          sync.Mutex
+	capacity      int
+	events        []*Event // oldest first
+	lastID        uint64
+	subscriptions map[*Subscription]bool
+}
+
+// Subscription receives the events of a subject, or of all subjects if subjectHash is empty.
+// C is closed when the subscription is closed or falls too far behind.
+type Subscription struct {
+	C           <-chan *Event
+	ch          chan *Event
+	subjectHash string
+	bus         *Bus
+}
+
+// NewBus ...
+func NewBus(capacity int) *Bus {
+	return &Bus{
+		capacity:      capacity,
+		events:        make([]*Event, 0, capacity),
+		subscriptions: make(map[*Subscription]bool),
+	}
+}
+
+// Publish an event, it's a no-op on a nil bus
+func (b *Bus) Publish(t Type, subjectHash string, data map[string]interface{}) {
+	if nil == b {
+		return
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.lastID++
+	e := &Event{ID: b.lastID, Type: t, SubjectHash: subjectHash, Time: time.Now().Unix(), Data: data}
+	if len(b.events) == b.capacity {
+		b.events = b.events[1:]
+	}
+	b.events = append(b.events, e)
+
+	for s := range b.subscriptions {
+		if !s.match(e) {
+			continue
+		}
+		select {
+		case s.ch <- e:
+		default:
+			// The subscriber can resume from the last event it received
+			b.remove(s)
+		}
+	}
+}
+
+// LastID returns the id of the latest event, 0 if there isn't any
+func (b *Bus) LastID() uint64 {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.lastID
+}
+
+// Subscribe returns the kept events after the cursor and subscribes the following ones.
+func (b *Bus) Subscribe(subjectHash string, cursor uint64) ([]*Event, *Subscription, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if cursor > b.lastID {
+		return nil, nil, fmt.Errorf("unknown cursor %d", cursor)
+	}
+	if 0 != len(b.events) && cursor+1 < b.events[0].ID {
+		return nil, nil, ErrCursorExpired
+	}
+
+	s := &Subscription{ch: make(chan *Event, SUBSCRIPTION_BUFFER), subjectHash: subjectHash, bus: b}
+	s.C = s.ch
+	replay := make([]*Event, 0)
+	for _, e := range b.events {
+		if e.ID > cursor && s.match(e) {
+			replay = append(replay, e)
+		}
+	}
+	b.subscriptions[s] = true
+	return replay, s, nil
+}
+
+// Close the subscription
+func (s *Subscription) Close() {
+	s.bus.lock.Lock()
+	defer s.bus.lock.Unlock()
+	s.bus.remove(s)
+}
+
+func (s *Subscription) match(e *Event) bool {
+	return 0 == len(s.subjectHash) || s.subjectHash == e.SubjectHash
+}
+
+// remove must be called with lock held
+func (b *Bus) remove(s *Subscription) {
+	if !b.subscriptions[s] {
+		return
+	}
+	delete(b.subscriptions, s)
+	close(s.ch)
+}
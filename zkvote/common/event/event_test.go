@@ -0,0 +1,59 @@
+package event
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubscribe(t *testing.T) {
+	b := NewBus(3)
+	b.Publish(SubjectCreated, "a", nil)
+	b.Publish(SubjectCreated, "b", nil)
+
+	// resume from a cursor with a filter
+	replay, s, err := b.Subscribe("a", 0)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(replay))
+	assert.Equal(t, uint64(1), replay[0].ID)
+
+	b.Publish(IdentityInserted, "b", nil)
+	b.Publish(IdentityInserted, "a", map[string]interface{}{"index": 1})
+	e := <-s.C
+	assert.Equal(t, uint64(4), e.ID)
+	assert.Equal(t, IdentityInserted, e.Type)
+
+	s.Close()
+	_, ok := <-s.C
+	assert.False(t, ok)
+
+	// only the latest 3 events are kept
+	_, _, err = b.Subscribe("", 0)
+	assert.Equal(t, ErrCursorExpired, err)
+	replay, s, err = b.Subscribe("", 1)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(replay))
+	s.Close()
+
+	_, _, err = b.Subscribe("", 5)
+	assert.NotNil(t, err)
+}
+
+func TestSlowSubscriber(t *testing.T) {
+	b := NewBus(DEFAULT_CAPACITY)
+	_, s, err := b.Subscribe("", b.LastID())
+	assert.Nil(t, err)
+	for i := 0; i <= SUBSCRIPTION_BUFFER; i++ {
+		b.Publish(BallotAccepted, "a", nil)
+	}
+
+	// dropped after the buffer is full
+	n := 0
+	for range s.C {
+		n++
+	}
+	assert.Equal(t, SUBSCRIPTION_BUFFER, n)
+
+	var nilBus *Bus
+	nilBus.Publish(BallotAccepted, "a", nil)
+}
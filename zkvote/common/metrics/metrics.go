@@ -0,0 +1,328 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CONTENT_TYPE of the Prometheus text exposition format
+const CONTENT_TYPE = "text/plain; version=0.0.4; charset=utf-8"
+
+// DEFAULT_BUCKETS of latencies in seconds
+var DEFAULT_BUCKETS = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Metric is written in the Prometheus text exposition format
+type Metric interface {
+	Name() string
+	write(w *bufio.Writer)
+}
+
+// Registry ...
+type Registry struct {
+	metrics map[string]Metric
+	lock    sync.RWMutex
+}
+
+// Default is the registry which the metrics created by this package are registered to
+var Default = NewRegistry()
+
+// NewRegistry ...
+func NewRegistry() *Registry {
+	return &Registry{metrics: make(map[string]Metric)}
+}
+
+// Register a metric, it replaces the registered one of the same name
+func (r *Registry) Register(m Metric) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.metrics[m.Name()] = m
+}
+
+// Write all metrics sorted by name
+func (r *Registry) Write(w io.Writer) error {
+	r.lock.RLock()
+	names := make([]string, 0, len(r.metrics))
+	for name := range r.metrics {
+		names = append(names, name)
+	}
+	metrics := make([]Metric, 0, len(names))
+	sort.Strings(names)
+	for _, name := range names {
+		metrics = append(metrics, r.metrics[name])
+	}
+	r.lock.RUnlock()
+
+	bw := bufio.NewWriter(w)
+	for _, m := range metrics {
+		m.write(bw)
+	}
+	return bw.Flush()
+}
+
+// Labels are the values of the labels of a series, by label name
+type Labels map[string]string
+
+//
+// Counter
+//
+
+// Counter is a monotonically increasing value, per values of its labels
+type Counter struct {
+	name   string
+	help   string
+	labels []string
+	series map[string]float64
+	lock   sync.Mutex
+}
+
+// NewCounter creates a counter registered to the default registry
+func NewCounter(name string, help string, labels ...string) *Counter {
+	c := &Counter{name: name, help: help, labels: labels, series: make(map[string]float64)}
+	Default.Register(c)
+	return c
+}
+
+// Name ...
+func (c *Counter) Name() string {
+	return c.name
+}
+
+// Inc increases the series of the label values by 1
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add v to the series of the label values, v must not be negative
+func (c *Counter) Add(v float64, labelValues ...string) {
+	if 0 > v {
+		return
+	}
+	key := seriesKey(c.labels, labelValues)
+	c.lock.Lock()
+	c.series[key] += v
+	c.lock.Unlock()
+}
+
+// Value returns the value of the series of the label values
+func (c *Counter) Value(labelValues ...string) float64 {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.series[seriesKey(c.labels, labelValues)]
+}
+
+func (c *Counter) write(w *bufio.Writer) {
+	writeHeader(w, c.name, c.help, "counter")
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if 0 == len(c.labels) && 0 == len(c.series) {
+		writeSample(w, c.name, "", 0)
+	}
+	for _, key := range sortedKeys(c.series) {
+		writeSample(w, c.name, key, c.series[key])
+	}
+}
+
+//
+// Gauge
+//
+
+// GaugeFunc is a value which is collected when the metrics are written.
+// The function returns the values by the label values joined with "\xff", the empty key is the series without labels.
+type GaugeFunc struct {
+	name    string
+	help    string
+	labels  []string
+	collect func() map[string]float64
+}
+
+// NewGaugeFunc creates a gauge of a single series, it isn't registered
+func NewGaugeFunc(name string, help string, collect func() float64) *GaugeFunc {
+	return &GaugeFunc{name: name, help: help, collect: func() map[string]float64 {
+		return map[string]float64{"": collect()}
+	}}
+}
+
+// NewGaugeVecFunc creates a gauge of a series per value of the label, it isn't registered
+func NewGaugeVecFunc(name string, help string, label string, collect func() map[string]float64) *GaugeFunc {
+	return &GaugeFunc{name: name, help: help, labels: []string{label}, collect: collect}
+}
+
+// Name ...
+func (g *GaugeFunc) Name() string {
+	return g.name
+}
+
+func (g *GaugeFunc) write(w *bufio.Writer) {
+	writeHeader(w, g.name, g.help, "gauge")
+	values := g.collect()
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		key := ""
+		if 0 != len(g.labels) {
+			key = seriesKey(g.labels, strings.Split(k, "\xff"))
+		}
+		writeSample(w, g.name, key, values[k])
+	}
+}
+
+//
+// Histogram
+//
+
+// Histogram counts observations in buckets, per values of its labels
+type Histogram struct {
+	name    string
+	help    string
+	labels  []string
+	buckets []float64
+	series  map[string]*histogramSeries
+	lock    sync.Mutex
+}
+
+type histogramSeries struct {
+	counts []uint64 // cumulative counts are computed when written
+	sum    float64
+	count  uint64
+}
+
+// NewHistogram creates a histogram registered to the default registry, buckets are upper bounds in increasing order
+func NewHistogram(name string, help string, buckets []float64, labels ...string) *Histogram {
+	h := &Histogram{name: name, help: help, labels: labels, buckets: buckets, series: make(map[string]*histogramSeries)}
+	Default.Register(h)
+	return h
+}
+
+// Name ...
+func (h *Histogram) Name() string {
+	return h.name
+}
+
+// Observe a value of the series of the label values
+func (h *Histogram) Observe(v float64, labelValues ...string) {
+	key := seriesKey(h.labels, labelValues)
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	s, ok := h.series[key]
+	if !ok {
+		s = &histogramSeries{counts: make([]uint64, len(h.buckets))}
+		h.series[key] = s
+	}
+	i := sort.SearchFloat64s(h.buckets, v)
+	if i < len(h.buckets) {
+		s.counts[i]++
+	}
+	s.sum += v
+	s.count++
+}
+
+// Since observes the seconds elapsed since the start
+func (h *Histogram) Since(start time.Time, labelValues ...string) {
+	h.Observe(time.Since(start).Seconds(), labelValues...)
+}
+
+// Count returns the number of observations of the series of the label values
+func (h *Histogram) Count(labelValues ...string) uint64 {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	s, ok := h.series[seriesKey(h.labels, labelValues)]
+	if !ok {
+		return 0
+	}
+	return s.count
+}
+
+func (h *Histogram) write(w *bufio.Writer) {
+	writeHeader(w, h.name, h.help, "histogram")
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	keys := make([]string, 0, len(h.series))
+	for k := range h.series {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		s := h.series[key]
+		var cumulative uint64
+		for i, b := range h.buckets {
+			cumulative += s.counts[i]
+			writeSample(w, h.name+"_bucket", joinLabels(key, `le="`+formatFloat(b)+`"`), float64(cumulative))
+		}
+		writeSample(w, h.name+"_bucket", joinLabels(key, `le="+Inf"`), float64(s.count))
+		writeSample(w, h.name+"_sum", key, s.sum)
+		writeSample(w, h.name+"_count", key, float64(s.count))
+	}
+}
+
+//
+// internal functions
+//
+
+// seriesKey formats the labels, e.g. method="GET",code="200"
+func seriesKey(labels []string, values []string) string {
+	pairs := make([]string, len(labels))
+	for i, l := range labels {
+		v := ""
+		if i < len(values) {
+			v = values[i]
+		}
+		pairs[i] = l + `="` + escape(v) + `"`
+	}
+	return strings.Join(pairs, ",")
+}
+
+func joinLabels(key string, label string) string {
+	if 0 == len(key) {
+		return label
+	}
+	return key + "," + label
+}
+
+func escape(s string) string {
+	s = strings.Replace(s, `\`, `\\`, -1)
+	s = strings.Replace(s, "\n", `\n`, -1)
+	return strings.Replace(s, `"`, `\"`, -1)
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func writeHeader(w *bufio.Writer, name string, help string, kind string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, strings.Replace(help, "\n", " ", -1), name, kind)
+}
+
+func writeSample(w *bufio.Writer, name string, key string, v float64) {
+	if 0 == len(key) {
+		fmt.Fprintf(w, "%s %s\n", name, formatFloat(v))
+		return
+	}
+	fmt.Fprintf(w, "%s{%s} %s\n", name, key, formatFloat(v))
+}
+
+func sortedKeys(m map[string]float64) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
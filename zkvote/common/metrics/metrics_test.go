@@ -0,0 +1,50 @@
+package metrics
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWrite(t *testing.T) {
+	r := NewRegistry()
+	c := &Counter{name: "test_total", help: "Test counter", labels: []string{"kind"}, series: make(map[string]float64)}
+	r.Register(c)
+	c.Inc(`a"b`)
+	c.Add(2, "c")
+	assert.Equal(t, float64(2), c.Value("c"))
+
+	h := &Histogram{name: "test_seconds", help: "Test histogram", buckets: []float64{.1, 1}, series: make(map[string]*histogramSeries)}
+	r.Register(h)
+	h.Observe(.05)
+	h.Observe(.5)
+	h.Observe(5)
+	assert.Equal(t, uint64(3), h.Count())
+
+	r.Register(NewGaugeVecFunc("test_peers", "Test gauge", "topic", func() map[string]float64 {
+		return map[string]float64{"t1": 3}
+	}))
+	r.Register(NewGaugeFunc("test_up", "Test gauge", func() float64 { return 1 }))
+
+	var buf bytes.Buffer
+	assert.Nil(t, r.Write(&buf))
+	assert.Equal(t, `# HELP test_peers Test gauge
+# TYPE test_peers gauge
+test_peers{topic="t1"} 3
+# HELP test_seconds Test histogram
+# TYPE test_seconds histogram
+test_seconds_bucket{le="0.1"} 1
+test_seconds_bucket{le="1"} 2
+test_seconds_bucket{le="+Inf"} 3
+test_seconds_sum 5.55
+test_seconds_count 3
+# HELP test_total Test counter
+# TYPE test_total counter
+test_total{kind="a\"b"} 1
+test_total{kind="c"} 2
+# HELP test_up Test gauge
+# TYPE test_up gauge
+test_up 1
+`, buf.String())
+}
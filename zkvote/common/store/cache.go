@@ -2,7 +2,9 @@ package store
 
 import (
 	"strings"
+	"sync"
 
+	"github.com/unitychain/zkvote-node/zkvote/common/event"
 	"github.com/unitychain/zkvote-node/zkvote/common/utils"
 	"github.com/unitychain/zkvote-node/zkvote/model/ballot"
 	"github.com/unitychain/zkvote-node/zkvote/model/identity"
@@ -10,11 +12,14 @@ import (
 )
 
 // Cache ...
+// It's safe for concurrent use, the getters return copies of the maps.
 type Cache struct {
 	collectedSubjects subject.Map
 	createdSubjects   subject.Map
 	ballotMap         map[subject.HashHex]ballot.Map
 	idMap             map[subject.HashHex]identity.Set
+	events            *event.Bus
+	lock              sync.RWMutex
 }
 
 // NewCache ...
@@ -27,6 +32,12 @@ func NewCache() (*Cache, error) {
 	}, nil
 }
 
+// SetEvents sets the bus which the cache publishes the subjects to
+func (c *Cache) SetEvents(events *event.Bus) {
+	c.events = events
+}
+
+// isExistedSubject must be called with lock held
 func (c *Cache) isExistedSubject(sHex subject.HashHex) bool {
 	for k := range c.collectedSubjects {
 		if strings.EqualFold(utils.Remove0x(k.String()), utils.Remove0x(sHex.String())) {
@@ -43,47 +54,63 @@ func (c *Cache) isExistedSubject(sHex subject.HashHex) bool {
 
 // InsertColletedSubject .
 func (c *Cache) InsertColletedSubject(k subject.HashHex, v *subject.Subject) {
-	if c.isExistedSubject(k) {
+	if !c.insertSubject(c.collectedSubjects, k, v) {
 		return
 	}
-	c.collectedSubjects[k] = v
+	c.events.Publish(event.SubjectCollected, k.String(), v.JSON())
 }
 
 //GetCollectedSubjects .
 func (c *Cache) GetCollectedSubjects() subject.Map {
-	return c.collectedSubjects
+	return c.copySubjects(c.collectedSubjects)
 }
 
 // GetACollectedSubject ...
 func (c *Cache) GetACollectedSubject(k subject.HashHex) *subject.Subject {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
 	return c.collectedSubjects[k]
 }
 
 // InsertCreatedSubject .
 func (c *Cache) InsertCreatedSubject(k subject.HashHex, v *subject.Subject) {
-	if c.isExistedSubject(k) {
+	if !c.insertSubject(c.createdSubjects, k, v) {
 		return
 	}
-	c.createdSubjects[k] = v
+	c.events.Publish(event.SubjectCreated, k.String(), v.JSON())
 }
 
 //GetCreatedSubjects .
 func (c *Cache) GetCreatedSubjects() subject.Map {
-	return c.createdSubjects
+	return c.copySubjects(c.createdSubjects)
 }
 
 // GetACreatedSubject ...
 func (c *Cache) GetACreatedSubject(k subject.HashHex) *subject.Subject {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
 	return c.createdSubjects[k]
 }
 
 // GetBallotSet .
 func (c *Cache) GetBallotSet(subHashHex subject.HashHex) ballot.Map {
-	return c.ballotMap[subHashHex]
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	set, ok := c.ballotMap[subHashHex]
+	if !ok {
+		return nil
+	}
+	ballots := ballot.NewMap()
+	for k, b := range set {
+		ballots[k] = b
+	}
+	return ballots
 }
 
 // InsertBallotSet .
 func (c *Cache) InsertBallotSet(subHashHex subject.HashHex, ballotMap ballot.Map) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
 	_, ok := c.ballotMap[subHashHex]
 	if !ok {
 		c.ballotMap[subHashHex] = ballot.NewMap()
@@ -93,6 +120,8 @@ func (c *Cache) InsertBallotSet(subHashHex subject.HashHex, ballotMap ballot.Map
 
 // InsertBallot .
 func (c *Cache) InsertBallot(subHashHex subject.HashHex, ba *ballot.Ballot) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
 	_, ok := c.ballotMap[subHashHex]
 	if !ok {
 		c.ballotMap[subHashHex] = ballot.NewMap()
@@ -101,6 +130,8 @@ func (c *Cache) InsertBallot(subHashHex subject.HashHex, ba *ballot.Ballot) {
 }
 
 func (c *Cache) InsertIdentitySet(subHashHex subject.HashHex, idSet identity.Set) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
 	_, ok := c.idMap[subHashHex]
 	if !ok {
 		c.idMap[subHashHex] = identity.NewSet()
@@ -109,6 +140,8 @@ func (c *Cache) InsertIdentitySet(subHashHex subject.HashHex, idSet identity.Set
 }
 
 func (c *Cache) InsertIdentity(subHashHex subject.HashHex, id identity.Identity) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
 	_, ok := c.idMap[subHashHex]
 	if !ok {
 		c.idMap[subHashHex] = identity.NewSet()
@@ -117,5 +150,36 @@ func (c *Cache) InsertIdentity(subHashHex subject.HashHex, id identity.Identity)
 }
 
 func (c *Cache) GetIdentitySet(subHashHex subject.HashHex) identity.Set {
-	return c.idMap[subHashHex]
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	set, ok := c.idMap[subHashHex]
+	if !ok {
+		return nil
+	}
+	ids := identity.NewSet()
+	for k, v := range set {
+		ids[k] = v
+	}
+	return ids
+}
+
+// insertSubject returns false if the subject exists already, the events are published without the lock
+func (c *Cache) insertSubject(subjects subject.Map, k subject.HashHex, v *subject.Subject) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.isExistedSubject(k) {
+		return false
+	}
+	subjects[k] = v
+	return true
+}
+
+func (c *Cache) copySubjects(subjects subject.Map) subject.Map {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	copied := subject.NewMap()
+	for k, v := range subjects {
+		copied[k] = v
+	}
+	return copied
 }
@@ -1,14 +1,138 @@
 package store
 
-// NodeValidator ...
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+
+	ipns "github.com/ipfs/go-ipns"
+	"github.com/libp2p/go-libp2p-core/peer"
+	pstore "github.com/libp2p/go-libp2p-core/peerstore"
+	record "github.com/libp2p/go-libp2p-record"
+	"github.com/unitychain/zkvote-node/zkvote/model/subject"
+)
+
+// Namespaces of the zkvote records in the DHT
+const (
+	NAMESPACE       = "zkvote"
+	VOTES_NAMESPACE = "votes"
+)
+
+// VotesKey is the DHT key of the votes rolled up by a node, only the node can write it.
+// The votes used to be put under "zkp-votes", which any peer could overwrite.
+// Records under that key are refused by the validator now, so a node keeps its votes
+// in the local datastore and republishes them under this key on its next rollup.
+func VotesKey(p peer.ID) string {
+	return fmt.Sprintf("/%s/%s/%s", NAMESPACE, VOTES_NAMESPACE, peer.IDB58Encode(p))
+}
+
+// NewNodeValidator returns the validator of the DHT records accepted by zkvote nodes
+func NewNodeValidator(kb pstore.KeyBook) record.NamespacedValidator {
+	return record.NamespacedValidator{
+		NAMESPACE: NodeValidator{},
+		"pk":      record.PublicKeyValidator{},
+		"ipns":    ipns.Validator{KeyBook: kb},
+	}
+}
+
+// NodeValidator validates the records in the zkvote namespace.
+// A record has to be signed by its author and match the schema of its sub namespace.
 type NodeValidator struct{}
 
 // Validate ...
-func (nv NodeValidator) Validate(ket string, value []byte) error {
-	return nil
+func (nv NodeValidator) Validate(key string, value []byte) error {
+	ns, path, err := splitKey(key)
+	if err != nil {
+		return err
+	}
+
+	r, err := UnmarshalRecord(value)
+	if err != nil {
+		return fmt.Errorf("invalid record, %v", err)
+	}
+	err = r.Verify(key)
+	if err != nil {
+		return err
+	}
+
+	if VOTES_NAMESPACE != ns {
+		return record.ErrInvalidRecordType
+	}
+	return validateVotes(path, r)
 }
 
-// Select ...
+// Select chooses the valid record with the largest sequence number.
+// Records with the same sequence number are ordered by their value to keep the decision stable.
 func (nv NodeValidator) Select(key string, values [][]byte) (int, error) {
-	return 0, nil
+	best := -1
+	var bestRecord *Record
+	for i, v := range values {
+		if nil != nv.Validate(key, v) {
+			continue
+		}
+		r, _ := UnmarshalRecord(v)
+		if -1 == best || r.Seq > bestRecord.Seq ||
+			(r.Seq == bestRecord.Seq && 0 < bytes.Compare(r.Value, bestRecord.Value)) {
+			best = i
+			bestRecord = r
+		}
+	}
+
+	if -1 == best {
+		return 0, fmt.Errorf("no valid record for %v", key)
+	}
+	return best, nil
+}
+
+// splitKey splits /zkvote/<namespace>/<path>
+func splitKey(key string) (string, string, error) {
+	ns, rest, err := record.SplitKey(key)
+	if err != nil || NAMESPACE != ns {
+		return "", "", record.ErrInvalidRecordType
+	}
+	i := strings.IndexByte(rest, '/')
+	if i <= 0 || i == len(rest)-1 {
+		return "", "", record.ErrInvalidRecordType
+	}
+	return rest[:i], rest[i+1:], nil
+}
+
+// validateVotes checks the record is a set of votes written by the peer in the key
+func validateVotes(path string, r *Record) error {
+	p, err := peer.IDB58Decode(path)
+	if err != nil {
+		return fmt.Errorf("invalid peer id, %v", err)
+	}
+	author, err := r.Author()
+	if err != nil || author != p {
+		return fmt.Errorf("votes of %v can't be written by %v", p, author)
+	}
+
+	var v struct {
+		VoteLeaves []*struct {
+			Ballots []int           `json:"ballots"`
+			Hash    subject.HashHex `json:"hash"`
+		} `json:"VoteLeaves"`
+		Root *big.Int `json:"root"`
+	}
+	err = json.Unmarshal(r.Value, &v)
+	if err != nil {
+		return fmt.Errorf("invalid votes, %v", err)
+	}
+	if nil == v.Root || 0 == len(v.VoteLeaves) {
+		return fmt.Errorf("invalid votes, root and leaves are required")
+	}
+	for _, l := range v.VoteLeaves {
+		if nil == l {
+			return fmt.Errorf("invalid votes, empty leaf")
+		}
+		for _, b := range l.Ballots {
+			if 0 > b {
+				return fmt.Errorf("invalid votes, negative ballots")
+			}
+		}
+	}
+	return nil
 }
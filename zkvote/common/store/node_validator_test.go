@@ -0,0 +1,70 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/stretchr/testify/assert"
+)
+
+const testVotes = `{"VoteLeaves":[{"subject":null,"ballots":[0,0],"hash":""}],"root":0}`
+
+func newTestKey(t *testing.T) (crypto.PrivKey, peer.ID) {
+	prvKey, _, err := crypto.GenerateKeyPair(crypto.ECDSA, 0)
+	assert.Nil(t, err)
+	p, err := peer.IDFromPrivateKey(prvKey)
+	assert.Nil(t, err)
+	return prvKey, p
+}
+
+func newTestRecord(t *testing.T, key string, value string, seq uint64, prvKey crypto.PrivKey) []byte {
+	r, err := NewSignedRecord(key, []byte(value), seq, prvKey)
+	assert.Nil(t, err)
+	b, err := r.Byte()
+	assert.Nil(t, err)
+	return b
+}
+
+func TestValidate_Votes(t *testing.T) {
+	prvKey, p := newTestKey(t)
+	otherKey, _ := newTestKey(t)
+	key := VotesKey(p)
+
+	assert.Nil(t, NodeValidator{}.Validate(key, newTestRecord(t, key, testVotes, 1, prvKey)))
+	// written by another peer
+	assert.NotNil(t, NodeValidator{}.Validate(key, newTestRecord(t, key, testVotes, 1, otherKey)))
+	// not votes
+	assert.NotNil(t, NodeValidator{}.Validate(key, newTestRecord(t, key, `{"foo":1}`, 1, prvKey)))
+	// not signed
+	r, _ := UnmarshalRecord(newTestRecord(t, key, testVotes, 1, prvKey))
+	r.Signature = nil
+	b, _ := r.Byte()
+	assert.NotNil(t, NodeValidator{}.Validate(key, b))
+}
+
+func TestValidate_Namespace(t *testing.T) {
+	prvKey, _ := newTestKey(t)
+	for _, key := range []string{"zkp-votes", "/zkvote/votes", "/zkvote/foo/bar", "/zkvote/subject/bar", "/other/votes/bar"} {
+		assert.NotNil(t, NodeValidator{}.Validate(key, newTestRecord(t, key, testVotes, 1, prvKey)), key)
+	}
+}
+
+func TestSelect(t *testing.T) {
+	prvKey, p := newTestKey(t)
+	otherKey, _ := newTestKey(t)
+	key := VotesKey(p)
+
+	values := [][]byte{
+		newTestRecord(t, key, testVotes, 1, prvKey),
+		newTestRecord(t, key, testVotes, 3, prvKey),
+		newTestRecord(t, key, testVotes, 5, otherKey),
+		newTestRecord(t, key, testVotes, 2, prvKey),
+	}
+	i, err := NodeValidator{}.Select(key, values)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, i)
+
+	_, err = NodeValidator{}.Select(key, values[2:3])
+	assert.NotNil(t, err)
+}
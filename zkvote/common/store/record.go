@@ -0,0 +1,91 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+// Record is the value stored in the DHT under the zkvote namespace.
+// The payload is wrapped with its sequence number and signed by its author.
+type Record struct {
+	Value     []byte `json:"value"`
+	Seq       uint64 `json:"seq"`
+	PubKey    []byte `json:"pubKey"`
+	Signature []byte `json:"signature,omitempty"`
+}
+
+// NewSignedRecord ...
+func NewSignedRecord(key string, value []byte, seq uint64, prvKey crypto.PrivKey) (*Record, error) {
+	pubKey, err := prvKey.GetPublic().Bytes()
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Record{Value: value, Seq: seq, PubKey: pubKey}
+	data, err := r.signedData(key)
+	if err != nil {
+		return nil, err
+	}
+	r.Signature, err = prvKey.Sign(data)
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// UnmarshalRecord ...
+func UnmarshalRecord(data []byte) (*Record, error) {
+	var r Record
+	err := json.Unmarshal(data, &r)
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// Byte ...
+func (r *Record) Byte() ([]byte, error) {
+	return json.Marshal(r)
+}
+
+// Verify checks the record is signed for the key by the owner of its public key
+func (r *Record) Verify(key string) error {
+	if 0 == len(r.Signature) {
+		return fmt.Errorf("record is not signed")
+	}
+	pubKey, err := crypto.UnmarshalPublicKey(r.PubKey)
+	if err != nil {
+		return fmt.Errorf("invalid public key, %v", err)
+	}
+	data, err := r.signedData(key)
+	if err != nil {
+		return err
+	}
+	ok, err := pubKey.Verify(data, r.Signature)
+	if err != nil || !ok {
+		return fmt.Errorf("invalid signature")
+	}
+	return nil
+}
+
+// Author returns the peer ID of the signer
+func (r *Record) Author() (peer.ID, error) {
+	pubKey, err := crypto.UnmarshalPublicKey(r.PubKey)
+	if err != nil {
+		return "", err
+	}
+	return peer.IDFromPublicKey(pubKey)
+}
+
+// signedData is the record without signature, bound to the key
+func (r *Record) signedData(key string) ([]byte, error) {
+	unsigned := Record{Value: r.Value, Seq: r.Seq, PubKey: r.PubKey}
+	data, err := json.Marshal(unsigned)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(key), data...), nil
+}
@@ -2,8 +2,10 @@ package store
 
 import (
 	"context"
+	"time"
 
 	"github.com/ipfs/go-datastore"
+	"github.com/libp2p/go-libp2p-core/peer"
 	dht "github.com/libp2p/go-libp2p-kad-dht"
 	"github.com/whyrusleeping/base32"
 )
@@ -24,10 +26,22 @@ func NewStore(dht *dht.IpfsDHT, db datastore.Batching) (*Store, error) {
 
 // func (s *Store) InsertSubject(HashHex)
 
-// PutDHT ...
+// PutDHT signs the value with the key of the host and puts it into the DHT.
+// The sequence number is the current time, so the latest put wins.
 func (store *Store) PutDHT(k, v string) error {
 	ctx := context.Background()
-	err := store.dht.PutValue(ctx, k, []byte(v))
+
+	host := store.dht.Host()
+	r, err := NewSignedRecord(k, []byte(v), uint64(time.Now().UnixNano()), host.Peerstore().PrivKey(host.ID()))
+	if err != nil {
+		return err
+	}
+	data, err := r.Byte()
+	if err != nil {
+		return err
+	}
+
+	err = store.dht.PutValue(ctx, k, data)
 	if err != nil {
 		return err
 	}
@@ -35,7 +49,7 @@ func (store *Store) PutDHT(k, v string) error {
 	return nil
 }
 
-// GetDHT ...
+// GetDHT returns the value of the best record of the key in the DHT
 func (store *Store) GetDHT(k string) ([]byte, error) {
 	ctx := context.Background()
 
@@ -43,7 +57,16 @@ func (store *Store) GetDHT(k string) ([]byte, error) {
 	if err != nil {
 		return nil, err
 	}
-	return vb, nil
+	r, err := UnmarshalRecord(vb)
+	if err != nil {
+		return nil, err
+	}
+	return r.Value, nil
+}
+
+// HostID returns the peer ID of the host of the DHT
+func (store *Store) HostID() peer.ID {
+	return store.dht.Host().ID()
 }
 
 // PutLocal ...
@@ -29,12 +29,20 @@ var file *os.File = nil
 
 // OpenLog ...
 func OpenLog() {
+	OpenLogFile("logs.log", "DEBUG")
+}
+
+// OpenLogFile logs to the file and the console, level is one of DEBUG, INFO, NOTICE, WARNING, ERROR and CRITICAL
+func OpenLogFile(path string, level string) error {
 	if file != nil {
-		return
+		return nil
+	}
+	logLevel, err := logging.LogLevel(level)
+	if err != nil {
+		return err
 	}
 
-	var err error
-	file, err = os.OpenFile("logs.log", os.O_APPEND|os.O_CREATE|os.O_RDWR, 0666)
+	file, err = os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_RDWR, 0666)
 	if err != nil {
 		fmt.Printf("error opening file: %v", err)
 	}
@@ -42,14 +50,16 @@ func OpenLog() {
 
 	backendFile := logging.NewLogBackend(file, "", 0)
 	backendFileFormatter := logging.NewBackendFormatter(backendFile, formatFile)
+	backendFileLeveled := logging.AddModuleLevel(backendFileFormatter)
+	backendFileLeveled.SetLevel(logLevel, "")
 
 	backendConsole := logging.NewLogBackend(os.Stdout, "", 0)
 	backendConsoleFormatter := logging.NewBackendFormatter(backendConsole, formatConsole)
 	backendConsoleLeveled := logging.AddModuleLevel(backendConsoleFormatter)
-	//backendConsoleLeveled.SetLevel(logging.WARNING, "")
-
-	logging.SetBackend(backendFileFormatter, backendConsoleLeveled)
+	backendConsoleLeveled.SetLevel(logLevel, "")
 
+	logging.SetBackend(backendFileLeveled, backendConsoleLeveled)
+	return nil
 }
 
 // CloseLog ...
@@ -0,0 +1,74 @@
+package archive
+
+import (
+	"encoding/json"
+	"fmt"
+
+	ba "github.com/unitychain/zkvote-node/zkvote/model/ballot"
+	"github.com/unitychain/zkvote-node/zkvote/model/subject"
+)
+
+// FORMAT identifies a zkvote archive
+const FORMAT = "zkvote-archive"
+
+// VERSION is the version of the archive format written by this node
+const VERSION = 1
+
+// Archive is a self-contained record of the voting state of a subject.
+// It contains everything to rebuild the identity tree and re-verify every ballot.
+type Archive struct {
+	Format    string `json:"format"`
+	Version   int    `json:"version"`
+	CreatedAt int64  `json:"createdAt"` // unix time
+
+	Subject *subject.Subject `json:"subject"`
+	// Identities are the identity commitments in the order of the identity tree
+	Identities []string `json:"identities"`
+	// Admissions are the signatures of the proposer admitting the identities, in the same order.
+	// They are omitted if the subject doesn't require them.
+	Admissions []string `json:"admissions,omitempty"`
+	// Roots are the roots of the identity tree, from the empty tree to the current one
+	Roots []string `json:"roots"`
+	// Ballots are in the order they were accepted
+	Ballots []*ba.Ballot `json:"ballots"`
+	// VerificationKey is the key the ballots were verified with
+	VerificationKey string `json:"verificationKey"`
+}
+
+// NewArchive parses and checks an archive
+func NewArchive(data []byte) (*Archive, error) {
+	var a Archive
+	err := json.Unmarshal(data, &a)
+	if err != nil {
+		return nil, fmt.Errorf("invalid archive, %v", err)
+	}
+	if FORMAT != a.Format {
+		return nil, fmt.Errorf("not a zkvote archive")
+	}
+	if 0 >= a.Version || VERSION < a.Version {
+		return nil, fmt.Errorf("unsupported archive version %d", a.Version)
+	}
+	if nil == a.Subject || 0 == len(a.Subject.Title) || nil == a.Subject.Proposer {
+		return nil, fmt.Errorf("invalid archive, subject is required")
+	}
+	if 0 != len(a.Admissions) && len(a.Admissions) != len(a.Identities) {
+		return nil, fmt.Errorf("invalid archive, %d admissions for %d identities", len(a.Admissions), len(a.Identities))
+	}
+	if 0 == len(a.Roots) {
+		return nil, fmt.Errorf("invalid archive, roots are required")
+	}
+	for _, b := range a.Ballots {
+		if nil == b {
+			return nil, fmt.Errorf("invalid archive, incomplete ballot")
+		}
+		if err := b.Validate(); nil != err {
+			return nil, fmt.Errorf("invalid archive, %v", err)
+		}
+	}
+	return &a, nil
+}
+
+// Byte ...
+func (a *Archive) Byte() ([]byte, error) {
+	return json.MarshalIndent(a, "", "  ")
+}
@@ -0,0 +1,33 @@
+package archive
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	id "github.com/unitychain/zkvote-node/zkvote/model/identity"
+	"github.com/unitychain/zkvote-node/zkvote/model/subject"
+)
+
+func TestNewArchive(t *testing.T) {
+	a := &Archive{
+		Format:     FORMAT,
+		Version:    VERSION,
+		Subject:    subject.NewSubject("title", "description", id.NewIdentity("0x1f")),
+		Identities: []string{"0x1f"},
+		Roots:      []string{"0x1", "0x2"},
+	}
+	data, err := a.Byte()
+	assert.Nil(t, err)
+	parsed, err := NewArchive(data)
+	assert.Nil(t, err)
+	assert.Equal(t, a.Subject.HashHex(), parsed.Subject.HashHex())
+	assert.Equal(t, a.Roots, parsed.Roots)
+
+	a.Version = VERSION + 1
+	data, _ = a.Byte()
+	_, err = NewArchive(data)
+	assert.NotNil(t, err)
+
+	_, err = NewArchive([]byte(`{"format":"other","version":1}`))
+	assert.NotNil(t, err)
+}
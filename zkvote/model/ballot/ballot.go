@@ -5,8 +5,10 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"math/big"
 
 	"github.com/arnaucube/go-snark/externalVerif"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/unitychain/zkvote-node/zkvote/common/utils"
 )
 
@@ -40,9 +42,72 @@ func NewBallot(proof string) (*Ballot, error) {
 		utils.LogErrorf("parse proof: unmarshal error %v", err.Error())
 		return nil, err
 	}
+	err = b.Validate()
+	if err != nil {
+		utils.LogWarningf("parse proof: %v", err)
+		return nil, err
+	}
 	return &b, nil
 }
 
+// Validate checks a ballot is complete and its root and nullifier hash are the ones its proof commits to.
+// The public signals are normalized to decimals, so the ballot is always keyed by the proven nullifier hash.
+func (b *Ballot) Validate() error {
+	if nil == b.Proof || 4 != len(b.PublicSignal) {
+		return fmt.Errorf("incomplete ballot")
+	}
+	for i, s := range b.PublicSignal {
+		n, ok := big.NewInt(0).SetString(s, 10)
+		if !ok || 0 > n.Sign() {
+			return fmt.Errorf("invalid public signal, %v", s)
+		}
+		b.PublicSignal[i] = n.String()
+	}
+	if !isSameSignal(b.Root, b.PublicSignal[0]) {
+		return fmt.Errorf("root doesn't match the proof (%v)/(%v)", b.Root, b.PublicSignal[0])
+	}
+	if !isSameSignal(b.NullifierHash, b.PublicSignal[1]) {
+		return fmt.Errorf("nullifier hash doesn't match the proof (%v)/(%v)", b.NullifierHash, b.PublicSignal[1])
+	}
+	b.Root = b.PublicSignal[0]
+	b.NullifierHash = b.PublicSignal[1]
+	return nil
+}
+
+func isSameSignal(claimed string, signal string) bool {
+	x, ok := big.NewInt(0).SetString(claimed, 10)
+	if !ok {
+		return false
+	}
+	y, _ := big.NewInt(0).SetString(signal, 10)
+	return 0 == x.Cmp(y)
+}
+
+// SignalHash returns the signal hash of a ballot voting for the option at optionIndex.
+// It is the keccak256 hash of the big-endian index, e.g. keccak256(0x01) for the second option.
+func SignalHash(optionIndex int) string {
+	b := big.NewInt(int64(optionIndex)).Bytes()
+	if 0 == len(b) {
+		b = []byte{0}
+	}
+	return big.NewInt(0).SetBytes(crypto.Keccak256(b)).String()
+}
+
+// SignalHash returns the signal hash of the ballot
+func (b *Ballot) SignalHash() string {
+	return b.PublicSignal[2]
+}
+
+// RootSignal returns the root of the identity tree the proof commits to
+func (b *Ballot) RootSignal() string {
+	return b.PublicSignal[0]
+}
+
+// NullifierSignal returns the nullifier hash the proof commits to
+func (b *Ballot) NullifierSignal() string {
+	return b.PublicSignal[1]
+}
+
 // Byte ...
 func (b *Ballot) Byte() ([]byte, error) {
 	return json.Marshal(b)
@@ -62,7 +127,7 @@ func (b *Ballot) Hash() *Hash {
 // NullifierHashHex ...
 func (b *Ballot) NullifierHashHex() NullifierHashHex {
 	// Convert to hex if needed
-	return NullifierHashHex(b.NullifierHash)
+	return NullifierHashHex(b.NullifierSignal())
 }
 
 // JSON .
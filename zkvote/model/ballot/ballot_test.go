@@ -0,0 +1,46 @@
+package ballot
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignalHash(t *testing.T) {
+	// Signal hashes of no/yes accepted by the original yes/no circuit
+	assert.Equal(t, "85131057757245807317576516368191972321038229705283732634690444270750521936266", SignalHash(0))
+	assert.Equal(t, "43379584054787486383572605962602545002668015983485933488536749112829893476306", SignalHash(1))
+	assert.NotEqual(t, SignalHash(1), SignalHash(2))
+}
+
+func TestNewBallot_Malformed(t *testing.T) {
+	_, err := NewBallot("")
+	assert.NotNil(t, err)
+	_, err = NewBallot("{}")
+	assert.NotNil(t, err)
+	_, err = NewBallot(`{"proof":{},"public_signal":["1","2","3"]}`)
+	assert.NotNil(t, err)
+	_, err = NewBallot(`{"proof":{},"public_signal":["1","2","3","x"]}`)
+	assert.NotNil(t, err)
+	_, err = NewBallot(`{"proof":{},"public_signal":["1","2","3","-4"]}`)
+	assert.NotNil(t, err)
+	_, err = NewBallot(`{"root":"1","nullifier_hash":"2","proof":{},"public_signal":["1","2","3","4"]}`)
+	assert.Nil(t, err)
+}
+
+func TestNewBallot_Unproven(t *testing.T) {
+	// the root and nullifier hash have to be the ones the proof commits to
+	_, err := NewBallot(`{"root":"1","nullifier_hash":"2","proof":{},"public_signal":["5","2","3","4"]}`)
+	assert.NotNil(t, err)
+	_, err = NewBallot(`{"root":"1","nullifier_hash":"111","proof":{},"public_signal":["1","2","3","4"]}`)
+	assert.NotNil(t, err)
+	_, err = NewBallot(`{"root":"1","nullifier_hash":"x","proof":{},"public_signal":["1","2","3","4"]}`)
+	assert.NotNil(t, err)
+
+	// the signals are normalized, so a ballot is keyed by the proven nullifier hash
+	b, err := NewBallot(`{"root":"01","nullifier_hash":"002","proof":{},"public_signal":["1","02","3","4"]}`)
+	assert.Nil(t, err)
+	assert.Equal(t, "2", b.NullifierHash)
+	assert.Equal(t, NullifierHashHex("2"), b.NullifierHashHex())
+	assert.Equal(t, "1", b.RootSignal())
+}
@@ -5,23 +5,30 @@ import (
 	"sync"
 
 	"github.com/libp2p/go-libp2p-core/host"
+	"github.com/unitychain/zkvote-node/zkvote/common/event"
 	"github.com/unitychain/zkvote-node/zkvote/common/store"
 )
 
 type Context struct {
-	Mutex *sync.RWMutex
-	Host  host.Host
-	Store *store.Store
-	Cache *store.Cache
-	Ctx   *context.Context
+	Mutex  *sync.RWMutex
+	Host   host.Host
+	Store  *store.Store
+	Cache  *store.Cache
+	Ctx    *context.Context
+	Events *event.Bus
 }
 
 func NewContext(mutex *sync.RWMutex, host host.Host, store *store.Store, cache *store.Cache, ctx *context.Context) *Context {
+	events := event.NewBus(event.DEFAULT_CAPACITY)
+	if nil != cache {
+		cache.SetEvents(events)
+	}
 	return &Context{
-		Mutex: mutex,
-		Host:  host,
-		Store: store,
-		Cache: cache,
-		Ctx:   ctx,
+		Mutex:  mutex,
+		Host:   host,
+		Store:  store,
+		Cache:  cache,
+		Ctx:    ctx,
+		Events: events,
 	}
 }
@@ -0,0 +1,344 @@
+package credential
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	uuid "github.com/google/uuid"
+	ic "github.com/libp2p/go-libp2p-core/crypto"
+)
+
+// Encodings of a credential
+const (
+	FORMAT_JSONLD = "jsonld"
+	FORMAT_JWT    = "jwt"
+)
+
+// Terms of a membership credential and its proof
+const (
+	CONTEXT_CREDENTIALS = "https://www.w3.org/2018/credentials/v1"
+	TYPE_CREDENTIAL     = "VerifiableCredential"
+	TYPE_MEMBERSHIP     = "ZkvoteMembershipCredential"
+	PROOF_TYPE          = "Ed25519Signature2018"
+	PROOF_PURPOSE       = "assertionMethod"
+)
+
+// Membership is the claim of a credential:
+// the identity commitment is admitted to the subject by the proposer.
+type Membership struct {
+	SubjectHash        string `json:"subjectHash"`
+	IdentityCommitment string `json:"identityCommitment"`
+	Admission          string `json:"admission,omitempty"`
+}
+
+// Proof is a detached JWS of the credential without proof
+type Proof struct {
+	Type               string `json:"type"`
+	Created            string `json:"created"`
+	VerificationMethod string `json:"verificationMethod"`
+	ProofPurpose       string `json:"proofPurpose"`
+	JWS                string `json:"jws"`
+}
+
+// Credential is a W3C verifiable credential of the membership of a subject.
+// The JSON-LD proof signs the JSON form of the credential without proof,
+// not the RDF normalization of it, so only the fields declared here are covered.
+type Credential struct {
+	Context           []string    `json:"@context"`
+	ID                string      `json:"id"`
+	Type              []string    `json:"type"`
+	Issuer            string      `json:"issuer"`
+	IssuanceDate      string      `json:"issuanceDate"`
+	ExpirationDate    string      `json:"expirationDate,omitempty"`
+	CredentialSubject *Membership `json:"credentialSubject"`
+	Proof             *Proof      `json:"proof,omitempty"`
+}
+
+type jwtHeader struct {
+	Alg  string   `json:"alg"`
+	Typ  string   `json:"typ,omitempty"`
+	Kid  string   `json:"kid,omitempty"`
+	B64  *bool    `json:"b64,omitempty"`
+	Crit []string `json:"crit,omitempty"`
+}
+
+type jwtClaims struct {
+	Iss string      `json:"iss"`
+	Jti string      `json:"jti"`
+	Nbf int64       `json:"nbf"`
+	Exp int64       `json:"exp,omitempty"`
+	VC  *Credential `json:"vc"`
+}
+
+// NewCredential issues an unsigned credential, expiresAt is in unix time and 0 for never
+func NewCredential(issuer string, membership *Membership, expiresAt int64) *Credential {
+	c := &Credential{
+		Context:           []string{CONTEXT_CREDENTIALS},
+		ID:                "urn:uuid:" + uuid.New().String(),
+		Type:              []string{TYPE_CREDENTIAL, TYPE_MEMBERSHIP},
+		Issuer:            issuer,
+		IssuanceDate:      time.Now().UTC().Format(time.RFC3339),
+		CredentialSubject: membership,
+	}
+	if 0 != expiresAt {
+		c.ExpirationDate = time.Unix(expiresAt, 0).UTC().Format(time.RFC3339)
+	}
+	return c
+}
+
+// Encode signs the credential with the key of the issuer in the format
+func (c *Credential) Encode(prvKey ic.PrivKey, format string) (string, error) {
+	switch format {
+	case FORMAT_JSONLD, "":
+		err := c.SignJSONLD(prvKey)
+		if err != nil {
+			return "", err
+		}
+		b, err := json.Marshal(c)
+		return string(b), err
+	case FORMAT_JWT:
+		return c.SignJWT(prvKey)
+	}
+	return "", fmt.Errorf("unsupported credential format, %v", format)
+}
+
+// SignJSONLD adds the proof of the issuer
+func (c *Credential) SignJSONLD(prvKey ic.PrivKey) error {
+	err := c.checkIssuer(prvKey)
+	if err != nil {
+		return err
+	}
+	payload, err := c.unsigned()
+	if err != nil {
+		return err
+	}
+	b64 := false
+	header, err := encodeSegment(&jwtHeader{Alg: "EdDSA", B64: &b64, Crit: []string{"b64"}})
+	if err != nil {
+		return err
+	}
+	sig, err := prvKey.Sign(append([]byte(header+"."), payload...))
+	if err != nil {
+		return err
+	}
+
+	c.Proof = &Proof{
+		Type:               PROOF_TYPE,
+		Created:            time.Now().UTC().Format(time.RFC3339),
+		VerificationMethod: VerificationMethod(c.Issuer),
+		ProofPurpose:       PROOF_PURPOSE,
+		JWS:                header + ".." + base64.RawURLEncoding.EncodeToString(sig),
+	}
+	return nil
+}
+
+// SignJWT encodes the credential as a JWT signed by the issuer
+func (c *Credential) SignJWT(prvKey ic.PrivKey) (string, error) {
+	err := c.checkIssuer(prvKey)
+	if err != nil {
+		return "", err
+	}
+	issuedAt, expiresAt, err := c.period()
+	if err != nil {
+		return "", err
+	}
+	vc := *c
+	vc.Proof = nil
+
+	header, err := encodeSegment(&jwtHeader{Alg: "EdDSA", Typ: "JWT", Kid: VerificationMethod(c.Issuer)})
+	if err != nil {
+		return "", err
+	}
+	claims, err := encodeSegment(&jwtClaims{Iss: c.Issuer, Jti: c.ID, Nbf: issuedAt, Exp: expiresAt, VC: &vc})
+	if err != nil {
+		return "", err
+	}
+	sig, err := prvKey.Sign([]byte(header + "." + claims))
+	if err != nil {
+		return "", err
+	}
+	return header + "." + claims + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+// Parse decodes a credential in JSON-LD or JWT and verifies the signature of its issuer.
+// The expiration is checked by CheckExpiration.
+func Parse(data string) (*Credential, error) {
+	data = strings.TrimSpace(data)
+	if strings.HasPrefix(data, "{") {
+		return parseJSONLD(data)
+	}
+	return parseJWT(data)
+}
+
+// CheckExpiration returns an error if the credential isn't valid at the given time
+func (c *Credential) CheckExpiration(now time.Time) error {
+	issuedAt, expiresAt, err := c.period()
+	if err != nil {
+		return err
+	}
+	if now.Unix() < issuedAt {
+		return fmt.Errorf("credential isn't valid until %v", c.IssuanceDate)
+	}
+	if 0 != expiresAt && now.Unix() >= expiresAt {
+		return fmt.Errorf("credential has expired at %v", c.ExpirationDate)
+	}
+	return nil
+}
+
+//
+// Internal functions
+//
+func parseJSONLD(data string) (*Credential, error) {
+	var c Credential
+	err := json.Unmarshal([]byte(data), &c)
+	if err != nil {
+		return nil, fmt.Errorf("invalid credential, %v", err)
+	}
+	err = c.checkFields()
+	if err != nil {
+		return nil, err
+	}
+	if nil == c.Proof || PROOF_TYPE != c.Proof.Type || VerificationMethod(c.Issuer) != c.Proof.VerificationMethod {
+		return nil, fmt.Errorf("credential isn't signed by its issuer")
+	}
+
+	parts := strings.Split(c.Proof.JWS, ".")
+	if 3 != len(parts) || 0 != len(parts[1]) {
+		return nil, fmt.Errorf("invalid proof of credential")
+	}
+	var header jwtHeader
+	err = decodeSegment(parts[0], &header)
+	if err != nil || "EdDSA" != header.Alg || nil == header.B64 || *header.B64 {
+		return nil, fmt.Errorf("unsupported proof of credential")
+	}
+	payload, err := c.unsigned()
+	if err != nil {
+		return nil, err
+	}
+	err = verify(c.Issuer, append([]byte(parts[0]+"."), payload...), parts[2])
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func parseJWT(data string) (*Credential, error) {
+	parts := strings.Split(data, ".")
+	if 3 != len(parts) {
+		return nil, fmt.Errorf("credential is neither JSON-LD nor JWT")
+	}
+	var header jwtHeader
+	err := decodeSegment(parts[0], &header)
+	if err != nil || "EdDSA" != header.Alg {
+		return nil, fmt.Errorf("unsupported JWT credential")
+	}
+	var claims jwtClaims
+	err = decodeSegment(parts[1], &claims)
+	if err != nil || nil == claims.VC {
+		return nil, fmt.Errorf("invalid JWT credential")
+	}
+	err = verify(claims.Iss, []byte(parts[0]+"."+parts[1]), parts[2])
+	if err != nil {
+		return nil, err
+	}
+
+	// The registered claims take precedence over the properties of vc
+	c := claims.VC
+	c.Issuer = claims.Iss
+	c.ID = claims.Jti
+	c.IssuanceDate = time.Unix(claims.Nbf, 0).UTC().Format(time.RFC3339)
+	c.ExpirationDate = ""
+	if 0 != claims.Exp {
+		c.ExpirationDate = time.Unix(claims.Exp, 0).UTC().Format(time.RFC3339)
+	}
+	c.Proof = nil
+	err = c.checkFields()
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *Credential) checkFields() error {
+	if 0 == len(c.Issuer) || 0 == len(c.ID) || nil == c.CredentialSubject {
+		return fmt.Errorf("invalid credential, issuer, id and credentialSubject are required")
+	}
+	for _, t := range c.Type {
+		if TYPE_MEMBERSHIP == t {
+			return nil
+		}
+	}
+	return fmt.Errorf("not a membership credential")
+}
+
+func (c *Credential) checkIssuer(prvKey ic.PrivKey) error {
+	if nil == prvKey {
+		return fmt.Errorf("invalid input")
+	}
+	did, err := NewDIDKey(prvKey.GetPublic())
+	if err != nil {
+		return err
+	}
+	if did != c.Issuer {
+		return fmt.Errorf("key doesn't belong to the issuer %v", c.Issuer)
+	}
+	return nil
+}
+
+// period returns the issuance and expiration in unix time, 0 if it never expires
+func (c *Credential) period() (int64, int64, error) {
+	issuedAt, err := time.Parse(time.RFC3339, c.IssuanceDate)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid issuance date, %v", err)
+	}
+	if 0 == len(c.ExpirationDate) {
+		return issuedAt.Unix(), 0, nil
+	}
+	expiresAt, err := time.Parse(time.RFC3339, c.ExpirationDate)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid expiration date, %v", err)
+	}
+	return issuedAt.Unix(), expiresAt.Unix(), nil
+}
+
+// unsigned is the payload of the JSON-LD proof
+func (c *Credential) unsigned() ([]byte, error) {
+	u := *c
+	u.Proof = nil
+	return json.Marshal(&u)
+}
+
+func verify(issuer string, data []byte, signature string) error {
+	pubKey, err := PubKeyFromDID(issuer)
+	if err != nil {
+		return err
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil {
+		return fmt.Errorf("invalid signature of credential, %v", err)
+	}
+	ok, err := pubKey.Verify(data, sig)
+	if err != nil || !ok {
+		return fmt.Errorf("credential isn't signed by its issuer")
+	}
+	return nil
+}
+
+func encodeSegment(v interface{}) (string, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func decodeSegment(s string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
@@ -0,0 +1,70 @@
+package credential
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	ic "github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestIssuer(t *testing.T) (ic.PrivKey, string) {
+	prvKey, pubKey, err := ic.GenerateKeyPair(ic.Ed25519, 0)
+	assert.Nil(t, err)
+	did, err := NewDIDKey(pubKey)
+	assert.Nil(t, err)
+	return prvKey, did
+}
+
+func TestDIDKey(t *testing.T) {
+	prvKey, did := newTestIssuer(t)
+	assert.True(t, strings.HasPrefix(did, "did:key:z6Mk"))
+
+	pubKey, err := PubKeyFromDID(did)
+	assert.Nil(t, err)
+	assert.True(t, pubKey.Equals(prvKey.GetPublic()))
+
+	_, err = PubKeyFromDID("did:web:example.com")
+	assert.NotNil(t, err)
+}
+
+func TestEncodeAndParse(t *testing.T) {
+	prvKey, did := newTestIssuer(t)
+	otherKey, _ := newTestIssuer(t)
+	membership := &Membership{SubjectHash: "abcd", IdentityCommitment: "0x1234", Admission: "5678"}
+
+	for _, format := range []string{FORMAT_JSONLD, FORMAT_JWT} {
+		c := NewCredential(did, membership, time.Now().Unix()+60)
+		data, err := c.Encode(prvKey, format)
+		assert.Nil(t, err)
+
+		parsed, err := Parse(data)
+		assert.Nil(t, err)
+		assert.Equal(t, c.ID, parsed.ID)
+		assert.Equal(t, did, parsed.Issuer)
+		assert.Equal(t, *membership, *parsed.CredentialSubject)
+		assert.Nil(t, parsed.CheckExpiration(time.Now()))
+		assert.NotNil(t, parsed.CheckExpiration(time.Now().Add(time.Minute)))
+
+		// Only the issuer can sign
+		_, err = c.Encode(otherKey, format)
+		assert.NotNil(t, err)
+	}
+
+	// Tampered claims
+	c := NewCredential(did, membership, 0)
+	data, err := c.Encode(prvKey, FORMAT_JSONLD)
+	assert.Nil(t, err)
+	_, err = Parse(strings.Replace(data, "0x1234", "0x5678", 1))
+	assert.NotNil(t, err)
+
+	jwt, err := c.Encode(prvKey, FORMAT_JWT)
+	assert.Nil(t, err)
+	parts := strings.Split(jwt, ".")
+	_, err = Parse(parts[0] + "." + parts[1] + "." + strings.Repeat("A", len(parts[2])))
+	assert.NotNil(t, err)
+
+	_, err = Parse("not a credential")
+	assert.NotNil(t, err)
+}
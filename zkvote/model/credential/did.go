@@ -0,0 +1,48 @@
+package credential
+
+import (
+	"fmt"
+	"strings"
+
+	ic "github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/mr-tron/base58"
+)
+
+// DID_KEY_PREFIX is the prefix of a did:key identifier
+const DID_KEY_PREFIX = "did:key:"
+
+// ed25519Codec is the multicodec prefix of an ed25519 public key
+var ed25519Codec = []byte{0xed, 0x01}
+
+// NewDIDKey returns the did:key identifier of an ed25519 public key
+func NewDIDKey(pubKey ic.PubKey) (string, error) {
+	if nil == pubKey || ic.Ed25519 != pubKey.Type() {
+		return "", fmt.Errorf("did:key requires an ed25519 key")
+	}
+	raw, err := pubKey.Raw()
+	if err != nil {
+		return "", err
+	}
+	// multibase base58btc
+	return DID_KEY_PREFIX + "z" + base58.Encode(append(append([]byte{}, ed25519Codec...), raw...)), nil
+}
+
+// PubKeyFromDID resolves a did:key identifier to its public key
+func PubKeyFromDID(did string) (ic.PubKey, error) {
+	if !strings.HasPrefix(did, DID_KEY_PREFIX+"z") {
+		return nil, fmt.Errorf("unsupported DID, %v", did)
+	}
+	b, err := base58.Decode(did[len(DID_KEY_PREFIX)+1:])
+	if err != nil {
+		return nil, fmt.Errorf("invalid DID, %v", err)
+	}
+	if len(b) <= len(ed25519Codec) || b[0] != ed25519Codec[0] || b[1] != ed25519Codec[1] {
+		return nil, fmt.Errorf("DID isn't an ed25519 key, %v", did)
+	}
+	return ic.UnmarshalEd25519PublicKey(b[len(ed25519Codec):])
+}
+
+// VerificationMethod returns the key of a did:key document which signs credentials
+func VerificationMethod(did string) string {
+	return did + "#" + strings.TrimPrefix(did, DID_KEY_PREFIX)
+}
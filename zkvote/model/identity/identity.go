@@ -6,6 +6,10 @@ import (
 	"github.com/unitychain/zkvote-node/zkvote/common/utils"
 )
 
+// snarkScalarField is the order of the scalar field of the circuit,
+// a commitment has to be an element of it
+var snarkScalarField, _ = big.NewInt(0).SetString("21888242871839275222246405745257275088548364400416034343698204186575808495617", 10)
+
 // Identity ...
 type Identity string
 
@@ -40,6 +44,15 @@ func (id *Identity) Hex() string {
 	return utils.GetHexStringFromBigInt(big.NewInt(0).SetBytes(id.Byte()))
 }
 
+// IsValid checks the identity commitment is a non-zero element of the scalar field
+func (id *Identity) IsValid() bool {
+	if nil == id || 0 == len(*id) || nil != utils.CheckHex(id.String()) {
+		return false
+	}
+	value := big.NewInt(0).SetBytes(id.Byte())
+	return 0 != value.Sign() && 0 > value.Cmp(snarkScalarField)
+}
+
 // Equal .
 func (id *Identity) Equal(othID *Identity) bool {
 	self := big.NewInt(0).SetBytes(id.Byte())
@@ -0,0 +1,18 @@
+package identity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsValid(t *testing.T) {
+	assert.True(t, NewIdentity("0x1f").IsValid())
+	assert.True(t, NewIdentityFromBytes([]byte{0x12, 0x34}).IsValid())
+
+	assert.False(t, NewIdentityFromBytes([]byte{}).IsValid())
+	assert.False(t, NewIdentityFromBytes([]byte{0x00}).IsValid())
+	// the order of the scalar field itself
+	assert.False(t, NewIdentity("30644e72e131a029b85045b68181585d2833e84879b9709143e1f593f0000001").IsValid())
+	assert.False(t, NewIdentity("ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff").IsValid())
+}
@@ -0,0 +1,61 @@
+package identity
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/unitychain/zkvote-node/zkvote/common/utils"
+)
+
+// Insertion is an identity commitment inserted at Index of the identity tree,
+// whose root is PrevRoot before the insertion.
+// An insertion without PrevRoot is a request for the sequencer of the subject to insert it.
+// Admission is the signature of the proposer admitting the identity to the subject.
+type Insertion struct {
+	Identity  string `json:"identity"`
+	Index     int    `json:"index"`
+	PrevRoot  string `json:"prevRoot,omitempty"`
+	Admission string `json:"admission,omitempty"`
+}
+
+// NewInsertion ...
+func NewInsertion(identity *Identity, index int, prevRoot string, admission string) *Insertion {
+	return &Insertion{Identity: identity.String(), Index: index, PrevRoot: prevRoot, Admission: admission}
+}
+
+// NewInsertionRequest ...
+func NewInsertionRequest(identity *Identity, admission string) *Insertion {
+	return &Insertion{Identity: identity.String(), Index: -1, Admission: admission}
+}
+
+// ParseInsertion ...
+func ParseInsertion(data []byte) (*Insertion, error) {
+	var i Insertion
+	err := json.Unmarshal(data, &i)
+	if err != nil {
+		return nil, err
+	}
+	if nil != utils.CheckHex(i.Identity) {
+		return nil, fmt.Errorf("invalid identity commitment, %v", i.Identity)
+	}
+	if !i.IsRequest() && (0 > i.Index || nil == utils.GetBigIntFromHexString(i.PrevRoot)) {
+		return nil, fmt.Errorf("invalid insertion at %d after %v", i.Index, i.PrevRoot)
+	}
+	return &i, nil
+}
+
+// IsRequest .
+func (i *Insertion) IsRequest() bool {
+	return 0 == len(i.PrevRoot)
+}
+
+// GetIdentity .
+func (i *Insertion) GetIdentity() *Identity {
+	return NewIdentity(i.Identity)
+}
+
+// Byte ...
+func (i *Insertion) Byte() []byte {
+	b, _ := json.Marshal(i)
+	return b
+}
@@ -2,7 +2,6 @@ package identity
 
 import (
 	"fmt"
-	"math"
 	"math/big"
 
 	merkletree "github.com/cbergoon/merkletree"
@@ -67,41 +66,53 @@ func (t TreeContent) BigInt() *big.Int {
 //
 
 // MerkleTree ...
+// An incremental merkle tree which keeps the nodes of every level,
+// so inserting, updating or getting the path of a leaf costs O(levels) hashes.
 type MerkleTree struct {
 	levels    uint8
 	nextIndex uint
 
-	root       *TreeContent
-	content    []merkletree.Content
-	mapContent map[merkletree.Content]uint
+	root *TreeContent
+	// nodes[0] are the leaves and nodes[levels] is the root.
+	// Nodes which only cover empty leaves are not stored, see zeros.
+	nodes [][]*big.Int
+	// zeros[i] is the value of a node at level i whose leaves are all empty
+	zeros      []*big.Int
+	mapContent map[string]uint
 
 	hashStrategy hashWrapper.HashWrapper
 }
 
+// MaxLevels is the max depth of a merkle tree
+const MaxLevels uint8 = 32
+
 // NewMerkleTree ...
 func NewMerkleTree(levels uint8) (*MerkleTree, error) {
+	if 0 == levels || MaxLevels < levels {
+		return nil, fmt.Errorf("invalid levels %d, should be 1 to %d", levels, MaxLevels)
+	}
 
 	// create an empty tree with zeros
-	var content []merkletree.Content
-	numIndexes := int(math.Pow(2, float64(levels)))
-	for i := 0; i < numIndexes; i++ {
-		content = append(content, TreeContent{big.NewInt(0)})
-	}
 	tree := &MerkleTree{
 		levels:       levels,
 		nextIndex:    0,
-		content:      content,
+		nodes:        make([][]*big.Int, levels+1),
+		zeros:        make([]*big.Int, levels+1),
 		hashStrategy: hashWrapper.MiMC7New(),
-		mapContent:   make(map[merkletree.Content]uint),
+		mapContent:   make(map[string]uint),
 	}
 
-	root, err := tree.calculateRoot()
-	if err != nil {
-		return nil, err
+	tree.zeros[0] = big.NewInt(0)
+	for i := 1; i <= int(levels); i++ {
+		h, err := tree.hash(tree.zeros[i-1], tree.zeros[i-1])
+		if err != nil {
+			return nil, err
+		}
+		tree.zeros[i] = h
 	}
-	tree.root = root
+	tree.root = &TreeContent{tree.zeros[levels]}
 
-	utils.LogInfof("total elements %d, init root: %v", numIndexes, root)
+	utils.LogInfof("total elements %d, init root: %v", tree.capacity(), tree.root)
 	return tree, nil
 }
 
@@ -113,17 +124,19 @@ func (m *MerkleTree) Insert(value TreeContent) (int, error) {
 	if m.IsExisted(&value) {
 		return -1, fmt.Errorf("value existed, %v", value)
 	}
+	if m.nextIndex >= m.capacity() {
+		return -1, fmt.Errorf("merkle tree is full, %d elements", m.capacity())
+	}
 
 	currentIndex := m.nextIndex
 	m.addContent(currentIndex, value)
 
-	root, err := m.calculateRoot()
+	err := m.updatePath(currentIndex)
 	if err != nil {
 		return -1, err
 	}
-	m.root = root
 	m.nextIndex++
-	utils.LogInfof("new merkle root: %v", root)
+	utils.LogInfof("new merkle root: %v", m.root)
 
 	return int(currentIndex), nil
 }
@@ -137,18 +150,18 @@ func (m *MerkleTree) Update(index uint, oldValue, newValue TreeContent) error {
 	if !m.IsExisted(&oldValue) {
 		return fmt.Errorf("old value not existed, %v", oldValue)
 	}
-	if eq, _ := m.content[index].Equals(oldValue); !eq {
+	if index >= m.nextIndex || 0 != m.nodes[0][index].Cmp(oldValue.x) {
 		// utils.LogErrorf("value of the index is not matched old value.")
 		return fmt.Errorf("value of the index is not matched old value")
 	}
 
+	delete(m.mapContent, oldValue.x.String())
 	m.addContent(index, newValue)
-	root, err := m.calculateRoot()
+	err := m.updatePath(index)
 	if err != nil {
 		return err
 	}
-	m.root = root
-	utils.LogInfof("new root: %v", root)
+	utils.LogInfof("new root: %v", m.root)
 
 	return nil
 }
@@ -194,53 +207,22 @@ func (m *MerkleTree) GetIntermediateValues(value *TreeContent) ([]*TreeContent,
 		}
 	}
 
-	currentIdx := idx
+	currentIdx := uint(idx)
 	imv := make([]*TreeContent, m.levels)
 	imi := make([]int, m.levels)
-	tree := make([][]*TreeContent, m.levels)
 	for i := 0; i < int(m.levels); i++ {
-
-		numElemofLevel := int(math.Pow(2, float64(int(m.levels)-i)))
-		valuesOfLevel := make([]*TreeContent, numElemofLevel)
-		imi[i] = currentIdx % 2
-		for j := 0; j < numElemofLevel; j++ {
-			if 0 == i {
-				h, _ := m.content[j].CalculateHash()
-				valuesOfLevel[j] = &TreeContent{big.NewInt(0).SetBytes(h)}
-
-			} else {
-				h, err := m.hashStrategy.Hash([]*big.Int{tree[i-1][2*j].x, tree[i-1][2*j+1].x, big.NewInt(0)})
-				if err != nil {
-					utils.LogFatalf("ERROR: calculate mimc7 error, %v", err.Error())
-					return nil, nil, nil
-				}
-				valuesOfLevel[j] = &TreeContent{h}
-			}
-		}
-
-		if 0 == currentIdx%2 {
-			imv[i] = valuesOfLevel[currentIdx+1]
-		} else {
-			imv[i] = valuesOfLevel[currentIdx-1]
-		}
-
-		tree[i] = valuesOfLevel
-		currentIdx = int(currentIdx / 2)
+		imi[i] = int(currentIdx % 2)
+		imv[i] = &TreeContent{m.getNode(i, currentIdx^1)}
+		currentIdx /= 2
 	}
-	root, err := m.hashStrategy.Hash([]*big.Int{tree[m.levels-1][0].x, tree[m.levels-1][1].x, big.NewInt(0)})
-	if err != nil {
-		utils.LogFatalf("ERROR: calculate root through mimc7 error, %v", err.Error())
-		return nil, nil, nil
-	}
-	return imv, imi, &TreeContent{root}
+	return imv, imi, m.root
 }
 
 // GetAllContent .
 func (m *MerkleTree) GetAllContent() []*TreeContent {
-	lenWithContent := len(m.mapContent)
-	ids := make([]*TreeContent, lenWithContent)
-	for i := 0; i < lenWithContent; i++ {
-		ids[i] = &TreeContent{m.content[i].(TreeContent).x}
+	ids := make([]*TreeContent, m.nextIndex)
+	for i := 0; i < int(m.nextIndex); i++ {
+		ids[i] = &TreeContent{m.nodes[0][i]}
 	}
 	return ids
 }
@@ -254,12 +236,13 @@ func (m *MerkleTree) IsExisted(value *TreeContent) bool {
 }
 
 // GetIndexByValue .
+// An empty leaf is zero, so the index of zero is the next empty leaf if it hasn't been inserted.
 func (m *MerkleTree) GetIndexByValue(value *TreeContent) int {
-	for i, c := range m.content {
-		if eq, _ := c.Equals(*value); eq {
-			utils.LogDebugf("Got index, %d", i)
-			return i
-		}
+	if i, ok := m.mapContent[value.x.String()]; ok {
+		return int(i)
+	}
+	if 0 == value.x.Sign() && m.nextIndex < m.capacity() {
+		return int(m.nextIndex)
 	}
 	return -1
 }
@@ -273,13 +256,47 @@ func (m *MerkleTree) Len() int {
 // Internal functions
 //
 
+func (m *MerkleTree) capacity() uint {
+	return 1 << m.levels
+}
+
 func (m *MerkleTree) addContent(idx uint, value TreeContent) {
-	m.content[idx] = value
-	m.mapContent[value] = idx
+	m.setNode(0, idx, value.x)
+	m.mapContent[value.x.String()] = idx
+}
+
+// getNode returns the node of the level at the index
+func (m *MerkleTree) getNode(level int, idx uint) *big.Int {
+	if idx < uint(len(m.nodes[level])) {
+		return m.nodes[level][idx]
+	}
+	return m.zeros[level]
 }
 
-func (m *MerkleTree) calculateRoot() (*TreeContent, error) {
+// setNode sets the node of the level at the index, nodes are filled from left to right
+func (m *MerkleTree) setNode(level int, idx uint, value *big.Int) {
+	if idx < uint(len(m.nodes[level])) {
+		m.nodes[level][idx] = value
+		return
+	}
+	m.nodes[level] = append(m.nodes[level], value)
+}
+
+// updatePath recalculates the nodes from the leaf at the index to the root
+func (m *MerkleTree) updatePath(idx uint) error {
+	for i := 0; i < int(m.levels); i++ {
+		h, err := m.hash(m.getNode(i, idx&^1), m.getNode(i, idx|1))
+		if err != nil {
+			utils.LogErrorf("ERROR: calculate mimc7 error, %v", err.Error())
+			return err
+		}
+		idx /= 2
+		m.setNode(i+1, idx, h)
+	}
+	m.root = &TreeContent{m.nodes[m.levels][0]}
+	return nil
+}
 
-	_, _, root := m.GetIntermediateValues(nil)
-	return root, nil
+func (m *MerkleTree) hash(left, right *big.Int) (*big.Int, error) {
+	return m.hashStrategy.Hash([]*big.Int{left, right, big.NewInt(0)})
 }
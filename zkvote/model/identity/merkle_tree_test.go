@@ -3,6 +3,7 @@ package identity
 import (
 	"fmt"
 	"math/big"
+	"math/rand"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -23,7 +24,7 @@ func TestInsert(t *testing.T) {
 	assert.Nil(t, err, "new merkle tree instance error")
 
 	idc, _ := big.NewInt(0).SetString(idCommitment, 10)
-	idx, err := tree.Insert(&TreeContent{idc})
+	idx, err := tree.Insert(TreeContent{idc})
 	assert.Nil(t, err, "insert error")
 	assert.Equal(t, 0, idx)
 
@@ -37,7 +38,7 @@ func TestInsert_10IDs(t *testing.T) {
 
 	for i := 0; i < 10; i++ {
 		idc, _ := big.NewInt(0).SetString(fmt.Sprintf("%d", 100*i+1), 10)
-		idx, err := tree.Insert(&TreeContent{idc})
+		idx, err := tree.Insert(TreeContent{idc})
 		tree.GetIntermediateValues(&TreeContent{idc})
 
 		assert.Nil(t, err, "insert error")
@@ -53,11 +54,11 @@ func TestInsert_Double(t *testing.T) {
 	assert.Nil(t, err, "new identity instance error")
 
 	idc, _ := big.NewInt(0).SetString(idCommitment, 10)
-	idx, err := tree.Insert(&TreeContent{idc})
+	idx, err := tree.Insert(TreeContent{idc})
 	assert.Nil(t, err, "Insert error")
 	assert.Equal(t, 0, idx)
 
-	idx, err = tree.Insert(&TreeContent{idc})
+	idx, err = tree.Insert(TreeContent{idc})
 	assert.NotNil(t, err, "should not Insert successfully")
 }
 
@@ -66,11 +67,11 @@ func TestTreeUpdate(t *testing.T) {
 	assert.Nil(t, err, "new identity instance error")
 
 	idc, _ := big.NewInt(0).SetString(idCommitment, 10)
-	idx, err := tree.Insert(&TreeContent{idc})
+	idx, err := tree.Insert(TreeContent{idc})
 	assert.Nil(t, err, "Insert error")
 	assert.Equal(t, 0, idx)
 
-	err = tree.Update(uint(idx), &TreeContent{idc}, &TreeContent{big.NewInt(100)})
+	err = tree.Update(uint(idx), TreeContent{idc}, TreeContent{big.NewInt(100)})
 	assert.Nil(t, err, "update error")
 	assert.Equal(t, "5860034871856545585778554733050920915757269722014984975581566802595274325429", tree.GetRoot().String())
 
@@ -81,11 +82,11 @@ func TestTreeUpdate_IncorrectIdx(t *testing.T) {
 	assert.Nil(t, err, "new identity instance error")
 
 	idc, _ := big.NewInt(0).SetString(idCommitment, 10)
-	idx, err := tree.Insert(&TreeContent{idc})
+	idx, err := tree.Insert(TreeContent{idc})
 	assert.Nil(t, err, "Insert error")
 	assert.Equal(t, 0, idx)
 
-	err = tree.Update(1, &TreeContent{idc}, &TreeContent{big.NewInt(100)})
+	err = tree.Update(1, TreeContent{idc}, TreeContent{big.NewInt(100)})
 	assert.NotNil(t, err, "update error")
 }
 
@@ -94,11 +95,11 @@ func TestTreeUpdate_IncorrectContent(t *testing.T) {
 	assert.Nil(t, err, "new identity instance error")
 
 	idc, _ := big.NewInt(0).SetString(idCommitment, 10)
-	idx, err := tree.Insert(&TreeContent{idc})
+	idx, err := tree.Insert(TreeContent{idc})
 	assert.Nil(t, err, "Insert error")
 	assert.Equal(t, 0, idx)
 
-	err = tree.Update(uint(idx), &TreeContent{big.NewInt(100)}, &TreeContent{big.NewInt(100)})
+	err = tree.Update(uint(idx), TreeContent{big.NewInt(100)}, TreeContent{big.NewInt(100)})
 	assert.NotNil(t, err, "update error")
 }
 
@@ -107,7 +108,7 @@ func TestGetAllContent(t *testing.T) {
 	assert.Nil(t, err, "new identity instance error")
 
 	idc, _ := big.NewInt(0).SetString(idCommitment, 10)
-	idx, err := tree.Insert(&TreeContent{idc})
+	idx, err := tree.Insert(TreeContent{idc})
 	assert.Nil(t, err, "Insert error")
 	assert.Equal(t, 0, idx)
 
@@ -120,7 +121,7 @@ func TestIsExisted(t *testing.T) {
 	assert.Nil(t, err, "new identity instance error")
 
 	idc, _ := big.NewInt(0).SetString(idCommitment, 10)
-	idx, err := tree.Insert(&TreeContent{idc})
+	idx, err := tree.Insert(TreeContent{idc})
 	assert.Nil(t, err, "Insert error")
 	assert.Equal(t, 0, idx)
 
@@ -134,7 +135,7 @@ func TestGetIndexByValue(t *testing.T) {
 
 	for i := 0; i < 10; i++ {
 		idc, _ := big.NewInt(0).SetString(fmt.Sprintf("%d", 100*i+1), 10)
-		idx, err := tree.Insert(&TreeContent{idc})
+		idx, err := tree.Insert(TreeContent{idc})
 		assert.Nil(t, err, "insert error")
 
 		index := tree.GetIndexByValue(&TreeContent{idc})
@@ -142,3 +143,108 @@ func TestGetIndexByValue(t *testing.T) {
 		assert.Equal(t, idx, index)
 	}
 }
+
+// calculateRoot calculates the root from all leaves as the tree used to do on every insert
+func calculateRoot(t *MerkleTree) *big.Int {
+	level := make([]*big.Int, t.capacity())
+	for i := range level {
+		level[i] = t.getNode(0, uint(i))
+	}
+	for len(level) > 1 {
+		next := make([]*big.Int, len(level)/2)
+		for j := range next {
+			next[j], _ = t.hash(level[2*j], level[2*j+1])
+		}
+		level = next
+	}
+	return level[0]
+}
+
+func TestInsert_SameRootAsFullCalculation(t *testing.T) {
+	tree, err := NewMerkleTree(6)
+	assert.Nil(t, err, "new merkle tree instance error")
+	assert.Equal(t, calculateRoot(tree), tree.GetRoot().BigInt())
+
+	for i := 0; i < 20; i++ {
+		_, err := tree.Insert(TreeContent{big.NewInt(rand.Int63())})
+		assert.Nil(t, err, "insert error")
+		assert.Equal(t, calculateRoot(tree), tree.GetRoot().BigInt())
+	}
+
+	old := tree.GetAllContent()[7]
+	err = tree.Update(7, *old, TreeContent{big.NewInt(100)})
+	assert.Nil(t, err, "update error")
+	assert.Equal(t, calculateRoot(tree), tree.GetRoot().BigInt())
+}
+
+func TestGetIntermediateValues(t *testing.T) {
+	tree, err := NewMerkleTree(10)
+	assert.Nil(t, err, "new merkle tree instance error")
+
+	for i := 0; i < 5; i++ {
+		idc := big.NewInt(int64(100*i + 1))
+		_, err := tree.Insert(TreeContent{idc})
+		assert.Nil(t, err, "insert error")
+	}
+
+	// Hash up the path of a leaf to get the root
+	idc := TreeContent{big.NewInt(301)}
+	values, indexes, root := tree.GetIntermediateValues(&idc)
+	node := idc.BigInt()
+	for i := range values {
+		if 0 == indexes[i] {
+			node, _ = tree.hash(node, values[i].BigInt())
+		} else {
+			node, _ = tree.hash(values[i].BigInt(), node)
+		}
+	}
+	assert.Equal(t, []int{1, 1, 0, 0, 0, 0, 0, 0, 0, 0}, indexes)
+	assert.Equal(t, root.BigInt(), node)
+	assert.Equal(t, tree.GetRoot().BigInt(), node)
+}
+
+func TestInsert_Full(t *testing.T) {
+	tree, err := NewMerkleTree(2)
+	assert.Nil(t, err, "new merkle tree instance error")
+
+	for i := 0; i < 4; i++ {
+		_, err := tree.Insert(TreeContent{big.NewInt(int64(i + 1))})
+		assert.Nil(t, err, "insert error")
+	}
+	_, err = tree.Insert(TreeContent{big.NewInt(5)})
+	assert.NotNil(t, err, "should not insert into a full tree")
+}
+
+func BenchmarkInsert(b *testing.B) {
+	tree, _ := NewMerkleTree(10)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if tree.Len() == 1<<10 {
+			b.StopTimer()
+			tree, _ = NewMerkleTree(10)
+			b.StartTimer()
+		}
+		tree.Insert(TreeContent{big.NewInt(int64(i + 1))})
+	}
+}
+
+// BenchmarkFullCalculation is the cost of an insert before the tree became incremental
+func BenchmarkFullCalculation(b *testing.B) {
+	tree, _ := NewMerkleTree(10)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		calculateRoot(tree)
+	}
+}
+
+func BenchmarkGetIntermediateValues(b *testing.B) {
+	tree, _ := NewMerkleTree(10)
+	for i := 0; i < 100; i++ {
+		tree.Insert(TreeContent{big.NewInt(int64(i + 1))})
+	}
+	value := TreeContent{big.NewInt(50)}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tree.GetIntermediateValues(&value)
+	}
+}
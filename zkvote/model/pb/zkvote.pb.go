@@ -20,6 +20,37 @@ var _ = math.Inf
 // proto package needs to be updated.
 const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
 
+type SyncKind int32
+
+const (
+	SyncKind_STATE      SyncKind = 0
+	SyncKind_LEAVES     SyncKind = 1
+	SyncKind_NULLIFIERS SyncKind = 2
+	SyncKind_BALLOTS    SyncKind = 3
+)
+
+var SyncKind_name = map[int32]string{
+	0: "STATE",
+	1: "LEAVES",
+	2: "NULLIFIERS",
+	3: "BALLOTS",
+}
+
+var SyncKind_value = map[string]int32{
+	"STATE":      0,
+	"LEAVES":     1,
+	"NULLIFIERS": 2,
+	"BALLOTS":    3,
+}
+
+func (x SyncKind) String() string {
+	return proto.EnumName(SyncKind_name, int32(x))
+}
+
+func (SyncKind) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_dfa3fe919df2773c, []int{0}
+}
+
 // a protocol define a set of reuqest and responses
 type SubjectRequest struct {
 	Metadata *Metadata `protobuf:"bytes,1,opt,name=metadata,proto3" json:"metadata,omitempty"`
@@ -129,6 +160,14 @@ type Subject struct {
 	Title                string   `protobuf:"bytes,1,opt,name=title,proto3" json:"title,omitempty"`
 	Description          string   `protobuf:"bytes,2,opt,name=description,proto3" json:"description,omitempty"`
 	Proposer             string   `protobuf:"bytes,3,opt,name=proposer,proto3" json:"proposer,omitempty"`
+	Options              []string `protobuf:"bytes,4,rep,name=options,proto3" json:"options,omitempty"`
+	OpenAt               int64    `protobuf:"varint,5,opt,name=openAt,proto3" json:"openAt,omitempty"`
+	CloseAt              int64    `protobuf:"varint,6,opt,name=closeAt,proto3" json:"closeAt,omitempty"`
+	TreeLevel            uint32   `protobuf:"varint,7,opt,name=treeLevel,proto3" json:"treeLevel,omitempty"`
+	VerificationKey      string   `protobuf:"bytes,8,opt,name=verificationKey,proto3" json:"verificationKey,omitempty"`
+	Sequencer            string   `protobuf:"bytes,9,opt,name=sequencer,proto3" json:"sequencer,omitempty"`
+	ProposerKey          string   `protobuf:"bytes,10,opt,name=proposerKey,proto3" json:"proposerKey,omitempty"`
+	Issuer               string   `protobuf:"bytes,11,opt,name=issuer,proto3" json:"issuer,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -180,6 +219,62 @@ func (m *Subject) GetProposer() string {
 	return ""
 }
 
+func (m *Subject) GetOptions() []string {
+	if m != nil {
+		return m.Options
+	}
+	return nil
+}
+
+func (m *Subject) GetOpenAt() int64 {
+	if m != nil {
+		return m.OpenAt
+	}
+	return 0
+}
+
+func (m *Subject) GetCloseAt() int64 {
+	if m != nil {
+		return m.CloseAt
+	}
+	return 0
+}
+
+func (m *Subject) GetTreeLevel() uint32 {
+	if m != nil {
+		return m.TreeLevel
+	}
+	return 0
+}
+
+func (m *Subject) GetVerificationKey() string {
+	if m != nil {
+		return m.VerificationKey
+	}
+	return ""
+}
+
+func (m *Subject) GetSequencer() string {
+	if m != nil {
+		return m.Sequencer
+	}
+	return ""
+}
+
+func (m *Subject) GetProposerKey() string {
+	if m != nil {
+		return m.ProposerKey
+	}
+	return ""
+}
+
+func (m *Subject) GetIssuer() string {
+	if m != nil {
+		return m.Issuer
+	}
+	return ""
+}
+
 type IdentityRequest struct {
 	Metadata *Metadata `protobuf:"bytes,1,opt,name=metadata,proto3" json:"metadata,omitempty"`
 	// method specific data
@@ -420,6 +515,390 @@ func (m *BallotResponse) GetBallotSet() []string {
 	return nil
 }
 
+type RollupRequest struct {
+	Metadata *Metadata `protobuf:"bytes,1,opt,name=metadata,proto3" json:"metadata,omitempty"`
+	// method specific data
+	Message              string   `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	SubjectHash          []byte   `protobuf:"bytes,3,opt,name=subjectHash,proto3" json:"subjectHash,omitempty"`
+	PrevRoot             string   `protobuf:"bytes,4,opt,name=prevRoot,proto3" json:"prevRoot,omitempty"`
+	Proof                string   `protobuf:"bytes,5,opt,name=proof,proto3" json:"proof,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RollupRequest) Reset()         { *m = RollupRequest{} }
+func (m *RollupRequest) String() string { return proto.CompactTextString(m) }
+func (*RollupRequest) ProtoMessage()    {}
+func (*RollupRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_dfa3fe919df2773c, []int{7}
+}
+
+func (m *RollupRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RollupRequest.Unmarshal(m, b)
+}
+func (m *RollupRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RollupRequest.Marshal(b, m, deterministic)
+}
+func (m *RollupRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RollupRequest.Merge(m, src)
+}
+func (m *RollupRequest) XXX_Size() int {
+	return xxx_messageInfo_RollupRequest.Size(m)
+}
+func (m *RollupRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_RollupRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RollupRequest proto.InternalMessageInfo
+
+func (m *RollupRequest) GetMetadata() *Metadata {
+	if m != nil {
+		return m.Metadata
+	}
+	return nil
+}
+
+func (m *RollupRequest) GetMessage() string {
+	if m != nil {
+		return m.Message
+	}
+	return ""
+}
+
+func (m *RollupRequest) GetSubjectHash() []byte {
+	if m != nil {
+		return m.SubjectHash
+	}
+	return nil
+}
+
+func (m *RollupRequest) GetPrevRoot() string {
+	if m != nil {
+		return m.PrevRoot
+	}
+	return ""
+}
+
+func (m *RollupRequest) GetProof() string {
+	if m != nil {
+		return m.Proof
+	}
+	return ""
+}
+
+type RollupResponse struct {
+	Metadata *Metadata `protobuf:"bytes,1,opt,name=metadata,proto3" json:"metadata,omitempty"`
+	// response specific data
+	Message              string   `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	SubjectHash          []byte   `protobuf:"bytes,3,opt,name=subjectHash,proto3" json:"subjectHash,omitempty"`
+	Root                 string   `protobuf:"bytes,4,opt,name=root,proto3" json:"root,omitempty"`
+	Error                string   `protobuf:"bytes,5,opt,name=error,proto3" json:"error,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RollupResponse) Reset()         { *m = RollupResponse{} }
+func (m *RollupResponse) String() string { return proto.CompactTextString(m) }
+func (*RollupResponse) ProtoMessage()    {}
+func (*RollupResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_dfa3fe919df2773c, []int{8}
+}
+
+func (m *RollupResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RollupResponse.Unmarshal(m, b)
+}
+func (m *RollupResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RollupResponse.Marshal(b, m, deterministic)
+}
+func (m *RollupResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RollupResponse.Merge(m, src)
+}
+func (m *RollupResponse) XXX_Size() int {
+	return xxx_messageInfo_RollupResponse.Size(m)
+}
+func (m *RollupResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_RollupResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RollupResponse proto.InternalMessageInfo
+
+func (m *RollupResponse) GetMetadata() *Metadata {
+	if m != nil {
+		return m.Metadata
+	}
+	return nil
+}
+
+func (m *RollupResponse) GetMessage() string {
+	if m != nil {
+		return m.Message
+	}
+	return ""
+}
+
+func (m *RollupResponse) GetSubjectHash() []byte {
+	if m != nil {
+		return m.SubjectHash
+	}
+	return nil
+}
+
+func (m *RollupResponse) GetRoot() string {
+	if m != nil {
+		return m.Root
+	}
+	return ""
+}
+
+func (m *RollupResponse) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+type SyncRequest struct {
+	Metadata *Metadata `protobuf:"bytes,1,opt,name=metadata,proto3" json:"metadata,omitempty"`
+	// method specific data
+	Message              string   `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	SubjectHash          []byte   `protobuf:"bytes,3,opt,name=subjectHash,proto3" json:"subjectHash,omitempty"`
+	Kind                 SyncKind `protobuf:"varint,4,opt,name=kind,proto3,enum=protocols.zkvote.SyncKind" json:"kind,omitempty"`
+	From                 int32    `protobuf:"varint,5,opt,name=from,proto3" json:"from,omitempty"`
+	To                   int32    `protobuf:"varint,6,opt,name=to,proto3" json:"to,omitempty"`
+	Buckets              []int32  `protobuf:"varint,7,rep,packed,name=buckets,proto3" json:"buckets,omitempty"`
+	Nullifiers           []string `protobuf:"bytes,8,rep,name=nullifiers,proto3" json:"nullifiers,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SyncRequest) Reset()         { *m = SyncRequest{} }
+func (m *SyncRequest) String() string { return proto.CompactTextString(m) }
+func (*SyncRequest) ProtoMessage()    {}
+func (*SyncRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_dfa3fe919df2773c, []int{9}
+}
+
+func (m *SyncRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SyncRequest.Unmarshal(m, b)
+}
+func (m *SyncRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SyncRequest.Marshal(b, m, deterministic)
+}
+func (m *SyncRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SyncRequest.Merge(m, src)
+}
+func (m *SyncRequest) XXX_Size() int {
+	return xxx_messageInfo_SyncRequest.Size(m)
+}
+func (m *SyncRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_SyncRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SyncRequest proto.InternalMessageInfo
+
+func (m *SyncRequest) GetMetadata() *Metadata {
+	if m != nil {
+		return m.Metadata
+	}
+	return nil
+}
+
+func (m *SyncRequest) GetMessage() string {
+	if m != nil {
+		return m.Message
+	}
+	return ""
+}
+
+func (m *SyncRequest) GetSubjectHash() []byte {
+	if m != nil {
+		return m.SubjectHash
+	}
+	return nil
+}
+
+func (m *SyncRequest) GetKind() SyncKind {
+	if m != nil {
+		return m.Kind
+	}
+	return SyncKind_STATE
+}
+
+func (m *SyncRequest) GetFrom() int32 {
+	if m != nil {
+		return m.From
+	}
+	return 0
+}
+
+func (m *SyncRequest) GetTo() int32 {
+	if m != nil {
+		return m.To
+	}
+	return 0
+}
+
+func (m *SyncRequest) GetBuckets() []int32 {
+	if m != nil {
+		return m.Buckets
+	}
+	return nil
+}
+
+func (m *SyncRequest) GetNullifiers() []string {
+	if m != nil {
+		return m.Nullifiers
+	}
+	return nil
+}
+
+type SyncResponse struct {
+	Metadata *Metadata `protobuf:"bytes,1,opt,name=metadata,proto3" json:"metadata,omitempty"`
+	// response specific data
+	Message              string   `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	SubjectHash          []byte   `protobuf:"bytes,3,opt,name=subjectHash,proto3" json:"subjectHash,omitempty"`
+	Kind                 SyncKind `protobuf:"varint,4,opt,name=kind,proto3,enum=protocols.zkvote.SyncKind" json:"kind,omitempty"`
+	Root                 string   `protobuf:"bytes,5,opt,name=root,proto3" json:"root,omitempty"`
+	LeafCount            int32    `protobuf:"varint,6,opt,name=leafCount,proto3" json:"leafCount,omitempty"`
+	BallotDigest         []byte   `protobuf:"bytes,7,opt,name=ballotDigest,proto3" json:"ballotDigest,omitempty"`
+	BucketDigests        [][]byte `protobuf:"bytes,8,rep,name=bucketDigests,proto3" json:"bucketDigests,omitempty"`
+	PrefixRoot           string   `protobuf:"bytes,9,opt,name=prefixRoot,proto3" json:"prefixRoot,omitempty"`
+	Leaves               []string `protobuf:"bytes,10,rep,name=leaves,proto3" json:"leaves,omitempty"`
+	Nullifiers           []string `protobuf:"bytes,11,rep,name=nullifiers,proto3" json:"nullifiers,omitempty"`
+	Ballots              []string `protobuf:"bytes,12,rep,name=ballots,proto3" json:"ballots,omitempty"`
+	Error                string   `protobuf:"bytes,13,opt,name=error,proto3" json:"error,omitempty"`
+	Admissions           []string `protobuf:"bytes,14,rep,name=admissions,proto3" json:"admissions,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SyncResponse) Reset()         { *m = SyncResponse{} }
+func (m *SyncResponse) String() string { return proto.CompactTextString(m) }
+func (*SyncResponse) ProtoMessage()    {}
+func (*SyncResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_dfa3fe919df2773c, []int{10}
+}
+
+func (m *SyncResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SyncResponse.Unmarshal(m, b)
+}
+func (m *SyncResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SyncResponse.Marshal(b, m, deterministic)
+}
+func (m *SyncResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SyncResponse.Merge(m, src)
+}
+func (m *SyncResponse) XXX_Size() int {
+	return xxx_messageInfo_SyncResponse.Size(m)
+}
+func (m *SyncResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_SyncResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SyncResponse proto.InternalMessageInfo
+
+func (m *SyncResponse) GetMetadata() *Metadata {
+	if m != nil {
+		return m.Metadata
+	}
+	return nil
+}
+
+func (m *SyncResponse) GetMessage() string {
+	if m != nil {
+		return m.Message
+	}
+	return ""
+}
+
+func (m *SyncResponse) GetSubjectHash() []byte {
+	if m != nil {
+		return m.SubjectHash
+	}
+	return nil
+}
+
+func (m *SyncResponse) GetKind() SyncKind {
+	if m != nil {
+		return m.Kind
+	}
+	return SyncKind_STATE
+}
+
+func (m *SyncResponse) GetRoot() string {
+	if m != nil {
+		return m.Root
+	}
+	return ""
+}
+
+func (m *SyncResponse) GetLeafCount() int32 {
+	if m != nil {
+		return m.LeafCount
+	}
+	return 0
+}
+
+func (m *SyncResponse) GetBallotDigest() []byte {
+	if m != nil {
+		return m.BallotDigest
+	}
+	return nil
+}
+
+func (m *SyncResponse) GetBucketDigests() [][]byte {
+	if m != nil {
+		return m.BucketDigests
+	}
+	return nil
+}
+
+func (m *SyncResponse) GetPrefixRoot() string {
+	if m != nil {
+		return m.PrefixRoot
+	}
+	return ""
+}
+
+func (m *SyncResponse) GetLeaves() []string {
+	if m != nil {
+		return m.Leaves
+	}
+	return nil
+}
+
+func (m *SyncResponse) GetNullifiers() []string {
+	if m != nil {
+		return m.Nullifiers
+	}
+	return nil
+}
+
+func (m *SyncResponse) GetBallots() []string {
+	if m != nil {
+		return m.Ballots
+	}
+	return nil
+}
+
+func (m *SyncResponse) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+func (m *SyncResponse) GetAdmissions() []string {
+	if m != nil {
+		return m.Admissions
+	}
+	return nil
+}
+
 // designed to be shared between all app protocols
 type Metadata struct {
 	// shared between all requests
@@ -439,7 +918,7 @@ func (m *Metadata) Reset()         { *m = Metadata{} }
 func (m *Metadata) String() string { return proto.CompactTextString(m) }
 func (*Metadata) ProtoMessage()    {}
 func (*Metadata) Descriptor() ([]byte, []int) {
-	return fileDescriptor_dfa3fe919df2773c, []int{7}
+	return fileDescriptor_dfa3fe919df2773c, []int{11}
 }
 
 func (m *Metadata) XXX_Unmarshal(b []byte) error {
@@ -510,6 +989,7 @@ func (m *Metadata) GetSign() []byte {
 }
 
 func init() {
+	proto.RegisterEnum("protocols.zkvote.SyncKind", SyncKind_name, SyncKind_value)
 	proto.RegisterType((*SubjectRequest)(nil), "protocols.zkvote.SubjectRequest")
 	proto.RegisterType((*SubjectResponse)(nil), "protocols.zkvote.SubjectResponse")
 	proto.RegisterType((*Subject)(nil), "protocols.zkvote.Subject")
@@ -517,37 +997,66 @@ func init() {
 	proto.RegisterType((*IdentityResponse)(nil), "protocols.zkvote.IdentityResponse")
 	proto.RegisterType((*BallotRequest)(nil), "protocols.zkvote.BallotRequest")
 	proto.RegisterType((*BallotResponse)(nil), "protocols.zkvote.BallotResponse")
+	proto.RegisterType((*RollupRequest)(nil), "protocols.zkvote.RollupRequest")
+	proto.RegisterType((*RollupResponse)(nil), "protocols.zkvote.RollupResponse")
+	proto.RegisterType((*SyncRequest)(nil), "protocols.zkvote.SyncRequest")
+	proto.RegisterType((*SyncResponse)(nil), "protocols.zkvote.SyncResponse")
 	proto.RegisterType((*Metadata)(nil), "protocols.zkvote.Metadata")
 }
 
 func init() { proto.RegisterFile("zkvote.proto", fileDescriptor_dfa3fe919df2773c) }
 
 var fileDescriptor_dfa3fe919df2773c = []byte{
-	// 408 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xc4, 0x92, 0xcf, 0x8a, 0xd4, 0x40,
-	0x10, 0xc6, 0xe9, 0xc9, 0xec, 0x4c, 0xa6, 0x66, 0x77, 0x76, 0x69, 0x44, 0xda, 0x45, 0x24, 0x04,
-	0x0f, 0x39, 0xe5, 0xb0, 0xa2, 0x0f, 0xe0, 0xc9, 0x45, 0x04, 0xc9, 0x82, 0x37, 0x0f, 0xf9, 0x53,
-	0x8c, 0xad, 0x49, 0x3a, 0xa6, 0x6a, 0x84, 0xf5, 0x2a, 0xbe, 0x84, 0x47, 0x4f, 0x3e, 0x87, 0x4f,
-	0x26, 0xe9, 0x74, 0xfe, 0xa8, 0x07, 0x2f, 0x2e, 0x73, 0x4a, 0xd7, 0xd7, 0x95, 0xfe, 0x7e, 0xfd,
-	0x75, 0xc1, 0xe9, 0xe7, 0x0f, 0x9f, 0x0c, 0x63, 0xdc, 0xb4, 0x86, 0x8d, 0xbc, 0xb0, 0x9f, 0xdc,
-	0x94, 0x14, 0xf7, 0x7a, 0x98, 0xc1, 0xee, 0xe6, 0x90, 0xbd, 0xc7, 0x9c, 0x13, 0xfc, 0x78, 0x40,
-	0x62, 0xf9, 0x0c, 0xfc, 0x0a, 0x39, 0x2d, 0x52, 0x4e, 0x95, 0x08, 0x44, 0xb4, 0xbd, 0xba, 0x8c,
-	0xff, 0xfc, 0x2d, 0x7e, 0xe5, 0x3a, 0x92, 0xb1, 0x57, 0x2a, 0x58, 0x57, 0x48, 0x94, 0xee, 0x51,
-	0x2d, 0x02, 0x11, 0x6d, 0x92, 0xa1, 0x0c, 0xbf, 0x09, 0x38, 0x1f, 0x4d, 0xa8, 0x31, 0x35, 0xe1,
-	0xff, 0x77, 0x91, 0x4f, 0xc1, 0xa7, 0xde, 0x84, 0x94, 0x17, 0x78, 0xd1, 0xf6, 0xea, 0xc1, 0xdf,
-	0x27, 0x0e, 0x18, 0x63, 0x6b, 0xf8, 0x16, 0xd6, 0x4e, 0x94, 0xf7, 0xe0, 0x84, 0x35, 0x97, 0x68,
-	0x81, 0x36, 0x49, 0x5f, 0xc8, 0x00, 0xb6, 0x05, 0x52, 0xde, 0xea, 0x86, 0xb5, 0xa9, 0x9d, 0xeb,
-	0x5c, 0x92, 0x97, 0xe0, 0x37, 0xad, 0x69, 0x0c, 0x61, 0xab, 0x3c, 0xbb, 0x3d, 0xd6, 0xe1, 0x57,
-	0x01, 0xe7, 0xd7, 0x05, 0xd6, 0xac, 0xf9, 0xf6, 0xce, 0x12, 0xee, 0x18, 0xdd, 0x85, 0x5e, 0xa4,
-	0xf4, 0xce, 0x42, 0x9c, 0x26, 0x73, 0x29, 0xfc, 0x21, 0xe0, 0x62, 0xe2, 0xb8, 0xb3, 0x47, 0xf8,
-	0x27, 0x48, 0xd7, 0xa1, 0x1d, 0xc7, 0x0d, 0xb2, 0x5a, 0x06, 0x5e, 0x17, 0xe7, 0x4c, 0x0a, 0xbf,
-	0x08, 0x38, 0x7b, 0x9e, 0x96, 0xa5, 0xe1, 0x63, 0x06, 0xf6, 0x5d, 0xc0, 0x6e, 0xa0, 0x38, 0x62,
-	0x5c, 0x0f, 0x61, 0x93, 0x59, 0x8a, 0x29, 0xac, 0x49, 0x08, 0x7f, 0x0a, 0xf0, 0x07, 0x43, 0xf9,
-	0x18, 0xce, 0xf2, 0x52, 0x63, 0xcd, 0x6f, 0xb0, 0xa5, 0x6e, 0x54, 0xfb, 0x31, 0xfe, 0x5d, 0xec,
-	0x0e, 0x64, 0x5d, 0x21, 0x71, 0x5a, 0x35, 0x16, 0xc7, 0x4b, 0x26, 0x41, 0xee, 0x60, 0xa1, 0x0b,
-	0x37, 0xc4, 0x0b, 0x5d, 0xc8, 0xfb, 0xb0, 0xda, 0x1b, 0x22, 0xdd, 0xa8, 0x65, 0x20, 0x22, 0x3f,
-	0x71, 0x55, 0xa7, 0xd7, 0xa6, 0xc0, 0xeb, 0x42, 0x9d, 0xd8, 0x5e, 0x57, 0xc9, 0x47, 0x00, 0xdd,
-	0xea, 0xf5, 0x21, 0x7b, 0x89, 0xb7, 0x6a, 0x65, 0xef, 0x33, 0x53, 0xa4, 0x84, 0x25, 0xe9, 0x7d,
-	0xad, 0xd6, 0x76, 0xc7, 0xae, 0xb3, 0x95, 0xcd, 0xf0, 0xc9, 0xaf, 0x00, 0x00, 0x00, 0xff, 0xff,
-	0x4e, 0xf2, 0xfc, 0x81, 0xab, 0x04, 0x00, 0x00,
+	// 808 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xc4, 0x56, 0x4d, 0x8f, 0xeb, 0x34,
+	0x14, 0x25, 0xcd, 0xf4, 0xeb, 0xf6, 0x63, 0x2a, 0x0b, 0x21, 0xf3, 0x34, 0x42, 0x51, 0xc4, 0xa2,
+	0x62, 0xd1, 0xc5, 0x43, 0xb0, 0x44, 0xea, 0x83, 0x41, 0x8c, 0x5e, 0xf9, 0x90, 0x3b, 0xbc, 0x7d,
+	0x9a, 0xdc, 0x0e, 0x66, 0xd2, 0x38, 0xd8, 0x4e, 0xc5, 0xb0, 0x45, 0xac, 0xd9, 0xb3, 0x64, 0xc5,
+	0x8a, 0x1d, 0x7f, 0x80, 0x1d, 0x7f, 0x80, 0xdf, 0x83, 0x7c, 0xe3, 0x4c, 0x32, 0x03, 0x12, 0x12,
+	0x62, 0xd4, 0x55, 0x7b, 0x8e, 0x6f, 0xec, 0xe3, 0x73, 0xae, 0xe3, 0xc0, 0xf4, 0xbb, 0xdb, 0xa3,
+	0xb2, 0xb8, 0x2a, 0xb5, 0xb2, 0x8a, 0x2d, 0xe8, 0x27, 0x55, 0xb9, 0x59, 0xd5, 0x7c, 0xbc, 0x83,
+	0xf9, 0xb6, 0xda, 0x7d, 0x8d, 0xa9, 0x15, 0xf8, 0x4d, 0x85, 0xc6, 0xb2, 0xf7, 0x61, 0x74, 0x40,
+	0x9b, 0x64, 0x89, 0x4d, 0x78, 0x10, 0x05, 0xcb, 0xc9, 0xf3, 0x67, 0xab, 0xc7, 0x8f, 0xad, 0x3e,
+	0xf5, 0x15, 0xe2, 0xbe, 0x96, 0x71, 0x18, 0x1e, 0xd0, 0x98, 0xe4, 0x06, 0x79, 0x2f, 0x0a, 0x96,
+	0x63, 0xd1, 0xc0, 0xf8, 0xa7, 0x00, 0xce, 0xef, 0x17, 0x31, 0xa5, 0x2a, 0x0c, 0xfe, 0xff, 0xab,
+	0xb0, 0xf7, 0x60, 0x64, 0xea, 0x45, 0x0c, 0x0f, 0xa3, 0x70, 0x39, 0x79, 0xfe, 0xe6, 0xdf, 0x67,
+	0x6c, 0x64, 0xdc, 0x97, 0xc6, 0x7f, 0xf4, 0x60, 0xe8, 0x59, 0xf6, 0x3a, 0xf4, 0xad, 0xb4, 0x39,
+	0x92, 0xa2, 0xb1, 0xa8, 0x01, 0x8b, 0x60, 0x92, 0xa1, 0x49, 0xb5, 0x2c, 0xad, 0x54, 0x85, 0x5f,
+	0xb6, 0x4b, 0xb1, 0x67, 0x30, 0x2a, 0xb5, 0x2a, 0x95, 0x41, 0xcd, 0x43, 0x1a, 0xbe, 0xc7, 0x4e,
+	0xb0, 0xa2, 0x2a, 0xc3, 0xcf, 0xa2, 0xd0, 0x09, 0xf6, 0x90, 0xbd, 0x01, 0x03, 0x55, 0x62, 0xb1,
+	0xb6, 0xbc, 0x1f, 0x05, 0xcb, 0x50, 0x78, 0xe4, 0x9e, 0x48, 0x73, 0x65, 0x70, 0x6d, 0xf9, 0x80,
+	0x06, 0x1a, 0xc8, 0x2e, 0x60, 0x6c, 0x35, 0xe2, 0x06, 0x8f, 0x98, 0xf3, 0x61, 0x14, 0x2c, 0x67,
+	0xa2, 0x25, 0xd8, 0x12, 0xce, 0x8f, 0xa8, 0xe5, 0x5e, 0xa6, 0x89, 0x5b, 0xe0, 0x25, 0xde, 0xf1,
+	0x11, 0x89, 0x79, 0x4c, 0xbb, 0x79, 0x8c, 0x4b, 0xbb, 0x48, 0x51, 0xf3, 0x31, 0xd5, 0xb4, 0x84,
+	0xdb, 0x6f, 0xa3, 0xde, 0xcd, 0x01, 0xf5, 0x7e, 0x3b, 0x94, 0x53, 0x2e, 0x8d, 0xa9, 0x50, 0xf3,
+	0x09, 0x0d, 0x7a, 0x14, 0xff, 0x10, 0xc0, 0xf9, 0x55, 0x86, 0x85, 0x95, 0xf6, 0xee, 0xc9, 0xda,
+	0xc9, 0xe9, 0xf3, 0xe9, 0x7d, 0x92, 0x98, 0xaf, 0xc8, 0xf0, 0xa9, 0xe8, 0x52, 0xf1, 0x2f, 0x01,
+	0x2c, 0x5a, 0x1d, 0x4f, 0xd6, 0x71, 0xff, 0x2a, 0xc4, 0x55, 0x48, 0xaf, 0x63, 0x8b, 0xd6, 0x37,
+	0x40, 0x97, 0x8a, 0xbf, 0x0f, 0x60, 0xf6, 0x22, 0xc9, 0x73, 0x65, 0x4f, 0x69, 0xd8, 0xcf, 0x01,
+	0xcc, 0x1b, 0x15, 0x27, 0xb4, 0xeb, 0x02, 0xc6, 0x3b, 0x52, 0xd1, 0x9a, 0xd5, 0x12, 0xf1, 0x6f,
+	0x01, 0xcc, 0x84, 0xca, 0xf3, 0xaa, 0x3c, 0xa1, 0x55, 0xf5, 0x59, 0xc7, 0xa3, 0x50, 0xca, 0x49,
+	0xf4, 0x67, 0xbd, 0xc6, 0xee, 0xfd, 0x51, 0x6a, 0xa5, 0xf6, 0x74, 0xa0, 0xc7, 0xa2, 0x06, 0xf1,
+	0xaf, 0x01, 0xcc, 0x1b, 0xdd, 0x27, 0x34, 0x97, 0xc1, 0x99, 0x6e, 0x45, 0x9f, 0x69, 0x2f, 0x18,
+	0xb5, 0x56, 0xba, 0x11, 0x4c, 0x20, 0xfe, 0xb1, 0x07, 0x93, 0xed, 0x5d, 0x91, 0x9e, 0xd2, 0xe6,
+	0x15, 0x9c, 0xdd, 0xca, 0x22, 0x23, 0xb5, 0xf3, 0x7f, 0x5a, 0xcf, 0x09, 0x7c, 0x29, 0x8b, 0x4c,
+	0x50, 0x9d, 0xdb, 0xdd, 0x5e, 0xab, 0x03, 0x6d, 0xa4, 0x2f, 0xe8, 0x3f, 0x9b, 0x43, 0xcf, 0x2a,
+	0x7a, 0x87, 0xf6, 0x45, 0xcf, 0x2a, 0xa7, 0x67, 0x57, 0xa5, 0xb7, 0x68, 0x0d, 0x1f, 0x46, 0xe1,
+	0xb2, 0x2f, 0x1a, 0xc8, 0xde, 0x02, 0x28, 0xaa, 0x3c, 0x97, 0x7b, 0x89, 0xda, 0xf0, 0x11, 0x75,
+	0x5e, 0x87, 0x89, 0xff, 0x0c, 0x61, 0x5a, 0x3b, 0x72, 0xc2, 0x00, 0xff, 0x83, 0x25, 0x14, 0x78,
+	0xbf, 0x13, 0xf8, 0x05, 0x8c, 0x73, 0x4c, 0xf6, 0x1f, 0xaa, 0xaa, 0xb0, 0xde, 0x99, 0x96, 0x60,
+	0x31, 0x4c, 0xeb, 0xe3, 0xf6, 0x91, 0xbc, 0x41, 0x63, 0xe9, 0x8a, 0x99, 0x8a, 0x07, 0x1c, 0x7b,
+	0x1b, 0x66, 0xb5, 0x6b, 0x35, 0xae, 0xdd, 0x9a, 0x8a, 0x87, 0xa4, 0x33, 0xb4, 0xd4, 0xb8, 0x97,
+	0xdf, 0xd2, 0x39, 0xa9, 0xaf, 0x98, 0x0e, 0xe3, 0x6e, 0x90, 0x1c, 0x93, 0x23, 0x1a, 0x0e, 0x64,
+	0xb6, 0x47, 0x8f, 0x82, 0x98, 0x3c, 0x0e, 0x82, 0x22, 0x24, 0x35, 0x86, 0x4f, 0x69, 0xb0, 0x81,
+	0x6d, 0x2b, 0xcf, 0x3a, 0xad, 0xec, 0xe6, 0x4b, 0xb2, 0x83, 0x34, 0x86, 0x2e, 0xe0, 0x79, 0x3d,
+	0x5f, 0xcb, 0xc4, 0xbf, 0x07, 0x30, 0x6a, 0x62, 0x72, 0x5b, 0x4b, 0x73, 0x89, 0x85, 0x7d, 0x85,
+	0xda, 0x0d, 0xfb, 0xcf, 0x80, 0x87, 0x24, 0x5d, 0xc2, 0xf2, 0x80, 0xc6, 0x26, 0x87, 0x92, 0x42,
+	0x0c, 0x45, 0x4b, 0xb8, 0x9e, 0x93, 0x99, 0xff, 0x08, 0xe8, 0xc9, 0xcc, 0x6d, 0xf4, 0x46, 0x19,
+	0x23, 0x4b, 0x8a, 0x6d, 0x24, 0x3c, 0x72, 0x7c, 0xa1, 0x32, 0xbc, 0xca, 0x7c, 0x3c, 0x1e, 0x91,
+	0x01, 0x2a, 0xc3, 0x2f, 0xaa, 0x9d, 0xbb, 0x7b, 0x07, 0x14, 0x40, 0x87, 0x71, 0xa1, 0x1a, 0x79,
+	0x53, 0xf8, 0x68, 0xe8, 0xff, 0x3b, 0x1f, 0xc0, 0xa8, 0x89, 0x9e, 0x8d, 0xa1, 0xbf, 0xbd, 0x5e,
+	0x5f, 0x5f, 0x2e, 0x5e, 0x63, 0x00, 0x83, 0xcd, 0xe5, 0xfa, 0xd5, 0xe5, 0x76, 0x11, 0xb0, 0x39,
+	0xc0, 0x67, 0x5f, 0x6e, 0x36, 0x57, 0x1f, 0x5f, 0x5d, 0x8a, 0xed, 0xa2, 0xc7, 0x26, 0x30, 0x7c,
+	0xb1, 0xde, 0x6c, 0x3e, 0xbf, 0xde, 0x2e, 0xc2, 0xdd, 0x80, 0x3a, 0xe9, 0xdd, 0xbf, 0x06, 0x00,
+	0xa7, 0x53, 0xcf, 0x8d, 0x2c, 0x0a, 0x00, 0x00,
 }
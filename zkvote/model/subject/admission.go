@@ -0,0 +1,101 @@
+package subject
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	ic "github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/unitychain/zkvote-node/zkvote/model/identity"
+)
+
+// WithProposerKey sets the public key of the proposer,
+// every identity of the subject has to be admitted by a signature of this key.
+func WithProposerKey(pubKey ic.PubKey) Opt {
+	return func(s *Subject) {
+		if nil == pubKey {
+			return
+		}
+		b, err := ic.MarshalPublicKey(pubKey)
+		if err != nil {
+			return
+		}
+		s.ProposerKey = hex.EncodeToString(b)
+	}
+}
+
+// WithProposerKeyHex sets the marshalled public key of the proposer in hex, as in the ProposerKey of a subject
+func WithProposerKeyHex(key string) Opt {
+	return func(s *Subject) {
+		s.ProposerKey = key
+	}
+}
+
+// RequiresAdmission returns true if the identities have to be admitted by the proposer
+func (s *Subject) RequiresAdmission() bool {
+	return 0 != len(s.ProposerKey)
+}
+
+// GetProposerKey returns the public key of the proposer, nil if the subject doesn't have one
+func (s *Subject) GetProposerKey() (ic.PubKey, error) {
+	if !s.RequiresAdmission() {
+		return nil, nil
+	}
+	b, err := hex.DecodeString(s.ProposerKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proposer key, %v", err)
+	}
+	return ic.UnmarshalPublicKey(b)
+}
+
+// SignAdmission admits an identity to the subject, returns the signature in hex
+func (s *Subject) SignAdmission(prvKey ic.PrivKey, identity *identity.Identity) (string, error) {
+	if nil == prvKey || nil == identity {
+		return "", fmt.Errorf("invalid input")
+	}
+	pubKey, err := s.GetProposerKey()
+	if err != nil {
+		return "", err
+	}
+	if nil == pubKey || !pubKey.Equals(prvKey.GetPublic()) {
+		return "", fmt.Errorf("not the proposer of the subject")
+	}
+
+	sig, err := prvKey.Sign(s.admissionData(identity))
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(sig), nil
+}
+
+// VerifyAdmission checks the signature of the proposer admitting an identity.
+// Any admission is accepted if the subject doesn't require one.
+func (s *Subject) VerifyAdmission(identity *identity.Identity, admission string) error {
+	if !s.RequiresAdmission() {
+		return nil
+	}
+	if nil == identity {
+		return fmt.Errorf("invalid input")
+	}
+	if 0 == len(admission) {
+		return fmt.Errorf("identity %v isn't admitted by the proposer", identity.String())
+	}
+	pubKey, err := s.GetProposerKey()
+	if err != nil {
+		return err
+	}
+	sig, err := hex.DecodeString(admission)
+	if err != nil {
+		return fmt.Errorf("invalid admission, %v", err)
+	}
+	ok, err := pubKey.Verify(s.admissionData(identity), sig)
+	if err != nil || !ok {
+		return fmt.Errorf("invalid admission of identity %v", identity.String())
+	}
+	return nil
+}
+
+// admissionData binds an identity commitment to the subject,
+// the commitment is normalized so that 0x01 and 0x1 share the same admission.
+func (s *Subject) admissionData(identity *identity.Identity) []byte {
+	return []byte("zkvote admission\n" + s.HashHex().String() + "\n" + identity.PathElement().BigInt().Text(16))
+}
@@ -3,6 +3,10 @@ package subject
 import (
 	"crypto/sha256"
 	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
 
 	"github.com/unitychain/zkvote-node/zkvote/model/identity"
 )
@@ -12,7 +16,93 @@ type Subject struct {
 	Title       string             `json:"title"`
 	Description string             `json:"Desc"`
 	Proposer    *identity.Identity `json:"proposer"`
-	hash        HashHex
+	Options     []string           `json:"options,omitempty"`
+	OpenAt      int64              `json:"openAt,omitempty"`
+	CloseAt     int64              `json:"closeAt,omitempty"`
+	// TreeLevel is the depth of the identity merkle tree, 0 for the default one.
+	// VerificationKey is the key of the circuit for this depth.
+	TreeLevel       uint8  `json:"treeLevel,omitempty"`
+	VerificationKey string `json:"verificationKey,omitempty"`
+	// Sequencer is the peer ID of the node which orders the identity insertions,
+	// empty if the publisher of an insertion orders it.
+	Sequencer string `json:"sequencer,omitempty"`
+	// ProposerKey is the marshalled public key of the proposer in hex,
+	// empty if anyone can add identities.
+	ProposerKey string `json:"proposerKey,omitempty"`
+	// Issuer is the DID of the proposer which issues the membership credentials,
+	// empty if the subject doesn't accept credentials.
+	Issuer string `json:"issuer,omitempty"`
+	hash   HashHex
+}
+
+// State of the voting period of a subject
+type State string
+
+const (
+	// StatePending means the subject can't be voted on yet
+	StatePending State = "pending"
+	// StateOpen means the subject can be voted on
+	StateOpen State = "open"
+	// StateClosed means the voting deadline of the subject has passed
+	StateClosed State = "closed"
+)
+
+// DefaultTreeLevel is the depth of the identity merkle tree of a subject proposed without one.
+// The default verification key of a node is for this depth.
+const DefaultTreeLevel uint8 = 10
+
+// DefaultOptions are the options of a subject proposed without any.
+// The order follows the signal of the original yes/no circuit, where 0 means no.
+var DefaultOptions = []string{"no", "yes"}
+
+// Opt represents an optional attribute of a subject.
+type Opt func(s *Subject)
+
+// WithOptions sets the ordered list of options which can be voted for,
+// the spaces around each option are trimmed
+func WithOptions(options ...string) Opt {
+	return func(s *Subject) {
+		if 0 == len(options) {
+			s.Options = nil
+			return
+		}
+		s.Options = make([]string, len(options))
+		for i, o := range options {
+			s.Options[i] = strings.TrimSpace(o)
+		}
+	}
+}
+
+// WithPeriod sets the voting period of the subject in unix time (seconds).
+// Zero means the period is unbounded on that side.
+func WithPeriod(openAt, closeAt int64) Opt {
+	return func(s *Subject) {
+		s.OpenAt = openAt
+		s.CloseAt = closeAt
+	}
+}
+
+// WithTreeLevel sets the depth of the identity merkle tree
+// and the verification key of the circuit for this depth
+func WithTreeLevel(level uint8, verificationKey string) Opt {
+	return func(s *Subject) {
+		s.TreeLevel = level
+		s.VerificationKey = verificationKey
+	}
+}
+
+// WithSequencer sets the peer which orders the identity insertions of the subject
+func WithSequencer(peerID string) Opt {
+	return func(s *Subject) {
+		s.Sequencer = peerID
+	}
+}
+
+// WithIssuer sets the DID which issues the membership credentials of the subject
+func WithIssuer(did string) Opt {
+	return func(s *Subject) {
+		s.Issuer = did
+	}
 }
 
 // Hash ...
@@ -42,8 +132,11 @@ func (h HashHex) Hash() Hash {
 }
 
 // NewSubject ...
-func NewSubject(title string, description string, identity *identity.Identity) *Subject {
+func NewSubject(title string, description string, identity *identity.Identity, opts ...Opt) *Subject {
 	s := Subject{Title: title, Description: description, Proposer: identity}
+	for _, opt := range opts {
+		opt(&s)
+	}
 	s.hash = s.Hash().Hex()
 	return &s
 }
@@ -61,20 +154,49 @@ func (s *Subject) HashHex() *HashHex {
 	return &s.hash
 }
 
+// hashedAttributes are the optional attributes in the hash of a subject, tagged by their JSON names
+// so that a value can't be moved from one attribute to another
+type hashedAttributes struct {
+	Options         []string `json:"options,omitempty"`
+	OpenAt          int64    `json:"openAt,omitempty"`
+	CloseAt         int64    `json:"closeAt,omitempty"`
+	TreeLevel       uint8    `json:"treeLevel,omitempty"`
+	VerificationKey string   `json:"verificationKey,omitempty"`
+	Sequencer       string   `json:"sequencer,omitempty"`
+	ProposerKey     string   `json:"proposerKey,omitempty"`
+	Issuer          string   `json:"issuer,omitempty"`
+}
+
 // Hash ...
+// The optional attributes are appended in JSON only when one of them is declared
+// so that the hashes of subjects proposed without them are unchanged.
 func (s *Subject) Hash() *Hash {
-	h := sha256.Sum256([]byte(s.Title + s.Description + s.Proposer.String()))
+	data := s.Title + s.Description + s.Proposer.String()
+	attrs := hashedAttributes{Options: s.Options, OpenAt: s.OpenAt, CloseAt: s.CloseAt, TreeLevel: s.TreeLevel,
+		VerificationKey: s.VerificationKey, Sequencer: s.Sequencer, ProposerKey: s.ProposerKey, Issuer: s.Issuer}
+	if b, _ := json.Marshal(attrs); "{}" != string(b) {
+		data += "\n" + string(b)
+	}
+	h := sha256.Sum256([]byte(data))
 	result := Hash(h[:])
 	return &result
 }
 
 // JSON ...
-func (s *Subject) JSON() map[string]string {
-	return map[string]string{
+func (s *Subject) JSON() map[string]interface{} {
+	return map[string]interface{}{
 		"hash":        s.HashHex().String(),
 		"title":       s.Title,
 		"description": s.Description,
 		"proposer":    s.Proposer.String(),
+		"options":     s.GetOptions(),
+		"openAt":      s.OpenAt,
+		"closeAt":     s.CloseAt,
+		"state":       s.GetState(time.Now()),
+		"treeLevel":   s.GetTreeLevel(),
+		"sequencer":   s.Sequencer,
+		"proposerKey": s.ProposerKey,
+		"issuer":      s.Issuer,
 	}
 }
 
@@ -92,3 +214,46 @@ func (s *Subject) GetDescription() string {
 func (s *Subject) GetProposer() *identity.Identity {
 	return s.Proposer
 }
+
+// GetOptions returns the ordered options of the subject
+func (s *Subject) GetOptions() []string {
+	if 0 == len(s.Options) {
+		return DefaultOptions
+	}
+	return s.Options
+}
+
+// CheckOptions returns an error if an option is empty or declared twice,
+// each option has to map to its own signal
+func (s *Subject) CheckOptions() error {
+	declared := make(map[string]bool)
+	for i, o := range s.Options {
+		if 0 == len(o) {
+			return fmt.Errorf("option %d is empty", i)
+		}
+		if declared[o] {
+			return fmt.Errorf("option %v is declared twice", o)
+		}
+		declared[o] = true
+	}
+	return nil
+}
+
+// GetState returns the state of the voting period at the given time
+func (s *Subject) GetState(now time.Time) State {
+	if 0 != s.OpenAt && now.Unix() < s.OpenAt {
+		return StatePending
+	}
+	if 0 != s.CloseAt && now.Unix() >= s.CloseAt {
+		return StateClosed
+	}
+	return StateOpen
+}
+
+// GetTreeLevel returns the depth of the identity merkle tree of the subject
+func (s *Subject) GetTreeLevel() uint8 {
+	if 0 == s.TreeLevel {
+		return DefaultTreeLevel
+	}
+	return s.TreeLevel
+}
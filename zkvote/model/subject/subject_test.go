@@ -0,0 +1,94 @@
+package subject
+
+import (
+	"testing"
+	"time"
+
+	ic "github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/unitychain/zkvote-node/zkvote/model/identity"
+)
+
+const idCommitment string = "0x26ee2d1e6c2a8a4ab0da4e6e8c0ab21ba4b2bc1fb3aacd5e8e6d2be2bb1cbd1a"
+
+func TestHash_WithoutOptions(t *testing.T) {
+	s := NewSubject("title", "desc", identity.NewIdentity(idCommitment))
+	o := NewSubject("title", "desc", identity.NewIdentity(idCommitment), WithOptions())
+	assert.Equal(t, *s.HashHex(), *o.HashHex())
+	assert.Equal(t, DefaultOptions, s.GetOptions())
+
+	o = NewSubject("title", "desc", identity.NewIdentity(idCommitment), WithOptions("alice", "bob"))
+	assert.NotEqual(t, *s.HashHex(), *o.HashHex())
+
+	o = NewSubject("title", "desc", identity.NewIdentity(idCommitment), WithPeriod(0, 100))
+	assert.NotEqual(t, *s.HashHex(), *o.HashHex())
+
+	o = NewSubject("title", "desc", identity.NewIdentity(idCommitment), WithSequencer(""))
+	assert.Equal(t, *s.HashHex(), *o.HashHex())
+	o = NewSubject("title", "desc", identity.NewIdentity(idCommitment), WithSequencer("QmSequencer"))
+	assert.NotEqual(t, *s.HashHex(), *o.HashHex())
+	o = NewSubject("title", "desc", identity.NewIdentity(idCommitment), WithIssuer("did:key:z6Mk"))
+	assert.NotEqual(t, *s.HashHex(), *o.HashHex())
+
+	// a value moved to another attribute changes the hash
+	s = NewSubject("title", "desc", identity.NewIdentity(idCommitment), WithProposerKeyHex("0801"))
+	assert.NotEqual(t, *s.HashHex(), *NewSubject("title", "desc", identity.NewIdentity(idCommitment), WithIssuer("0801")).HashHex())
+	assert.NotEqual(t, *s.HashHex(), *NewSubject("title", "desc", identity.NewIdentity(idCommitment), WithSequencer("0801")).HashHex())
+	s = NewSubject("title", "desc", identity.NewIdentity(idCommitment), WithSequencer("a"), WithIssuer("b"))
+	assert.NotEqual(t, *s.HashHex(), *NewSubject("title", "desc", identity.NewIdentity(idCommitment), WithSequencer("a\nb")).HashHex())
+}
+
+func TestOptions(t *testing.T) {
+	s := NewSubject("title", "desc", identity.NewIdentity(idCommitment), WithOptions(" alice", "bob "))
+	assert.Equal(t, []string{"alice", "bob"}, s.GetOptions())
+	assert.Nil(t, s.CheckOptions())
+
+	// the options are encoded unambiguously
+	o := NewSubject("title", "desc", identity.NewIdentity(idCommitment), WithOptions("alice\nbob", "carol"))
+	assert.NotEqual(t, *NewSubject("title", "desc", identity.NewIdentity(idCommitment), WithOptions("alice", "bob\ncarol")).HashHex(), *o.HashHex())
+
+	assert.NotNil(t, NewSubject("title", "desc", identity.NewIdentity(idCommitment), WithOptions("alice", " ")).CheckOptions())
+	assert.NotNil(t, NewSubject("title", "desc", identity.NewIdentity(idCommitment), WithOptions("alice", "bob", "alice ")).CheckOptions())
+}
+
+func TestGetState(t *testing.T) {
+	now := time.Now()
+	s := NewSubject("title", "desc", identity.NewIdentity(idCommitment), WithPeriod(now.Unix()+10, now.Unix()+20))
+	assert.Equal(t, StatePending, s.GetState(now))
+	assert.Equal(t, StateOpen, s.GetState(now.Add(10*time.Second)))
+	assert.Equal(t, StateClosed, s.GetState(now.Add(20*time.Second)))
+
+	s = NewSubject("title", "desc", identity.NewIdentity(idCommitment))
+	assert.Equal(t, StateOpen, s.GetState(now))
+}
+
+func TestAdmission(t *testing.T) {
+	prvKey, pubKey, err := ic.GenerateKeyPair(ic.Ed25519, 0)
+	assert.Nil(t, err)
+	otherKey, _, err := ic.GenerateKeyPair(ic.Ed25519, 0)
+	assert.Nil(t, err)
+
+	// Anyone can add identities without a proposer key
+	s := NewSubject("title", "desc", identity.NewIdentity(idCommitment))
+	assert.False(t, s.RequiresAdmission())
+	assert.Nil(t, s.VerifyAdmission(identity.NewIdentity("0x1234"), ""))
+
+	o := NewSubject("title", "desc", identity.NewIdentity(idCommitment), WithProposerKey(pubKey))
+	assert.True(t, o.RequiresAdmission())
+	assert.NotEqual(t, *s.HashHex(), *o.HashHex())
+
+	admission, err := o.SignAdmission(prvKey, identity.NewIdentity("0x1234"))
+	assert.Nil(t, err)
+	assert.Nil(t, o.VerifyAdmission(identity.NewIdentity("0x1234"), admission))
+	assert.Nil(t, o.VerifyAdmission(identity.NewIdentity("0x001234"), admission))
+	assert.NotNil(t, o.VerifyAdmission(identity.NewIdentity("0x5678"), admission))
+	assert.NotNil(t, o.VerifyAdmission(identity.NewIdentity("0x1234"), ""))
+
+	// Only the proposer can admit identities
+	_, err = o.SignAdmission(otherKey, identity.NewIdentity("0x1234"))
+	assert.NotNil(t, err)
+
+	// An admission is bound to its subject
+	p := NewSubject("another", "desc", identity.NewIdentity(idCommitment), WithProposerKey(pubKey))
+	assert.NotNil(t, p.VerifyAdmission(identity.NewIdentity("0x1234"), admission))
+}
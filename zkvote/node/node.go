@@ -3,43 +3,147 @@ package node
 import (
 	"context"
 	"fmt"
+	"io/ioutil"
+	"math/big"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/ipfs/go-datastore"
 	"github.com/libp2p/go-libp2p"
 	connmgr "github.com/libp2p/go-libp2p-connmgr"
+	"github.com/libp2p/go-libp2p-core/peer"
 	crypto "github.com/libp2p/go-libp2p-crypto"
 	dht "github.com/libp2p/go-libp2p-kad-dht"
 	dhtopts "github.com/libp2p/go-libp2p-kad-dht/opts"
+	ma "github.com/multiformats/go-multiaddr"
 	"github.com/unitychain/zkvote-node/zkvote/common/store"
 	"github.com/unitychain/zkvote-node/zkvote/common/utils"
+	localContext "github.com/unitychain/zkvote-node/zkvote/model/context"
+	"github.com/unitychain/zkvote-node/zkvote/model/subject"
 	zkp "github.com/unitychain/zkvote-node/zkvote/node/zkp_vote"
+	pro "github.com/unitychain/zkvote-node/zkvote/operator/service/manager/protocol"
 )
 
 const DB_NODE_ID = "nodeID"
 
+// ROLLUP_VK_PATH is the verification key of the rollup proofs
+const ROLLUP_VK_PATH = "./snark/rollup_verification_key.json"
+
+// Defaults of the options
+const (
+	BUCKET_SIZE       = 1
+	CONN_LOW          = 1500
+	CONN_HIGH         = 2000
+	CONN_GRACE        = time.Minute
+	BOOTSTRAP_TIMEOUT = 30 * time.Second
+)
+
+type allOpts struct {
+	listenAddrs      []string
+	bucketSize       int
+	connLow          int
+	connHigh         int
+	connGrace        time.Duration
+	bootstrapTimeout time.Duration
+	vkPath           string
+	keyFile          string
+}
+
+// Opt represents an option of the node
+type Opt func(opts *allOpts)
+
+// WithListenAddrs sets the multiaddrs to listen on, the libp2p defaults are used if none is given
+func WithListenAddrs(addrs ...string) Opt {
+	return func(opts *allOpts) {
+		opts.listenAddrs = addrs
+	}
+}
+
+// WithBucketSize sets the bucket size of the DHT routing table
+func WithBucketSize(size int) Opt {
+	return func(opts *allOpts) {
+		opts.bucketSize = size
+	}
+}
+
+// WithConnLimits sets the watermarks of the connection manager and the grace period of new connections
+func WithConnLimits(low int, high int, grace time.Duration) Opt {
+	return func(opts *allOpts) {
+		opts.connLow = low
+		opts.connHigh = high
+		opts.connGrace = grace
+	}
+}
+
+// WithBootstrapTimeout sets how long DHTBootstrap waits for the seeds
+func WithBootstrapTimeout(d time.Duration) Opt {
+	return func(opts *allOpts) {
+		opts.bootstrapTimeout = d
+	}
+}
+
+// WithVerificationKey sets the path of the verification key of rollup proofs
+func WithVerificationKey(path string) Opt {
+	return func(opts *allOpts) {
+		opts.vkPath = path
+	}
+}
+
+// WithPrivateKeyFile sets the file of the hex encoded ECDSA key of the peer,
+// the key is generated and kept in the datastore if no file is given
+func WithPrivateKeyFile(path string) Opt {
+	return func(opts *allOpts) {
+		opts.keyFile = path
+	}
+}
+
+// Node is a rollup aggregator,
+// it accepts rollup proofs of subjects and keeps the votes of them in the DHT
 type Node struct {
-	zkpVote *zkp.ZkpVote
-	store   *store.Store
+	*localContext.Context
+	dht             *dht.IpfsDHT
+	zkpVote         *zkp.ZkpVote
+	store           *store.Store
+	rollupProtocol  *pro.RollupProtocol
+	verificationKey string
+	opts            *allOpts
 }
 
-func NewNode(ctx context.Context, ds datastore.Batching, bucketSize int) *Node {
-	cmgr := connmgr.NewConnManager(1500, 2000, time.Minute)
+// NewNode ...
+// The node can't accept any rollup without the verification key of the rollup circuit,
+// so it fails if the key can't be read.
+func NewNode(ctx context.Context, ds datastore.Batching, opts ...Opt) (*Node, error) {
+	o := &allOpts{
+		bucketSize:       BUCKET_SIZE,
+		connLow:          CONN_LOW,
+		connHigh:         CONN_HIGH,
+		connGrace:        CONN_GRACE,
+		bootstrapTimeout: BOOTSTRAP_TIMEOUT,
+		vkPath:           ROLLUP_VK_PATH,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	cmgr := connmgr.NewConnManager(o.connLow, o.connHigh, o.connGrace)
 
 	// Ignoring most errors for brevity
 	// See echo example for more details and better implementation
-	prvKey, err := loadPrivateKey(ds)
+	prvKey, err := loadPrivateKey(ds, o.keyFile)
 	if err != nil {
 		panic(err)
 	}
 
-	opts := []libp2p.Option{libp2p.ConnectionManager(cmgr), libp2p.Identity(prvKey)}
-	host, err := libp2p.New(context.Background(), opts...)
+	libp2pOpts := []libp2p.Option{libp2p.ConnectionManager(cmgr), libp2p.Identity(prvKey)}
+	if 0 != len(o.listenAddrs) {
+		libp2pOpts = append(libp2pOpts, libp2p.ListenAddrStrings(o.listenAddrs...))
+	}
+	host, err := libp2p.New(context.Background(), libp2pOpts...)
 	if err != nil {
 		panic(err)
 	}
 
-	d1, err := dht.New(context.Background(), host, dhtopts.BucketSize(bucketSize), dhtopts.Datastore(ds), dhtopts.Validator(store.NodeValidator{}))
+	d1, err := dht.New(context.Background(), host, dhtopts.BucketSize(o.bucketSize), dhtopts.Datastore(ds), dhtopts.Validator(store.NewNodeValidator(host.Peerstore())))
 	if err != nil {
 		panic(err)
 	}
@@ -54,16 +158,137 @@ func NewNode(ctx context.Context, ds datastore.Batching, bucketSize int) *Node {
 		utils.LogFatalf("New ZKP Vote error, %v", err.Error())
 	}
 
-	return &Node{
-		zkpVote: zkp,
-		store:   store,
+	vkData, err := ioutil.ReadFile(o.vkPath)
+	if err != nil {
+		return nil, fmt.Errorf("read rollup verification key error, %v", err)
+	}
+	if 0 == len(vkData) {
+		return nil, fmt.Errorf("rollup verification key %v is empty", o.vkPath)
+	}
+
+	n := &Node{
+		Context:         localContext.NewContext(new(sync.RWMutex), host, store, nil, &ctx),
+		dht:             d1,
+		zkpVote:         zkp,
+		store:           store,
+		verificationKey: string(vkData),
+		opts:            o,
+	}
+	n.rollupProtocol = pro.NewRollupProtocol(n.Context, n.handleRollup)
+
+	return n, nil
+}
+
+// Stop the rollup protocol, and then close the DHT and the host
+func (n *Node) Stop(ctx context.Context) error {
+	utils.LogInfo("Stop node")
+	n.rollupProtocol.Stop()
+	if err := n.dht.Close(); nil != err {
+		utils.LogWarningf("Close DHT error, %v", err)
+	}
+	return n.Host.Close()
+}
+
+// Info ...
+func (n *Node) Info() {
+	fmt.Println()
+	fmt.Println("My peer ID:")
+	fmt.Printf("\t%s\n", n.Host.ID())
+
+	fmt.Println()
+	fmt.Println("My identified multiaddrs:")
+	for _, a := range n.Host.Addrs() {
+		fmt.Printf("\t%s/p2p/%s\n", a, n.Host.ID())
+	}
+}
+
+// DHTBootstrap connects to the seeds and bootstraps the DHT
+func (n *Node) DHTBootstrap(seeds ...ma.Multiaddr) error {
+	ctx, cancel := context.WithTimeout(*n.Ctx, n.opts.bootstrapTimeout)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for _, seed := range seeds {
+		ai, err := peer.AddrInfoFromP2pAddr(seed)
+		if err != nil {
+			return err
+		}
+		wg.Add(1)
+		go func(ai peer.AddrInfo) {
+			defer wg.Done()
+			if err := n.Host.Connect(ctx, ai); err != nil {
+				utils.LogWarningf("Failed while connecting to peer: %s; %s", ai, err)
+			}
+		}(*ai)
+	}
+	wg.Wait()
+
+	if err := n.dht.Bootstrap(ctx); err != nil && err != context.DeadlineExceeded {
+		return fmt.Errorf("failed while bootstrapping DHT: %w", err)
+	}
+	return nil
+}
+
+// Rollup accepts a rollup proof of a subject
+// prevRoot: the root of votes which the proof is based on, in decimal
+// proof: the rollup proof in json
+// return the new root of votes
+func (n *Node) Rollup(subjectHashHex string, prevRoot string, proof string) (string, error) {
+	if 0 == len(subjectHashHex) || 0 == len(prevRoot) || 0 == len(proof) {
+		return "", fmt.Errorf("invalid input")
+	}
+
+	bigPrevRoot, ok := big.NewInt(0).SetString(prevRoot, 10)
+	if !ok {
+		return "", fmt.Errorf("invalid root, %v", prevRoot)
+	}
+	rProof, err := zkp.NewRollupProof(proof)
+	if err != nil {
+		return "", err
+	}
+
+	err = n.zkpVote.Rollup(subject.HashHex(utils.Remove0x(subjectHashHex)), bigPrevRoot, rProof, n.verificationKey)
+	if err != nil {
+		return "", err
 	}
+	return n.GetRoot(), nil
+}
+
+// GetRoot returns the current root of votes in decimal
+func (n *Node) GetRoot() string {
+	return n.zkpVote.GetRoot().String()
+}
+
+// GetBallots returns the ballots of each option of a subject
+func (n *Node) GetBallots(subjectHashHex string) ([]int, error) {
+	return n.zkpVote.GetBallots(subject.HashHex(utils.Remove0x(subjectHashHex)))
+}
+
+// GetVoteLeaves returns the votes of all rolled up subjects
+func (n *Node) GetVoteLeaves() []*zkp.VoteLeaf {
+	return n.zkpVote.GetVoteLeaves()
 }
 
-func loadPrivateKey(ds datastore.Batching) (crypto.PrivKey, error) {
+func (n *Node) handleRollup(subjectHash *subject.Hash, prevRoot string, proof string) (string, error) {
+	return n.Rollup(subjectHash.Hex().String(), prevRoot, proof)
+}
+
+func loadPrivateKey(ds datastore.Batching, keyFile string) (crypto.PrivKey, error) {
 	var prvKey crypto.PrivKey
 	var err error
 
+	if 0 != len(keyFile) {
+		data, err := ioutil.ReadFile(keyFile)
+		if err != nil {
+			return nil, err
+		}
+		prvKey, err = crypto.UnmarshalECDSAPrivateKey(utils.GetBytesFromHexString(strings.TrimSpace(string(data))))
+		if err != nil {
+			return nil, fmt.Errorf("unmarshal private key error, %v", err)
+		}
+		return prvKey, nil
+	}
+
 	tmpStore, _ := store.NewStore(nil, ds)
 	strKey, _ := tmpStore.GetLocal(DB_NODE_ID)
 	if 0 == len(strKey) {
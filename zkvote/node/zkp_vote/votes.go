@@ -18,6 +18,9 @@ type VoteLeaf struct {
 	Hash    s.HashHex  `json:"hash"`
 }
 
+// VOTE_TREE_LEVEL is the depth of the tree of vote leaves
+const VOTE_TREE_LEVEL uint8 = 10
+
 type Votes struct {
 	VoteLeaves []*VoteLeaf `json:"VoteLeaves"`
 	Root       *big.Int    `json:"root"`
@@ -25,23 +28,41 @@ type Votes struct {
 }
 
 func NewVotes() (*Votes, error) {
-	return &Votes{
+	v := &Votes{
 		VoteLeaves: []*VoteLeaf{
 			&VoteLeaf{
 				Subject: nil,
 				Ballots: []int{0, 0},
 				Hash:    s.HashHex(""),
 			}},
-		Root: big.NewInt(0),
-	}, nil
+	}
+	err := v.buildTree()
+	if err != nil {
+		return nil, err
+	}
+	return v, nil
 }
 
+// NewVotesWithSerializedString restores the votes and rebuilds the vote tree,
+// the root has to match the serialized one.
 func NewVotesWithSerializedString(jsonStr string) (*Votes, error) {
 	var v Votes
 	err := json.Unmarshal([]byte(jsonStr), &v)
 	if err != nil {
 		return nil, err
 	}
+	if 0 == len(v.VoteLeaves) || nil == v.Root {
+		return nil, fmt.Errorf("invalid votes, root and leaves are required")
+	}
+
+	root := v.Root
+	err = v.buildTree()
+	if err != nil {
+		return nil, err
+	}
+	if !v.IsRootMatched(root) {
+		return nil, fmt.Errorf("root of votes mismatch, %v/%v", root, v.Root)
+	}
 	return &v, nil
 }
 
@@ -63,32 +84,82 @@ func (v *Votes) CreateAVote(subject *s.Subject) error {
 
 	l := &VoteLeaf{
 		Subject: subject,
-		Ballots: []int{0, 0},
+		Ballots: make([]int, len(subject.GetOptions())),
 		Hash:    *subject.HashHex(),
 	}
 	return v.insertLeaf(l)
 }
 
-func (v *Votes) Update(subHashHex s.HashHex, newBallot []int) error {
+// CreateAVoteByHash creates an empty vote of a subject known by its hash and number of options only
+func (v *Votes) CreateAVoteByHash(subHashHex s.HashHex, options int) error {
+	if 2 > options {
+		return fmt.Errorf("at least 2 options are required, got %d", options)
+	}
 	leaf := v.getVoteLeaf(subHashHex)
-	if leaf == nil {
+	if leaf != nil {
+		return fmt.Errorf("Subject is existed, hash:%v", subHashHex)
+	}
+
+	l := &VoteLeaf{
+		Subject: nil,
+		Ballots: make([]int, options),
+		Hash:    s.HashHex(utils.Remove0x(subHashHex.String())),
+	}
+	return v.insertLeaf(l)
+}
+
+func (v *Votes) Update(subHashHex s.HashHex, newBallot []int) error {
+	idx := v.getVoteLeafIndex(subHashHex)
+	if -1 == idx {
 		return fmt.Errorf("Can't find this subject")
 	}
-	leaf.Ballots[0] = newBallot[0]
-	leaf.Ballots[1] = newBallot[1]
+	leaf := v.VoteLeaves[idx]
+	if len(leaf.Ballots) != len(newBallot) {
+		return fmt.Errorf("number of options mismatch, %d/%d", len(leaf.Ballots), len(newBallot))
+	}
+
+	oldContent := leaf.treeContent()
+	newLeaf := &VoteLeaf{Subject: leaf.Subject, Ballots: append([]int{}, newBallot...), Hash: leaf.Hash}
+	newContent := newLeaf.treeContent()
+	if b, _ := oldContent.Equals(*newContent); !b {
+		err := v.voteTree.Update(uint(idx), *oldContent, *newContent)
+		if err != nil {
+			return err
+		}
+	}
+	v.VoteLeaves[idx] = newLeaf
 
 	v.Root = v.calcRoot()
 	return nil
 }
 
+// GetBallots returns the ballots of each option of a subject
+func (v *Votes) GetBallots(subHashHex s.HashHex) ([]int, error) {
+	leaf := v.getVoteLeaf(subHashHex)
+	if leaf == nil {
+		return nil, fmt.Errorf("Can't find this subject")
+	}
+	return append([]int{}, leaf.Ballots...), nil
+}
+
+// GetVoteLeaves returns the leaves of the subjects, without the empty one
+func (v *Votes) GetVoteLeaves() []*VoteLeaf {
+	return append([]*VoteLeaf{}, v.VoteLeaves[1:]...)
+}
+
 func (v *Votes) IsValidBallotNumber(subHashHex s.HashHex, newBallot []int) (bool, error) {
 	leaf := v.getVoteLeaf(subHashHex)
 	if leaf == nil {
 		return false, fmt.Errorf("Can't find this subject")
 	}
 
-	if leaf.Ballots[0] > newBallot[0] || leaf.Ballots[1] > newBallot[1] {
-		return false, fmt.Errorf("number of coming ballots is wrong, current:%v, coming:%v", leaf.Ballots, newBallot)
+	if len(leaf.Ballots) != len(newBallot) {
+		return false, fmt.Errorf("number of options mismatch, %d/%d", len(leaf.Ballots), len(newBallot))
+	}
+	for i, b := range leaf.Ballots {
+		if b > newBallot[i] {
+			return false, fmt.Errorf("number of coming ballots is wrong, current:%v, coming:%v", leaf.Ballots, newBallot)
+		}
 	}
 
 	return true, nil
@@ -104,33 +175,73 @@ func (v *Votes) IsRootMatched(root *big.Int) bool {
 //
 // Internals
 //
+
+// copy returns votes which can be updated without changing these ones,
+// the leaves are shared since they are replaced instead of modified
+func (v *Votes) copy() (*Votes, error) {
+	c := &Votes{VoteLeaves: append([]*VoteLeaf{}, v.VoteLeaves...)}
+	err := c.buildTree()
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
 func (v *Votes) calcRoot() *big.Int {
 	return v.voteTree.GetRoot().BigInt()
 }
 
 func (v *Votes) getVoteLeaf(subHashHex s.HashHex) *VoteLeaf {
-	for _, l := range v.VoteLeaves {
-		if strings.EqualFold(utils.Prepend0x(subHashHex.String()), l.Hash.String()) {
-			return l
+	idx := v.getVoteLeafIndex(subHashHex)
+	if -1 == idx {
+		return nil
+	}
+	return v.VoteLeaves[idx]
+}
+
+func (v *Votes) getVoteLeafIndex(subHashHex s.HashHex) int {
+	if 0 == len(utils.Remove0x(subHashHex.String())) {
+		return -1
+	}
+	for i, l := range v.VoteLeaves {
+		if strings.EqualFold(utils.Remove0x(subHashHex.String()), utils.Remove0x(l.Hash.String())) {
+			return i
 		}
 	}
 	utils.LogWarningf("Can't find subject hash, %v", subHashHex)
-	return nil
+	return -1
 }
 
 func (v *Votes) insertLeaf(leaf *VoteLeaf) error {
-
-	b, e := json.Marshal(v.VoteLeaves)
-	if e != nil {
-		return e
-	}
-
-	x := big.NewInt(0).SetBytes(crypto.Keccak256(b))
-	_, e = v.voteTree.Insert(*tree.NewTreeContent(x))
+	_, e := v.voteTree.Insert(*leaf.treeContent())
 	if e != nil {
 		return e
 	}
 
 	v.VoteLeaves = append(v.VoteLeaves, leaf)
+	v.Root = v.calcRoot()
+	return nil
+}
+
+// buildTree builds the vote tree from the leaves
+func (v *Votes) buildTree() error {
+	t, err := tree.NewMerkleTree(VOTE_TREE_LEVEL)
+	if err != nil {
+		return err
+	}
+	for _, l := range v.VoteLeaves {
+		_, err = t.Insert(*l.treeContent())
+		if err != nil {
+			return err
+		}
+	}
+	v.voteTree = t
+	v.Root = v.calcRoot()
 	return nil
 }
+
+// treeContent is the value of the leaf in the vote tree
+func (l *VoteLeaf) treeContent() *tree.TreeContent {
+	b, _ := json.Marshal([]interface{}{strings.ToLower(utils.Remove0x(l.Hash.String())), l.Ballots})
+	return tree.NewTreeContent(big.NewInt(0).SetBytes(crypto.Keccak256(b)))
+}
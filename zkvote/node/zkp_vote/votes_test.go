@@ -0,0 +1,108 @@
+package zkp_vote
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/unitychain/zkvote-node/zkvote/common/utils"
+	s "github.com/unitychain/zkvote-node/zkvote/model/subject"
+)
+
+const subjectHash = s.HashHex("4a4b2a3a4b2a3a4b2a3a4b2a3a4b2a3a4b2a3a4b2a3a4b2a3a4b2a3a4b2a3a4b")
+
+func TestNewVotes(t *testing.T) {
+	v, err := NewVotes()
+	assert.Nil(t, err)
+	assert.NotNil(t, v.voteTree)
+	assert.Equal(t, v.calcRoot(), v.Root)
+	assert.Equal(t, 0, len(v.GetVoteLeaves()))
+}
+
+func TestUpdate(t *testing.T) {
+	v, err := NewVotes()
+	assert.Nil(t, err)
+	emptyRoot := v.Root
+
+	assert.Nil(t, v.CreateAVoteByHash(subjectHash, 2))
+	assert.NotNil(t, v.CreateAVoteByHash(subjectHash, 2))
+	createdRoot := v.Root
+	assert.NotEqual(t, emptyRoot, createdRoot)
+
+	assert.Nil(t, v.Update(subjectHash, []int{3, 2}))
+	assert.NotEqual(t, createdRoot, v.Root)
+	ballots, err := v.GetBallots("0x" + subjectHash)
+	assert.Nil(t, err)
+	assert.Equal(t, []int{3, 2}, ballots)
+
+	// the same ballots keep the root
+	root := v.Root
+	assert.Nil(t, v.Update(subjectHash, []int{3, 2}))
+	assert.Equal(t, root, v.Root)
+
+	b, err := v.IsValidBallotNumber(subjectHash, []int{2, 2})
+	assert.False(t, b)
+	assert.NotNil(t, err)
+	assert.NotNil(t, v.Update(subjectHash, []int{3, 2, 1}))
+	assert.NotNil(t, v.Update("1234", []int{3, 2}))
+}
+
+func TestNewVotesWithSerializedString(t *testing.T) {
+	v, err := NewVotes()
+	assert.Nil(t, err)
+	assert.Nil(t, v.CreateAVoteByHash(subjectHash, 2))
+	assert.Nil(t, v.Update(subjectHash, []int{1, 5}))
+
+	str, err := v.Serialize()
+	assert.Nil(t, err)
+	restored, err := NewVotesWithSerializedString(str)
+	assert.Nil(t, err)
+	assert.Equal(t, v.Root, restored.Root)
+	ballots, err := restored.GetBallots(subjectHash)
+	assert.Nil(t, err)
+	assert.Equal(t, []int{1, 5}, ballots)
+
+	// ballots don't match the root
+	v.VoteLeaves[1].Ballots = []int{9, 9}
+	str, err = v.Serialize()
+	assert.Nil(t, err)
+	_, err = NewVotesWithSerializedString(str)
+	assert.NotNil(t, err)
+}
+
+func newTestRollupProof(t *testing.T, signals ...string) *RollupProof {
+	rp, err := NewRollupProof(`{"proof":{},"public_signal":["` + strings.Join(signals, `","`) + `"]}`)
+	assert.Nil(t, err)
+	return rp
+}
+
+func TestRollup_Signals(t *testing.T) {
+	v, err := NewVotes()
+	assert.Nil(t, err)
+	z := &ZkpVote{votes: v}
+	root := v.Root.String()
+	subj := utils.GetBigIntFromHexString(subjectHash.String())
+	subj.Div(subj, big.NewInt(8))
+
+	_, err = NewRollupProof(`{"proof":{},"public_signal":["1","2","3","4"]}`)
+	assert.NotNil(t, err)
+
+	// the proof is for another subject or root
+	_, err = z.isValidProof(subjectHash, v.Root, newTestRollupProof(t, "1", root, "2", "1", "0", "0"), "{}")
+	assert.EqualError(t, err, "subject doesn't match the proof ("+subj.String()+")/(1)")
+	_, err = z.isValidProof(subjectHash, v.Root, newTestRollupProof(t, subj.String(), "1", "2", "1", "0", "0"), "{}")
+	assert.NotNil(t, err)
+	_, err = z.isValidProof(subjectHash, v.Root, newTestRollupProof(t, subj.String(), root, "2", "1", "-1", "0"), "{}")
+	assert.NotNil(t, err)
+
+	// the ballots of all options are checked before the proof
+	_, err = z.isValidProof(subjectHash, v.Root, newTestRollupProof(t, subj.String(), root, "2", "1", "0", "3"), "{}")
+	assert.EqualError(t, err, "invalid proof")
+	assert.Nil(t, v.CreateAVoteByHash(subjectHash, 3))
+	assert.Nil(t, v.Update(subjectHash, []int{1, 1, 1}))
+	_, err = z.isValidProof(subjectHash, v.Root, newTestRollupProof(t, subj.String(), v.Root.String(), "2", "2", "2", "0"), "{}")
+	assert.NotNil(t, err)
+	_, err = z.isValidProof(subjectHash, v.Root, newTestRollupProof(t, subj.String(), v.Root.String(), "2", "2", "2"), "{}")
+	assert.EqualError(t, err, "number of options mismatch, 3/2")
+}
@@ -1,9 +1,11 @@
 package zkp_vote
 
 import (
+	"encoding/json"
 	"fmt"
 	"math/big"
 	"strconv"
+	"sync"
 
 	"github.com/arnaucube/go-snark/externalVerif"
 	"github.com/unitychain/zkvote-node/zkvote/common/store"
@@ -13,38 +15,91 @@ import (
 	s "github.com/unitychain/zkvote-node/zkvote/model/subject"
 )
 
-const ZKPVOTE_DHT_KEY = "zkp-votes"
+// ZKPVOTE_DB_KEY is the key of the votes in the local datastore, the DHT record is under store.VotesKey
+const ZKPVOTE_DB_KEY = "zkp-votes"
+
+// The public signals of a rollup proof, the proof binds the subject and the transition of the root of votes.
+// The subject hash is divided by 8 to fit the field, the same as the external nullifier of ballots,
+// and the ballots of each option of the subject follow the roots.
+// The number of options is fixed by the circuit, so it's fixed by the verification key as well.
+const (
+	SIGNAL_SUBJECT = iota
+	SIGNAL_PREV_ROOT
+	SIGNAL_NEW_ROOT
+	SIGNAL_BALLOTS
+)
 
 type RollupProof struct {
-	Root         string                     `json:"root"`
-	Ballots      []int                      `json:"ballots"`
 	Proof        *externalVerif.CircomProof `json:"proof"`
-	PublicSignal []string                   `json:"public_signal"`
+	PublicSignal []string                   `json:"public_signal"` // subject hash / 8, previous root, new root, ballots of each option
+}
+
+// NewRollupProof ...
+func NewRollupProof(proof string) (*RollupProof, error) {
+	var rp RollupProof
+	err := json.Unmarshal([]byte(proof), &rp)
+	if err != nil {
+		utils.LogErrorf("parse rollup proof: unmarshal error %v", err.Error())
+		return nil, err
+	}
+	if nil == rp.Proof || SIGNAL_BALLOTS+2 > len(rp.PublicSignal) {
+		return nil, fmt.Errorf("incomplete rollup proof")
+	}
+	return &rp, nil
+}
+
+// ballots returns the ballots of each option committed by the proof
+func (rp *RollupProof) ballots() ([]int, error) {
+	ballots := make([]int, len(rp.PublicSignal)-SIGNAL_BALLOTS)
+	for i := range ballots {
+		b, err := strconv.Atoi(rp.PublicSignal[SIGNAL_BALLOTS+i])
+		if nil != err || 0 > b {
+			return nil, fmt.Errorf("invalid number of ballots, %v", rp.PublicSignal[SIGNAL_BALLOTS:])
+		}
+		ballots[i] = b
+	}
+	return ballots, nil
 }
 
 type ZkpVote struct {
 	votes *Votes
+	store *store.Store
+	lock  sync.RWMutex
 }
 
 func NewZkpVote(s *store.Store) (*ZkpVote, error) {
 
-	v, err := loadDataFromDHT(s)
+	v, err := loadData(s)
 	if err != nil {
 		return nil, err
 	}
 
 	return &ZkpVote{
 		votes: v,
+		store: s,
 	}, nil
 }
 
+// loadData loads the votes from the local datastore, then the DHT.
+// A node without any votes starts from the empty ones.
+func loadData(s *store.Store) (*Votes, error) {
+	utils.LogDebugf("ZKPVote: load DB")
+
+	value, err := s.GetLocal(ZKPVOTE_DB_KEY)
+	if err == nil && 0 != len(value) {
+		return NewVotesWithSerializedString(value)
+	}
+
+	return loadDataFromDHT(s)
+}
+
 func loadDataFromDHT(s *store.Store) (*Votes, error) {
 	utils.LogDebugf("ZKPVote: load DHT")
 
-	value, err := s.GetDHT(ZKPVOTE_DHT_KEY)
+	value, err := s.GetDHT(store.VotesKey(s.HostID()))
 	if err != nil {
-		utils.LogErrorf("Get DHT data error, %v", err)
-		return nil, err
+		utils.LogWarningf("Get DHT data error, %v", err)
+		return NewVotes()
 	}
 
 	if len(value) == 0 {
@@ -53,42 +108,135 @@ func loadDataFromDHT(s *store.Store) (*Votes, error) {
 	return NewVotesWithSerializedString(string(value))
 }
 
+// Rollup updates the ballots of a subject with a rollup proof.
+// The proof has to be based on the current root of votes, and the new root it commits to
+// has to be the root after the update, otherwise nothing changes.
 func (z *ZkpVote) Rollup(subHashHex s.HashHex, prvRoot *big.Int, rProof *RollupProof, vkString string) error {
+	z.lock.Lock()
+	defer z.lock.Unlock()
+
 	if !z.votes.IsRootMatched(prvRoot) {
 		utils.LogErrorf("Not match current root %v/%v", z.votes.Root, prvRoot)
 		return fmt.Errorf("Not match current root %v/%v", z.votes.Root, prvRoot)
 	}
-	if b, e := z.isValidProof(subHashHex, rProof, vkString); !b {
-		utils.LogErrorf("Not a valid proof, ", e)
-		return e
+	ballots, err := z.isValidProof(subHashHex, prvRoot, rProof, vkString)
+	if err != nil {
+		utils.LogErrorf("Not a valid proof, %v", err)
+		return err
+	}
+
+	votes, err := z.votes.copy()
+	if err != nil {
+		return err
+	}
+	// The first rollup of a subject starts from no ballots
+	if nil == votes.getVoteLeaf(subHashHex) {
+		err := votes.CreateAVoteByHash(subHashHex, len(ballots))
+		if err != nil {
+			return err
+		}
+	}
+	err = votes.Update(subHashHex, ballots)
+	if err != nil {
+		return err
+	}
+	if !isSameSignal(votes.Root, rProof.PublicSignal[SIGNAL_NEW_ROOT]) {
+		return fmt.Errorf("new root doesn't match the proof (%v)/(%v)", votes.Root, rProof.PublicSignal[SIGNAL_NEW_ROOT])
+	}
+
+	z.votes = votes
+	return z.save()
+}
+
+// GetRoot returns the root of the votes
+func (z *ZkpVote) GetRoot() *big.Int {
+	z.lock.RLock()
+	defer z.lock.RUnlock()
+
+	return big.NewInt(0).Set(z.votes.Root)
+}
+
+// GetBallots returns the ballots of a subject
+func (z *ZkpVote) GetBallots(subHashHex s.HashHex) ([]int, error) {
+	z.lock.RLock()
+	defer z.lock.RUnlock()
+
+	return z.votes.GetBallots(subHashHex)
+}
+
+// GetVoteLeaves returns the votes of all subjects
+func (z *ZkpVote) GetVoteLeaves() []*VoteLeaf {
+	z.lock.RLock()
+	defer z.lock.RUnlock()
+
+	return z.votes.GetVoteLeaves()
+}
+
+// save persists the votes into the local datastore and the DHT.
+// Failing to put the DHT is not an error since there may be no peers yet.
+func (z *ZkpVote) save() error {
+	value, err := z.votes.Serialize()
+	if err != nil {
+		return err
 	}
 
-	ballot_Yes, _ := strconv.Atoi(rProof.PublicSignal[1])
-	ballot_No, _ := strconv.Atoi(rProof.PublicSignal[2])
-	return z.votes.Update(subHashHex, []int{ballot_Yes, ballot_No})
+	err = z.store.PutLocal(ZKPVOTE_DB_KEY, value)
+	if err != nil {
+		utils.LogErrorf("Put local db error, %v", err)
+		return err
+	}
+
+	err = z.store.PutDHT(store.VotesKey(z.store.HostID()), value)
+	if err != nil {
+		utils.LogWarningf("Put DHT error, %v", err)
+	}
+	return nil
 }
 
-func (z *ZkpVote) isValidProof(subj s.HashHex, rProof *RollupProof, vkString string) (bool, error) {
+// isValidProof checks the signals of a rollup proof and verifies it, the ballots it commits to are returned
+func (z *ZkpVote) isValidProof(subj s.HashHex, prvRoot *big.Int, rProof *RollupProof, vkString string) ([]int, error) {
 	if 0 == len(vkString) {
 		utils.LogWarningf("invalid input: %s", vkString)
-		return false, fmt.Errorf("vk string is empty")
+		return nil, fmt.Errorf("vk string is empty")
+	}
+	if nil == rProof || nil == rProof.Proof || SIGNAL_BALLOTS+2 > len(rProof.PublicSignal) {
+		return nil, fmt.Errorf("incomplete rollup proof")
 	}
 
-	newRoot := rProof.PublicSignal[0]
-	ballot_Yes, _ := strconv.Atoi(rProof.PublicSignal[1])
-	ballot_No, _ := strconv.Atoi(rProof.PublicSignal[2])
+	bigSubj := utils.GetBigIntFromHexString(subj.String())
+	if nil == bigSubj {
+		return nil, fmt.Errorf("invalid subject hash, %v", subj)
+	}
+	bigSubj.Div(bigSubj, big.NewInt(8))
+	if !isSameSignal(bigSubj, rProof.PublicSignal[SIGNAL_SUBJECT]) {
+		return nil, fmt.Errorf("subject doesn't match the proof (%v)/(%v)", bigSubj, rProof.PublicSignal[SIGNAL_SUBJECT])
+	}
+	if !isSameSignal(prvRoot, rProof.PublicSignal[SIGNAL_PREV_ROOT]) {
+		return nil, fmt.Errorf("previous root doesn't match the proof (%v)/(%v)", prvRoot, rProof.PublicSignal[SIGNAL_PREV_ROOT])
+	}
+	if _, ok := big.NewInt(0).SetString(rProof.PublicSignal[SIGNAL_NEW_ROOT], 10); !ok {
+		return nil, fmt.Errorf("invalid new root, %v", rProof.PublicSignal[SIGNAL_NEW_ROOT])
+	}
 
-	bigNewRoot, _ := big.NewInt(0).SetString(newRoot, 10)
-	bigRoot, _ := big.NewInt(0).SetString(rProof.Root, 10)
-	if 0 != bigRoot.Cmp(bigNewRoot) {
-		utils.LogWarningf("root is inconsistent (%v)/(%v)", rProof.Root, bigNewRoot)
-		return false, fmt.Errorf(fmt.Sprintf("root is inconsistent (%v)/(%v)", rProof.Root, bigNewRoot))
+	ballots, err := rProof.ballots()
+	if err != nil {
+		return nil, err
+	}
+	// ballots can't decrease, a new subject starts from no ballots
+	if nil != z.votes.getVoteLeaf(subj) {
+		if b, e := z.votes.IsValidBallotNumber(subj, ballots); !b {
+			utils.LogWarningf("Not a valid ballot, %v", e)
+			return nil, e
+		}
 	}
 
-	if b, e := z.votes.IsValidBallotNumber(subj, []int{ballot_Yes, ballot_No}); !b {
-		utils.LogWarningf("Not a valid ballot, %v", e)
-		return false, e
+	if !snark.Verify(vkString, rProof.Proof, rProof.PublicSignal) {
+		return nil, fmt.Errorf("invalid proof")
 	}
+	return ballots, nil
+}
 
-	return snark.Verify(vkString, rProof.Proof, rProof.PublicSignal), nil
+func isSameSignal(x *big.Int, signal string) bool {
+	y, ok := big.NewInt(0).SetString(signal, 10)
+	return ok && 0 == x.Cmp(y)
 }
@@ -0,0 +1,134 @@
+package operator
+
+import (
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/unitychain/zkvote-node/zkvote/common/utils"
+)
+
+// Health of a running operator
+type Health struct {
+	Status  string `json:"status"`
+	PeerID  string `json:"peerID"`
+	DID     string `json:"did"`
+	Version string `json:"version"`
+	Uptime  int64  `json:"uptime"` // seconds
+}
+
+// Readiness tells if the operator can serve requests with an up-to-date state.
+// It's ready once the DHT has peers and no sync with peers is in progress.
+type Readiness struct {
+	Ready            bool `json:"ready"`
+	DHTBootstrapped  bool `json:"dhtBootstrapped"`
+	RoutingTableSize int  `json:"routingTableSize"`
+	PendingSyncs     int  `json:"pendingSyncs"`
+}
+
+// PeerInfo ...
+type PeerInfo struct {
+	ID    string   `json:"id"`
+	Addrs []string `json:"addrs"`
+}
+
+// Peers are the peers known to the operator
+type Peers struct {
+	ID          string              `json:"id"`
+	Addrs       []string            `json:"addrs"`
+	Connected   []*PeerInfo         `json:"connected"`
+	Connections int                 `json:"connections"`
+	Peerstore   []string            `json:"peerstore"` // peers with addresses
+	MDNS        []*PeerInfo         `json:"mdns"`
+	Topics      map[string][]string `json:"topics"` // peers of the subscribed topics
+}
+
+// DHTInfo ...
+type DHTInfo struct {
+	Bootstrapped bool     `json:"bootstrapped"`
+	Size         int      `json:"size"`
+	Peers        []string `json:"peers"` // of the routing table
+}
+
+// GetHealth ...
+func (o *Operator) GetHealth() *Health {
+	return &Health{
+		Status:  "ok",
+		PeerID:  o.Host.ID().Pretty(),
+		DID:     o.GetDID(),
+		Version: utils.ClientVersion,
+		Uptime:  int64(time.Since(o.startedAt).Seconds()),
+	}
+}
+
+// GetReadiness ...
+func (o *Operator) GetReadiness() *Readiness {
+	r := &Readiness{
+		DHTBootstrapped:  1 == atomic.LoadInt32(&o.bootstrapped),
+		RoutingTableSize: o.dht.RoutingTable().Size(),
+		PendingSyncs:     o.GetPendingSyncs(),
+	}
+	r.Ready = (r.DHTBootstrapped || 0 != r.RoutingTableSize) && 0 == r.PendingSyncs
+	return r
+}
+
+// GetPeers ...
+func (o *Operator) GetPeers() *Peers {
+	peers := &Peers{
+		ID:          o.Host.ID().Pretty(),
+		Addrs:       make([]string, 0),
+		Connected:   make([]*PeerInfo, 0),
+		Connections: len(o.Host.Network().Conns()),
+		Peerstore:   make([]string, 0),
+		MDNS:        make([]*PeerInfo, 0),
+		Topics:      make(map[string][]string),
+	}
+	for _, a := range o.Host.Addrs() {
+		peers.Addrs = append(peers.Addrs, a.String()+"/p2p/"+o.Host.ID().Pretty())
+	}
+	for _, p := range o.Host.Network().Peers() {
+		peers.Connected = append(peers.Connected, o.peerInfo(o.Host.Peerstore().PeerInfo(p)))
+	}
+	for _, p := range o.Host.Peerstore().PeersWithAddrs() {
+		peers.Peerstore = append(peers.Peerstore, p.Pretty())
+	}
+	sort.Strings(peers.Peerstore)
+
+	o.Mutex.RLock()
+	for _, pi := range o.mdnsPeers {
+		peers.MDNS = append(peers.MDNS, o.peerInfo(pi))
+	}
+	o.Mutex.RUnlock()
+
+	for _, topic := range o.pubsub.GetTopics() {
+		ids := make([]string, 0)
+		for _, p := range o.pubsub.ListPeers(topic) {
+			ids = append(ids, p.Pretty())
+		}
+		peers.Topics[topic] = ids
+	}
+	return peers
+}
+
+// GetDHTInfo ...
+func (o *Operator) GetDHTInfo() *DHTInfo {
+	info := &DHTInfo{
+		Bootstrapped: 1 == atomic.LoadInt32(&o.bootstrapped),
+		Size:         o.dht.RoutingTable().Size(),
+		Peers:        make([]string, 0),
+	}
+	for _, p := range o.dht.RoutingTable().ListPeers() {
+		info.Peers = append(info.Peers, p.Pretty())
+	}
+	sort.Strings(info.Peers)
+	return info
+}
+
+func (o *Operator) peerInfo(pi peer.AddrInfo) *PeerInfo {
+	info := &PeerInfo{ID: pi.ID.Pretty(), Addrs: make([]string, len(pi.Addrs))}
+	for i, a := range pi.Addrs {
+		info.Addrs[i] = a.String()
+	}
+	return info
+}
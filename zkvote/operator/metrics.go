@@ -0,0 +1,40 @@
+package operator
+
+import (
+	"github.com/unitychain/zkvote-node/zkvote/common/metrics"
+)
+
+// registerMetrics registers the gauges of the network and of the subjects to the default registry
+func (o *Operator) registerMetrics() {
+	metrics.Default.Register(metrics.NewGaugeFunc("zkvote_connected_peers", "Number of connected peers", func() float64 {
+		return float64(len(o.Host.Network().Peers()))
+	}))
+	metrics.Default.Register(metrics.NewGaugeFunc("zkvote_dht_routing_table_size", "Number of peers in the DHT routing table", func() float64 {
+		return float64(o.dht.RoutingTable().Size())
+	}))
+	metrics.Default.Register(metrics.NewGaugeVecFunc("zkvote_pubsub_topic_peers", "Number of peers of the subscribed pubsub topics", "topic", func() map[string]float64 {
+		peers := make(map[string]float64)
+		for _, topic := range o.pubsub.GetTopics() {
+			peers[topic] = float64(len(o.pubsub.ListPeers(topic)))
+		}
+		return peers
+	}))
+	metrics.Default.Register(metrics.NewGaugeFunc("zkvote_subjects", "Number of created and collected subjects", func() float64 {
+		subjects, _ := o.GetSubjectList()
+		return float64(len(subjects))
+	}))
+	metrics.Default.Register(metrics.NewGaugeFunc("zkvote_members", "Number of members of the joined subjects", func() float64 {
+		members := 0
+		for _, ids := range o.GetIdentityIndex() {
+			members += len(ids)
+		}
+		return float64(members)
+	}))
+	metrics.Default.Register(metrics.NewGaugeFunc("zkvote_ballots", "Number of ballots of the joined subjects", func() float64 {
+		ballots := 0
+		for _, m := range o.GetBallotMaps() {
+			ballots += len(m)
+		}
+		return float64(ballots)
+	}))
+}
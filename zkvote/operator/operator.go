@@ -2,13 +2,15 @@ package operator
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/ipfs/go-datastore"
-	ipns "github.com/ipfs/go-ipns"
 
 	"github.com/libp2p/go-libp2p"
 	circuit "github.com/libp2p/go-libp2p-circuit"
@@ -19,21 +21,137 @@ import (
 	dht "github.com/libp2p/go-libp2p-kad-dht"
 	dhtopts "github.com/libp2p/go-libp2p-kad-dht/opts"
 	pubsub "github.com/libp2p/go-libp2p-pubsub"
-	record "github.com/libp2p/go-libp2p-record"
 	msdnDiscovery "github.com/libp2p/go-libp2p/p2p/discovery"
 
 	"github.com/manifoldco/promptui"
 	ma "github.com/multiformats/go-multiaddr"
+	"github.com/unitychain/zkvote-node/zkvote/audit"
 	"github.com/unitychain/zkvote-node/zkvote/common/store"
 	"github.com/unitychain/zkvote-node/zkvote/common/utils"
+	"github.com/unitychain/zkvote-node/zkvote/model/archive"
 	localContext "github.com/unitychain/zkvote-node/zkvote/model/context"
+	"github.com/unitychain/zkvote-node/zkvote/model/credential"
+	"github.com/unitychain/zkvote-node/zkvote/model/subject"
 	"github.com/unitychain/zkvote-node/zkvote/operator/service/manager"
+	"github.com/unitychain/zkvote-node/zkvote/operator/service/manager/voter"
 )
 
 // node client version
 
 const DB_PEER_ID = "peerID"
 
+// VK_PATH is the path of the verification key of ballots
+const VK_PATH = "./snark/verification_key.json"
+
+// Defaults of the options
+const (
+	BUCKET_SIZE       = 1
+	CONN_LOW          = 1500
+	CONN_HIGH         = 2000
+	CONN_GRACE        = time.Minute
+	MDNS_INTERVAL     = 5 * time.Second
+	BOOTSTRAP_TIMEOUT = 30 * time.Second
+)
+
+type allOpts struct {
+	listenAddrs      []string
+	relay            bool
+	bucketSize       int
+	connLow          int
+	connHigh         int
+	connGrace        time.Duration
+	mdnsInterval     time.Duration
+	bootstrapTimeout time.Duration
+	vkPath           string
+	keyFile          string
+	managerOpts      []manager.Opt
+}
+
+// Opt represents an option of the operator
+type Opt func(opts *allOpts)
+
+func newOpts(opts []Opt) *allOpts {
+	o := &allOpts{
+		bucketSize:       BUCKET_SIZE,
+		connLow:          CONN_LOW,
+		connHigh:         CONN_HIGH,
+		connGrace:        CONN_GRACE,
+		mdnsInterval:     MDNS_INTERVAL,
+		bootstrapTimeout: BOOTSTRAP_TIMEOUT,
+		vkPath:           VK_PATH,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithListenAddrs sets the multiaddrs to listen on, the libp2p defaults are used if none is given
+func WithListenAddrs(addrs ...string) Opt {
+	return func(opts *allOpts) {
+		opts.listenAddrs = addrs
+	}
+}
+
+// WithRelay enables relaying the connections of other peers
+func WithRelay(relay bool) Opt {
+	return func(opts *allOpts) {
+		opts.relay = relay
+	}
+}
+
+// WithBucketSize sets the bucket size of the DHT routing table
+func WithBucketSize(size int) Opt {
+	return func(opts *allOpts) {
+		opts.bucketSize = size
+	}
+}
+
+// WithConnLimits sets the watermarks of the connection manager and the grace period of new connections
+func WithConnLimits(low int, high int, grace time.Duration) Opt {
+	return func(opts *allOpts) {
+		opts.connLow = low
+		opts.connHigh = high
+		opts.connGrace = grace
+	}
+}
+
+// WithMDNSInterval sets the interval of mDNS discovery
+func WithMDNSInterval(d time.Duration) Opt {
+	return func(opts *allOpts) {
+		opts.mdnsInterval = d
+	}
+}
+
+// WithBootstrapTimeout sets how long DHTBootstrap waits for the seeds
+func WithBootstrapTimeout(d time.Duration) Opt {
+	return func(opts *allOpts) {
+		opts.bootstrapTimeout = d
+	}
+}
+
+// WithVerificationKey sets the path of the verification key of ballots
+func WithVerificationKey(path string) Opt {
+	return func(opts *allOpts) {
+		opts.vkPath = path
+	}
+}
+
+// WithPrivateKeyFile sets the file of the hex encoded ECDSA key of the peer,
+// the key is generated and kept in the datastore if no file is given
+func WithPrivateKeyFile(path string) Opt {
+	return func(opts *allOpts) {
+		opts.keyFile = path
+	}
+}
+
+// WithManagerOpts sets the options of the manager
+func WithManagerOpts(managerOpts ...manager.Opt) Opt {
+	return func(opts *allOpts) {
+		opts.managerOpts = managerOpts
+	}
+}
+
 // Node ...
 type Operator struct {
 	*localContext.Context
@@ -42,13 +160,32 @@ type Operator struct {
 	pubsub    *pubsub.PubSub
 	db        datastore.Batching
 	mdnsPeers map[peer.ID]peer.AddrInfo
+	mdns      msdnDiscovery.Service
 	streams   chan network.Stream
+
+	opts         *allOpts
+	startedAt    time.Time
+	bootstrapped int32 // atomic, 1 once the DHT has bootstrapped with at least one seed
 }
 
-func loadPrivateKey(ds datastore.Batching) (crypto.PrivKey, error) {
+func loadPrivateKey(ds datastore.Batching, keyFile string) (crypto.PrivKey, error) {
 	var prvKey crypto.PrivKey
 	var err error
 
+	if 0 != len(keyFile) {
+		data, err := ioutil.ReadFile(keyFile)
+		if err != nil {
+			return nil, err
+		}
+		prvKey, err = crypto.UnmarshalECDSAPrivateKey(utils.GetBytesFromHexString(strings.TrimSpace(string(data))))
+		if err != nil {
+			return nil, fmt.Errorf("unmarshal private key error, %v", err)
+		}
+		b, _ := prvKey.GetPublic().Bytes()
+		utils.LogInfof("peer pub key: %v", utils.GetHexStringFromBytes(b))
+		return prvKey, nil
+	}
+
 	tmpStore, _ := store.NewStore(nil, ds)
 	strKey, _ := tmpStore.GetLocal(DB_PEER_ID)
 	if 0 == len(strKey) {
@@ -69,45 +206,40 @@ func loadPrivateKey(ds datastore.Batching) (crypto.PrivKey, error) {
 	return prvKey, err
 }
 
-// NewNode create a new node with its implemented protocols
-func NewOperator(ctx context.Context, ds datastore.Batching, relay bool, bucketSize int) (*Operator, error) {
-	cmgr := connmgr.NewConnManager(1500, 2000, time.Minute)
+// NewOperator create a new operator with its implemented protocols
+func NewOperator(ctx context.Context, ds datastore.Batching, opts ...Opt) (*Operator, error) {
+	o := newOpts(opts)
+	cmgr := connmgr.NewConnManager(o.connLow, o.connHigh, o.connGrace)
 
 	// Ignoring most errors for brevity
 	// See echo example for more details and better implementation
 
-	prvKey, err := loadPrivateKey(ds)
+	prvKey, err := loadPrivateKey(ds, o.keyFile)
 	if err != nil {
 		panic(err)
 	}
-	// listen, _ := ma.NewMultiaddr(fmt.Sprintf("/ip4/127.0.0.1/tcp/%d", port))
 
-	opts := []libp2p.Option{libp2p.ConnectionManager(cmgr), libp2p.Identity(prvKey)}
-	if relay {
-		opts = append(opts, libp2p.EnableRelay(circuit.OptHop))
+	libp2pOpts := []libp2p.Option{libp2p.ConnectionManager(cmgr), libp2p.Identity(prvKey)}
+	if 0 != len(o.listenAddrs) {
+		libp2pOpts = append(libp2pOpts, libp2p.ListenAddrStrings(o.listenAddrs...))
+	}
+	if o.relay {
+		libp2pOpts = append(libp2pOpts, libp2p.EnableRelay(circuit.OptHop))
 	}
 
-	host, err := libp2p.New(context.Background(), opts...)
+	host, err := libp2p.New(context.Background(), libp2pOpts...)
 	if err != nil {
 		panic(err)
 	}
 
-	d2, err := dht.New(context.Background(), host, dhtopts.BucketSize(bucketSize), dhtopts.Datastore(ds), dhtopts.Validator(record.NamespacedValidator{
-		"pk":   record.PublicKeyValidator{},
-		"ipns": ipns.Validator{KeyBook: host.Peerstore()},
-	}))
-	_ = d2
-
-	// Use an empty validator here for simplicity
-	// CAUTION! Use d2 will cause a "stream reset" error!
-
-	d1, err := dht.New(context.Background(), host, dhtopts.BucketSize(bucketSize), dhtopts.Datastore(ds), dhtopts.Validator(store.NodeValidator{}))
+	d1, err := dht.New(context.Background(), host, dhtopts.BucketSize(o.bucketSize), dhtopts.Datastore(ds), dhtopts.Validator(store.NewNodeValidator(host.Peerstore())))
 	if err != nil {
 		panic(err)
 	}
 
-	// Pubsub
-	ps, err := pubsub.NewGossipSub(ctx, host)
+	// Pubsub, the peers are blacklisted by their scores
+	score := voter.NewPeerScore(host)
+	ps, err := pubsub.NewGossipSub(ctx, host, pubsub.WithBlacklist(score))
 	if err != nil {
 		panic(err)
 	}
@@ -118,26 +250,87 @@ func NewOperator(ctx context.Context, ds datastore.Batching, relay bool, bucketS
 		db:        ds,
 		mdnsPeers: make(map[peer.ID]peer.AddrInfo),
 		streams:   make(chan network.Stream, 128),
+		opts:      o,
+		startedAt: time.Now(),
 	}
 	s, _ := store.NewStore(d1, ds)
 	cache, _ := store.NewCache()
 	op.Context = localContext.NewContext(new(sync.RWMutex), host, s, cache, &ctx)
 
-	vkData, err := ioutil.ReadFile("./snark/verification_key.json")
+	vkData, err := ioutil.ReadFile(o.vkPath)
 	if err != nil {
 		panic(err)
 	}
-	op.Manager, _ = manager.NewManager(ps, d1, op.Context, string(vkData))
+	op.Manager, err = manager.NewManager(ps, d1, op.Context, string(vkData), append([]manager.Opt{manager.WithPeerScore(score)}, o.managerOpts...)...)
+	if err != nil {
+		d1.Close()
+		host.Close()
+		return nil, err
+	}
+	op.registerMetrics()
 
-	mdns, err := msdnDiscovery.NewMdnsService(ctx, host, time.Second*5, "")
+	mdns, err := msdnDiscovery.NewMdnsService(ctx, host, o.mdnsInterval, "")
 	if err != nil {
 		panic(err)
 	}
 	mdns.RegisterNotifee(op)
+	op.mdns = mdns
 
 	return op, nil
 }
 
+// Start the services of the operator
+func (o *Operator) Start() {
+	o.Manager.Start()
+}
+
+// Stop the discovery and the manager, and then close the DHT and the host.
+// The in-flight syncs are drained and the subjects are saved, ctx bounds how long it takes.
+func (o *Operator) Stop(ctx context.Context) error {
+	utils.LogInfo("Stop operator")
+	o.mdns.Close()
+	err := o.Manager.Stop(ctx)
+	if nil != err {
+		utils.LogWarningf("Stop manager error, %v", err)
+	}
+	if e := o.dht.Close(); nil != e {
+		utils.LogWarningf("Close DHT error, %v", e)
+	}
+	if e := o.Host.Close(); nil != e {
+		utils.LogWarningf("Close host error, %v", e)
+	}
+	return err
+}
+
+// AuditArchive audits an exported archive without starting the node.
+// The report is signed with the key of the node, only the key and verification key options apply.
+func AuditArchive(ds datastore.Batching, path string, opts ...Opt) (*audit.Report, error) {
+	o := newOpts(opts)
+	prvKey, err := loadPrivateKey(ds, o.keyFile)
+	if err != nil {
+		return nil, err
+	}
+	vkData, err := ioutil.ReadFile(o.vkPath)
+	if err != nil {
+		return nil, err
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	a, err := archive.NewArchive(data)
+	if err != nil {
+		return nil, err
+	}
+
+	report := audit.Audit(a, string(vkData))
+	err = report.Sign(prvKey)
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
 // HandlePeerFound msdn handler
 func (o *Operator) HandlePeerFound(pi peer.AddrInfo) {
 	o.Mutex.Lock()
@@ -149,82 +342,50 @@ func (o *Operator) HandlePeerFound(pi peer.AddrInfo) {
 	}
 }
 
-// Info ...
+// Info prints the diagnostics which the /admin endpoints serve
 func (o *Operator) Info() error {
-	// 0b. Let's get a sense of what those defaults are. What transports are we
-	// listening on? Each transport will have a multiaddr. If you run this
-	// multiple times, you will get different port numbers. Note how we listen
-	// on all interfaces by default.
-	// fmt.Println("My addresses:")
-	// for _, a := range o.h.Addrs() {
-	// 	fmt.Printf("\t%s\n", a)
-	// }
-
-	fmt.Println()
-	fmt.Println("My peer ID:")
-	fmt.Printf("\t%s\n", o.Host.ID())
-
-	fmt.Println()
-	fmt.Println("My identified multiaddrs:")
-	for _, a := range o.Host.Addrs() {
-		fmt.Printf("\t%s/p2p/%s\n", a, o.Host.ID())
+	subjects, err := o.GetSubjectList()
+	if err != nil {
+		return err
 	}
-
-	// What protocols are added by default?
-	// fmt.Println()
-	// fmt.Println("Protocols:")
-	// for _, p := range o.h.Mux().Protocols() {
-	// 	fmt.Printf("\t%s\n", p)
-	// }
-
-	// What peers do we have in our peerstore? (hint: we've connected to nobody so far).
-	fmt.Println()
-	fmt.Println("Peers in peerstore:")
-	for _, p := range o.Host.Peerstore().PeersWithAddrs() {
-		fmt.Printf("\t%s\n", p)
+	debugs := make(map[string]interface{})
+	for _, s := range subjects {
+		debug, err := o.GetSubjectDebug(s.HashHex().String())
+		if err != nil {
+			return err
+		}
+		debugs[s.HashHex().String()] = debug
 	}
-	fmt.Println(len(o.Host.Peerstore().PeersWithAddrs()))
 
-	// DHT routing table
-	fmt.Println("DHT Routing table:")
-	o.dht.RoutingTable().Print()
-
-	// Connections
-	fmt.Println("Connections:")
-	fmt.Println(len(o.Host.Network().Conns()))
-
-	fmt.Println("Created subjectHashHex:")
-	for sh := range o.Cache.GetCreatedSubjects() {
-		fmt.Println(sh)
+	data, err := json.MarshalIndent(map[string]interface{}{
+		"health":    o.GetHealth(),
+		"readiness": o.GetReadiness(),
+		"peers":     o.GetPeers(),
+		"dht":       o.GetDHTInfo(),
+		"subjects":  debugs,
+	}, "", "  ")
+	if err != nil {
+		return err
 	}
-
-	fmt.Println("Collected subjects:")
-	fmt.Println(o.Cache.GetCollectedSubjects())
-
-	fmt.Println("Subcribed topics:")
-	fmt.Println(o.pubsub.GetTopics())
-
-	fmt.Println("Identity Index:")
-	fmt.Println(o.GetIdentityIndex())
-
-	fmt.Println("Voter Identity Merkle Tree Contents:")
-	fmt.Println(o.GetVoterIdentities())
-
-	fmt.Println("Ballot Map:")
-	fmt.Println(o.GetBallotMaps())
-
+	fmt.Println(string(data))
 	return nil
 }
 
-// DHTBootstrap ...
+// DHTBootstrap connects to the seeds and bootstraps the DHT.
+// The node is bootstrapped once at least one seed is connected, or right away if there are no seeds.
 func (o *Operator) DHTBootstrap(seeds ...ma.Multiaddr) error {
-	fmt.Println("Will bootstrap for 30 seconds...")
+	if 0 == len(seeds) {
+		atomic.StoreInt32(&o.bootstrapped, 1)
+		return nil
+	}
+	fmt.Printf("Will bootstrap for %v...\n", o.opts.bootstrapTimeout)
 
-	ctx, cancel := context.WithTimeout(*o.Ctx, 30*time.Second)
+	ctx, cancel := context.WithTimeout(*o.Ctx, o.opts.bootstrapTimeout)
 	defer cancel()
 
 	var wg sync.WaitGroup
 	wg.Add(len(seeds))
+	var connected int32
 
 	for _, ma := range seeds {
 		ai, err := peer.AddrInfoFromP2pAddr(ma)
@@ -240,11 +401,15 @@ func (o *Operator) DHTBootstrap(seeds ...ma.Multiaddr) error {
 				fmt.Printf("Failed while connecting to peer: %s; %s\n", ai, err)
 			} else {
 				fmt.Printf("Succeeded while connecting to peer: %s\n", ai)
+				atomic.AddInt32(&connected, 1)
 			}
 		}(*ai)
 	}
 
 	wg.Wait()
+	if 0 == atomic.LoadInt32(&connected) {
+		return fmt.Errorf("failed to connect to any of the %d seeds", len(seeds))
+	}
 
 	// if err := o.dht.BootstrapRandom(ctx); err != nil && err != context.DeadlineExceeded {
 	// 	return fmt.Errorf("failed while bootstrapping DHT: %w", err)
@@ -252,6 +417,7 @@ func (o *Operator) DHTBootstrap(seeds ...ma.Multiaddr) error {
 	if err := o.dht.Bootstrap(ctx); err != nil && err != context.DeadlineExceeded {
 		return fmt.Errorf("failed while bootstrapping DHT: %w", err)
 	}
+	atomic.StoreInt32(&o.bootstrapped, 1)
 
 	fmt.Println("bootstrap OK! Routing table:")
 	o.dht.RoutingTable().Print()
@@ -259,97 +425,22 @@ func (o *Operator) DHTBootstrap(seeds ...ma.Multiaddr) error {
 	return nil
 }
 
-// // Authenticate incoming p2p message
-// // message: a protobufs go data object
-// // data: common p2p message data
-// func (n *Node) authenticateMessage(message proto.Message, data *p2p.MessageData) bool {
-// 	// store a temp ref to signature and remove it from message data
-// 	// sign is a string to allow easy reset to zero-value (empty string)
-// 	sign := data.Sign
-// 	data.Sign = nil
-
-// 	// marshall data without the signature to protobufs3 binary format
-// 	bin, err := proto.Marshal(message)
-// 	if err != nil {
-// 		log.Println(err, "failed to marshal pb message")
-// 		return false
-// 	}
-
-// 	// restore sig in message data (for possible future use)
-// 	data.Sign = sign
-
-// 	// restore peer id binary format from base58 encoded node id data
-// 	peerId, err := peer.IDB58Decode(data.NodeId)
-// 	if err != nil {
-// 		log.Println(err, "Failed to decode node id from base58")
-// 		return false
-// 	}
-
-// 	// verify the data was authored by the signing peer identified by the public key
-// 	// and signature included in the message
-// 	return n.verifyData(bin, []byte(sign), peerId, data.NodePubKey)
-// }
-
-// // sign an outgoing p2p message payload
-// func (n *Node) signProtoMessage(message proto.Message) ([]byte, error) {
-// 	data, err := proto.Marshal(message)
-// 	if err != nil {
-// 		return nil, err
-// 	}
-// 	return n.signData(data)
-// }
-
-// // sign binary data using the local node's private key
-// func (n *Node) signData(data []byte) ([]byte, error) {
-// 	key := n.Peerstore().PrivKey(n.ID())
-// 	res, err := key.Sign(data)
-// 	return res, err
-// }
-
-// Verify incoming p2p message data integrity
-// data: data to verify
-// signature: author signature provided in the message payload
-// peerId: author peer id from the message payload
-// pubKeyData: author public key from the message payload
-// func (n *Node) verifyData(data []byte, signature []byte, peerId peer.ID, pubKeyData []byte) bool {
-// 	key, err := crypto.UnmarshalPublicKey(pubKeyData)
-// 	if err != nil {
-// 		log.Println(err, "Failed to extract key from message key data")
-// 		return false
-// 	}
-
-// 	// extract node id from the provided public key
-// 	idFromKey, err := peer.IDFromPublicKey(key)
-
-// 	if err != nil {
-// 		log.Println(err, "Failed to extract peer id from public key")
-// 		return false
-// 	}
-
-// 	// verify that message author node id matches the provided node public key
-// 	if idFromKey != peerId {
-// 		log.Println(err, "Node id and provided public key mismatch")
-// 		return false
-// 	}
-
-// 	res, err := key.Verify(data, signature)
-// 	if err != nil {
-// 		log.Println(err, "Error authenticating data")
-// 		return false
-// 	}
-
-// 	return res
-// }
-
-// Run interactive commands
-func (o *Operator) Run() {
+// Run interactive commands until the prompt is interrupted
+func (o *Operator) Run() error {
 	commands := []struct {
 		name string
 		exec func() error
 	}{
 		{"My info", o.handleMyInfo},
 		{"Manager: Propose a subject", o.handlePropose},
+		{"Manager: Admit an identity", o.handleAdmit},
+		{"Manager: Issue a credential", o.handleIssueCredential},
+		{"Manager: Revoke a credential", o.handleRevokeCredential},
 		{"Manager: Join a subject", o.handleJoin},
+		{"Manager: Export a subject", o.handleExport},
+		{"Manager: Import a subject", o.handleImport},
+		{"Manager: Audit a subject", o.handleAudit},
+		{"Manager: Audit an archive", o.handleAuditArchive},
 		{"Manager: Find topic providers", o.handleFindProposers},
 		{"Manager: Collect all topics", o.handleCollect},
 		// {"Manager: Sync identity index", o.handleSyncIdentityIndex},
@@ -375,7 +466,7 @@ func (o *Operator) Run() {
 		fmt.Println()
 		i, _, err := sel.Run()
 		if err != nil {
-			panic(err)
+			return err
 		}
 
 		if err := commands[i].exec(); err != nil {
@@ -429,7 +520,20 @@ func (o *Operator) handlePropose() error {
 		return err
 	}
 
-	return o.Propose(title, description, "")
+	p = promptui.Prompt{
+		Label: "Subject options (comma separated, empty for yes/no)",
+	}
+	options, err := p.Run()
+	if err != nil {
+		return err
+	}
+	var opts []subject.Opt
+	if options = strings.TrimSpace(options); 0 != len(options) {
+		opts = append(opts, subject.WithOptions(strings.Split(options, ",")...))
+	}
+
+	_, err = o.Propose(title, description, "", opts...)
+	return err
 }
 
 func (o *Operator) handleJoin() error {
@@ -449,7 +553,177 @@ func (o *Operator) handleJoin() error {
 		return err
 	}
 
-	return o.Join(subjectHashHex, identityCommitmentHex)
+	p = promptui.Prompt{
+		Label: "Credential or admission hex (empty if the subject doesn't require one)",
+	}
+	presented, err := p.Run()
+	if err != nil {
+		return err
+	}
+
+	return o.Join(subjectHashHex, identityCommitmentHex, presented)
+}
+
+func (o *Operator) handleAdmit() error {
+	p := promptui.Prompt{
+		Label: "Subject hash hex",
+	}
+	subjectHashHex, err := p.Run()
+	if err != nil {
+		return err
+	}
+
+	p = promptui.Prompt{
+		Label: "Identity commitment hex",
+	}
+	identityCommitmentHex, err := p.Run()
+	if err != nil {
+		return err
+	}
+
+	admission, err := o.Admit(subjectHashHex, identityCommitmentHex)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Admission: %s\n", admission)
+	return nil
+}
+
+func (o *Operator) handleIssueCredential() error {
+	p := promptui.Prompt{
+		Label: "Subject hash hex",
+	}
+	subjectHashHex, err := p.Run()
+	if err != nil {
+		return err
+	}
+
+	p = promptui.Prompt{
+		Label: "Identity commitment hex",
+	}
+	identityCommitmentHex, err := p.Run()
+	if err != nil {
+		return err
+	}
+
+	sel := promptui.Select{
+		Label: "Credential format",
+		Items: []string{credential.FORMAT_JSONLD, credential.FORMAT_JWT},
+	}
+	_, format, err := sel.Run()
+	if err != nil {
+		return err
+	}
+
+	c, err := o.IssueCredential(subjectHashHex, identityCommitmentHex, format, 0)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Credential: %s\n", c)
+	return nil
+}
+
+func (o *Operator) handleRevokeCredential() error {
+	p := promptui.Prompt{
+		Label: "Credential id",
+	}
+	credentialID, err := p.Run()
+	if err != nil {
+		return err
+	}
+
+	return o.RevokeCredential(credentialID)
+}
+
+func (o *Operator) handleExport() error {
+	p := promptui.Prompt{
+		Label: "Subject hash hex",
+	}
+	subjectHashHex, err := p.Run()
+	if err != nil {
+		return err
+	}
+
+	p = promptui.Prompt{
+		Label: "Archive file path",
+	}
+	path, err := p.Run()
+	if err != nil {
+		return err
+	}
+
+	data, err := o.Export(subjectHashHex)
+	if err != nil {
+		return err
+	}
+	err = ioutil.WriteFile(path, data, 0644)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Exported to %s\n", path)
+	return nil
+}
+
+func (o *Operator) handleImport() error {
+	p := promptui.Prompt{
+		Label: "Archive file path",
+	}
+	path, err := p.Run()
+	if err != nil {
+		return err
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	s, err := o.Import(data)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Imported subject %s\n", s.HashHex().String())
+	return nil
+}
+
+func (o *Operator) handleAudit() error {
+	p := promptui.Prompt{
+		Label: "Subject hash hex",
+	}
+	subjectHashHex, err := p.Run()
+	if err != nil {
+		return err
+	}
+
+	report, err := o.Audit(subjectHashHex)
+	if err != nil {
+		return err
+	}
+	return printReport(report)
+}
+
+func (o *Operator) handleAuditArchive() error {
+	p := promptui.Prompt{
+		Label: "Archive file path",
+	}
+	path, err := p.Run()
+	if err != nil {
+		return err
+	}
+
+	report, err := AuditArchive(o.db, path, WithPrivateKeyFile(o.opts.keyFile), WithVerificationKey(o.opts.vkPath))
+	if err != nil {
+		return err
+	}
+	return printReport(report)
+}
+
+func printReport(report *audit.Report) error {
+	data, err := report.Byte()
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(data))
+	return nil
 }
 
 // func (o *Operator) handleSyncIdentityIndex() error {
@@ -0,0 +1,326 @@
+package operator
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+	"unsafe"
+
+	"github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	"github.com/libp2p/go-libp2p-core/peer"
+	tu "github.com/libp2p/go-libp2p-core/test"
+	ma "github.com/multiformats/go-multiaddr"
+	"github.com/stretchr/testify/assert"
+	"github.com/unitychain/zkvote-node/zkvote/common/store"
+	"github.com/unitychain/zkvote-node/zkvote/common/utils"
+	id "github.com/unitychain/zkvote-node/zkvote/model/identity"
+	"github.com/unitychain/zkvote-node/zkvote/model/subject"
+	"github.com/unitychain/zkvote-node/zkvote/operator/service/manager"
+	pro "github.com/unitychain/zkvote-node/zkvote/operator/service/manager/protocol"
+)
+
+// Identity commitments and the external nullifier of the ballots in service/test/vectors,
+// the ballot i is proven with the tree of the first i+1 commitments
+var vectorIdentities = [...]string{
+	"17610192990552485611214212559447309706539616482639833145108503521837267798810",
+	"12911218845103750861406388355446622324232020446592892913783900846357287231484",
+	"1032284940727177649939355598892149604970527830516654273792409518693495635491",
+	"21176767283001926398440783773513762000716980892317227378236556477014800668400",
+	"277593402026800763958336343436402082617452946079134938418951384937122081698",
+	"6894328305305102806720810244282194911715263103973532827353421862495894269552",
+	"16696521640980513762895570995527758356630582039153470423682046528515430261262",
+	"3445457589949194301656936787185600418234571893114183450807142773072595456469",
+	"312527539520761448694291090800047775034752705203482547162969903432178197468",
+	"15339060964594858963739198162689168732368415427742964439329199487013648959013",
+}
+
+const vectorExternalNullifier = "9695771177025341492834515246141576816221841749730679787621778614635855226700"
+
+func newTestOperator(t *testing.T) *Operator {
+	ds := dssync.MutexWrap(datastore.NewMapDatastore())
+	op, err := NewOperator(context.Background(), ds,
+		WithListenAddrs("/ip4/127.0.0.1/tcp/0"),
+		WithVerificationKey("../../snark/verification_key.json"),
+		WithManagerOpts(manager.WithSyncInterval(time.Second), manager.WithSyncTimeout(5*time.Second)))
+	assert.Nil(t, err)
+	op.Start()
+	return op
+}
+
+// collect requests the subjects of a peer by the subject protocol and collects them as SyncSubjects does.
+// The protocol of the operator is created again since the one of the manager isn't exported, it serves the same context.
+func collect(t *testing.T, op *Operator, from *Operator) {
+	subjects, err := pro.NewSubjectProtocol(op.Context).SubmitRequest(context.Background(), from.Host.ID())
+	assert.Nil(t, err)
+	for _, s := range subjects {
+		op.Cache.InsertColletedSubject(*s.HashHex(), s)
+	}
+}
+
+// vectorSubject returns a subject sequenced by a peer whose hash is the one the vectors are proven for.
+// The preimage of that hash is unknown, so the cached hash is set directly.
+func vectorSubject(sequencer peer.ID) *subject.Subject {
+	sub := subject.NewSubject("vectors", "", id.NewIdentity(hexOf(vectorIdentities[0])), subject.WithSequencer(sequencer.Pretty()))
+	h, _ := big.NewInt(0).SetString(vectorExternalNullifier, 10)
+	hash := subject.HashHex(utils.Remove0x(utils.GetHexStringFromBigInt(h.Mul(h, big.NewInt(8)))))
+
+	f := reflect.ValueOf(sub).Elem().FieldByName("hash")
+	reflect.NewAt(f.Type(), unsafe.Pointer(f.UnsafeAddr())).Elem().Set(reflect.ValueOf(hash))
+	return sub
+}
+
+func hexOf(decimal string) string {
+	n, _ := big.NewInt(0).SetString(decimal, 10)
+	return utils.GetHexStringFromBigInt(n)
+}
+
+func newTestOperators(t *testing.T, n int) ([]*Operator, func()) {
+	ops := make([]*Operator, n)
+	for i := range ops {
+		ops[i] = newTestOperator(t)
+	}
+	for i, a := range ops {
+		for _, b := range ops[i+1:] {
+			ai := peer.AddrInfo{ID: b.Host.ID(), Addrs: b.Host.Addrs()}
+			// The dial is shared with the ones of the mDNS discovery, which are cancelled with their operators
+			err := a.Host.Connect(context.Background(), ai)
+			if nil != err {
+				err = a.Host.Connect(context.Background(), ai)
+			}
+			assert.Nil(t, err)
+		}
+	}
+	return ops, func() {
+		for _, op := range ops {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			assert.Nil(t, op.Stop(ctx))
+			cancel()
+		}
+	}
+}
+
+// syncAll runs the syncs of a subject with every peer a few times
+func syncAll(op *Operator, subjHex subject.HashHex) {
+	for i := 0; i < 5; i++ {
+		if ch, err := op.SyncIdentities(subjHex); nil == err {
+			for range ch {
+			}
+		}
+		if ch, err := op.SyncBallots(subjHex); nil == err {
+			for range ch {
+			}
+		}
+	}
+}
+
+func TestConcurrentTraffic(t *testing.T) {
+	ops, stop := newTestOperators(t, 3)
+	defer stop()
+
+	sub, err := ops[0].Propose("title", "desc", "0x1111")
+	assert.Nil(t, err)
+	subjHex := sub.HashHex().String()
+	for _, op := range ops[1:] {
+		collect(t, op, ops[0])
+		// The collected subject is the proposed one
+		collected := op.Cache.GetACollectedSubject(*sub.HashHex())
+		if assert.NotNil(t, collected) {
+			assert.Equal(t, sub.Sequencer, collected.Sequencer)
+			assert.True(t, collected.RequiresAdmission())
+		}
+		// The requests of the joiners have to reach the proposer
+		assert.Eventually(t, func() bool {
+			return 0 != len(op.pubsub.ListPeers("identity/"+subjHex))
+		}, 10*time.Second, 100*time.Millisecond)
+	}
+
+	var wg sync.WaitGroup
+	run := func(fn func()) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			fn()
+		}()
+	}
+	joins := map[*Operator][]string{
+		ops[0]: {"0x2222", "0x3333"},
+		ops[1]: {"0x4444"},
+		ops[2]: {"0x5555"},
+	}
+	for op, idcs := range joins {
+		op, idcs := op, idcs
+		run(func() {
+			for _, idc := range idcs {
+				// Even on the node of the proposer, only Admit lets an identity in
+				assert.NotNil(t, op.Join(subjHex, idc, ""))
+				admission, err := ops[0].Admit(subjHex, idc)
+				assert.Nil(t, err)
+				assert.Nil(t, op.Join(subjHex, idc, admission))
+			}
+		})
+	}
+	for _, op := range ops {
+		op := op
+		run(func() {
+			for i := 0; i < 20; i++ {
+				op.GetSubjectList()
+				op.GetMembers(subjHex)
+				op.GetBallotMaps()
+				op.GetVoterIdentities()
+				op.GetProviders()
+				assert.NotNil(t, op.Vote(subjHex, "invalid"))
+				time.Sleep(10 * time.Millisecond)
+			}
+		})
+	}
+	for _, op := range ops {
+		op := op
+		run(func() { syncAll(op, *sub.HashHex()) })
+	}
+	wg.Wait()
+
+	// The sequencer orders the identities, so every operator has the same tree
+	expected := []string{"0x1111", "0x2222", "0x3333", "0x4444", "0x5555"}
+	for _, op := range ops {
+		op := op
+		assert.Eventually(t, func() bool {
+			members, err := op.GetMembers(subjHex)
+			return nil == err && len(expected) == len(members)
+		}, 20*time.Second, 100*time.Millisecond)
+	}
+	members, err := ops[0].GetMembers(subjHex)
+	assert.Nil(t, err)
+	for _, op := range ops[1:] {
+		m, err := op.GetMembers(subjHex)
+		assert.Nil(t, err)
+		assert.Equal(t, members, m)
+	}
+}
+
+func TestConcurrentVotes(t *testing.T) {
+	ops, stop := newTestOperators(t, 3)
+	defer stop()
+
+	// The hash of the subject is forged, the peers can't collect it by the subject protocol since they rehash it
+	sub := vectorSubject(ops[0].Host.ID())
+	subjHex := sub.HashHex().String()
+	for _, op := range ops {
+		s := *sub
+		op.Cache.InsertColletedSubject(*s.HashHex(), &s)
+	}
+
+	// The sequencer inserts the identities in the order the ballots are proven with
+	assert.Nil(t, ops[0].Join(subjHex, hexOf(vectorIdentities[0]), ""))
+	assert.Eventually(t, func() bool {
+		members, err := ops[0].GetMembers(subjHex)
+		return nil == err && 1 == len(members)
+	}, 10*time.Second, 100*time.Millisecond)
+	for _, idc := range vectorIdentities[1:] {
+		assert.Nil(t, ops[0].InsertIdentity(subjHex, hexOf(idc), ""))
+	}
+	// The other operators take the identities from the sequencer and join after them
+	for i, op := range ops[1:] {
+		op := op
+		assert.Eventually(t, func() bool {
+			return 0 != len(op.pubsub.ListPeers("identity/"+subjHex))
+		}, 10*time.Second, 100*time.Millisecond)
+		assert.Nil(t, op.Join(subjHex, fmt.Sprintf("0x%d", 4444+1111*i), ""))
+	}
+	for _, op := range ops {
+		op := op
+		assert.Eventually(t, func() bool {
+			members, err := op.GetMembers(subjHex)
+			return nil == err && len(vectorIdentities)+2 == len(members)
+		}, 20*time.Second, 100*time.Millisecond)
+	}
+
+	var wg sync.WaitGroup
+	run := func(fn func()) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			fn()
+		}()
+	}
+	// Each proof takes seconds to verify on every operator, so only a few of the vectors are cast
+	const ballots = 6
+	for i, op := range ops {
+		i, op := i, op
+		run(func() {
+			for v := i; v < ballots; v += len(ops) {
+				proof, err := ioutil.ReadFile(fmt.Sprintf("service/test/vectors/vote%d.proof", v))
+				assert.Nil(t, err)
+				assert.Nil(t, op.Vote(subjHex, string(proof)))
+			}
+		})
+		run(func() { syncAll(op, *sub.HashHex()) })
+		run(func() {
+			for i := 0; i < 20; i++ {
+				op.GetBallotMaps()
+				op.GetMembers(subjHex)
+				op.Open(subjHex)
+				time.Sleep(10 * time.Millisecond)
+			}
+		})
+	}
+	wg.Wait()
+
+	// Every ballot reaches every operator, by gossip or by sync
+	tallies := make([][]int, len(ops))
+	for i, op := range ops {
+		deadline := time.Now().Add(60 * time.Second)
+		for {
+			votes, err := op.Open(subjHex)
+			assert.Nil(t, err)
+			tallies[i] = votes
+			if ballots == votes[0]+votes[1] || time.Now().After(deadline) {
+				break
+			}
+			if ch, err := op.SyncBallots(*sub.HashHex()); nil == err {
+				for range ch {
+				}
+			}
+		}
+		assert.Equal(t, ballots, tallies[i][0]+tallies[i][1])
+		assert.Equal(t, tallies[0], tallies[i])
+	}
+}
+
+func TestDHTBootstrap(t *testing.T) {
+	ops, stop := newTestOperators(t, 2)
+	defer stop()
+
+	// A node without seeds is bootstrapped
+	assert.Nil(t, ops[0].DHTBootstrap())
+	assert.True(t, ops[0].GetReadiness().DHTBootstrapped)
+
+	// A node which can't connect to any of its seeds isn't
+	unreachable, err := ma.NewMultiaddr("/ip4/127.0.0.1/tcp/1/p2p/" + tu.RandPeerIDFatal(t).Pretty())
+	assert.Nil(t, err)
+	assert.NotNil(t, ops[1].DHTBootstrap(unreachable))
+	assert.False(t, ops[1].GetReadiness().DHTBootstrapped)
+
+	seed, err := ma.NewMultiaddr(ops[0].Host.Addrs()[0].String() + "/p2p/" + ops[0].Host.ID().Pretty())
+	assert.Nil(t, err)
+	assert.Nil(t, ops[1].DHTBootstrap(unreachable, seed))
+	assert.True(t, ops[1].GetReadiness().DHTBootstrapped)
+}
+
+func TestNewOperator_ManagerError(t *testing.T) {
+	ds := dssync.MutexWrap(datastore.NewMapDatastore())
+	s, err := store.NewStore(nil, ds)
+	assert.Nil(t, err)
+	assert.Nil(t, s.PutLocal(manager.KEY_ISSUER, "not a key"))
+
+	op, err := NewOperator(context.Background(), ds,
+		WithListenAddrs("/ip4/127.0.0.1/tcp/0"),
+		WithVerificationKey("../../snark/verification_key.json"))
+	assert.NotNil(t, err)
+	assert.Nil(t, op)
+}
@@ -6,14 +6,19 @@ import (
 	"sync"
 	"time"
 
+	ic "github.com/libp2p/go-libp2p-core/crypto"
 	"github.com/libp2p/go-libp2p-core/discovery"
 	"github.com/libp2p/go-libp2p-core/peer"
 	routingDiscovery "github.com/libp2p/go-libp2p-discovery"
 	dht "github.com/libp2p/go-libp2p-kad-dht"
 	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	"github.com/unitychain/zkvote-node/zkvote/audit"
+	"github.com/unitychain/zkvote-node/zkvote/common/event"
 	"github.com/unitychain/zkvote-node/zkvote/common/utils"
+	"github.com/unitychain/zkvote-node/zkvote/model/archive"
 	ba "github.com/unitychain/zkvote-node/zkvote/model/ballot"
 	localContext "github.com/unitychain/zkvote-node/zkvote/model/context"
+	"github.com/unitychain/zkvote-node/zkvote/model/credential"
 	id "github.com/unitychain/zkvote-node/zkvote/model/identity"
 	"github.com/unitychain/zkvote-node/zkvote/model/subject"
 	pro "github.com/unitychain/zkvote-node/zkvote/operator/service/manager/protocol"
@@ -22,25 +27,82 @@ import (
 
 const KEY_SUBJECTS = "subjects"
 
+// Defaults of the options
+const (
+	SYNC_INTERVAL = 60 * time.Second
+	SYNC_TIMEOUT  = 30 * time.Second
+)
+
+// Errors of the manager, the details are wrapped after them
+var (
+	ErrSubjectNotFound = fmt.Errorf("Can NOT find subject")
+	ErrSubjectExisted  = fmt.Errorf("subject already existed")
+	ErrSubjectClosed   = fmt.Errorf("subject has been closed")
+)
+
 // Manager ...
 type Manager struct {
 	*localContext.Context
-	subjProtocol   pro.Protocol
-	idProtocol     pro.Protocol
-	ballotProtocol pro.Protocol
+	subjProtocol *pro.SubjectProtocol
+	syncProtocol *pro.SyncProtocol
 
 	ps                *pubsub.PubSub
 	dht               *dht.IpfsDHT
 	discovery         discovery.Discovery
 	providers         map[peer.ID]string
+	providerLock      sync.RWMutex
 	subjectProtocolCh chan []*subject.Subject
-	voters            map[subject.HashHex]*voter.Voter
-	chAnnounce        chan bool
+	voters            map[subject.HashHex]*voter.Voter // guarded by votersLock, each voter guards its own state
+	votersLock        sync.RWMutex
+	peerScore         *voter.PeerScore
+	announceOnce      sync.Once
 
 	zkVerificationKey string
 
-	idLock     sync.Mutex
-	ballotLock sync.Mutex
+	// membership credentials are issued with a DID key of this node
+	issuerKey      ic.PrivKey
+	issuerDID      string
+	credentials    map[string]*issuedCredential
+	credentialLock sync.Mutex
+
+	idLock       sync.Mutex
+	ballotLock   sync.Mutex
+	pendingSyncs int32 // atomic
+
+	syncInterval time.Duration
+	syncTimeout  time.Duration
+
+	// the background workers are done when ctx is cancelled by Stop
+	ctx           context.Context
+	cancel        context.CancelFunc
+	workers       sync.WaitGroup
+	lifecycleLock sync.Mutex
+	stopped       bool
+}
+
+// Opt represents an option of the manager
+type Opt func(m *Manager)
+
+// WithSyncInterval sets the interval of collecting the subjects of peers
+func WithSyncInterval(d time.Duration) Opt {
+	return func(m *Manager) {
+		m.syncInterval = d
+	}
+}
+
+// WithSyncTimeout sets how long a subject or sync request waits for its response
+func WithSyncTimeout(d time.Duration) Opt {
+	return func(m *Manager) {
+		m.syncTimeout = d
+	}
+}
+
+// WithPeerScore sets the scores of the peers relaying gossip messages,
+// which should be the blacklist of the pubsub to refuse the blacklisted peers
+func WithPeerScore(score *voter.PeerScore) Opt {
+	return func(m *Manager) {
+		m.peerScore = score
+	}
 }
 
 // NewManager ...
@@ -49,9 +111,11 @@ func NewManager(
 	dht *dht.IpfsDHT,
 	lc *localContext.Context,
 	zkVerificationKey string,
+	opts ...Opt,
 ) (*Manager, error) {
 	// Discovery
 	rd := routingDiscovery.NewRoutingDiscovery(dht)
+	ctx, cancel := context.WithCancel(*lc.Ctx)
 
 	m := &Manager{
 		ps:                pubsub,
@@ -61,19 +125,31 @@ func NewManager(
 		providers:         make(map[peer.ID]string),
 		subjectProtocolCh: make(chan []*subject.Subject, 10),
 		voters:            make(map[subject.HashHex]*voter.Voter),
-		chAnnounce:        make(chan bool),
+		peerScore:         voter.NewPeerScore(lc.Host),
 		zkVerificationKey: zkVerificationKey,
+		credentials:       make(map[string]*issuedCredential),
 		idLock:            sync.Mutex{},
 		ballotLock:        sync.Mutex{},
+		syncInterval:      SYNC_INTERVAL,
+		syncTimeout:       SYNC_TIMEOUT,
+		ctx:               ctx,
+		cancel:            cancel,
 	}
-	m.subjProtocol = pro.NewProtocol(pro.SubjectProtocolType, lc)
-	m.idProtocol = pro.NewProtocol(pro.IdentityProtocolType, lc)
-	m.ballotProtocol = pro.NewProtocol(pro.BallotProtocolType, lc)
+	for _, opt := range opts {
+		opt(m)
+	}
+	m.subjProtocol = pro.NewSubjectProtocol(lc)
+	m.syncProtocol = pro.NewSyncProtocol(lc, m.handleSync)
 
-	go m.announce()
-	m.loadDB()
+	err := m.loadIssuer()
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	m.loadCredentials()
 
-	go m.syncSubjectWorker()
+	m.loadDB()
+	m.applyRevocations()
 
 	return m, nil
 }
@@ -88,24 +164,47 @@ func finally() {
 //
 // vote/identity function
 //
-// Propose a new subject
-func (m *Manager) Propose(title string, description string, identityCommitmentHex string) error {
+// Propose a new subject, the created subject is returned
+func (m *Manager) Propose(title string, description string, identityCommitmentHex string, opts ...subject.Opt) (*subject.Subject, error) {
 	defer finally()
 
 	utils.LogInfof("Propose, title:%v, desc:%v, id:%v", title, description, identityCommitmentHex)
 	if 0 == len(title) || 0 == len(identityCommitmentHex) {
 		utils.LogErrorf("Invalid input")
-		return fmt.Errorf("invalid input")
+		return nil, fmt.Errorf("invalid input")
 	}
 
-	voter, err := m.propose(title, description, identityCommitmentHex)
+	identity := id.NewIdentity(identityCommitmentHex)
+	if nil == identity {
+		utils.LogErrorf("Invalid identity commitment, %v", identityCommitmentHex)
+		return nil, fmt.Errorf("invalid identity commitment")
+	}
+	sub := subject.NewSubject(title, description, identity, opts...)
+	if err := sub.CheckOptions(); err != nil {
+		utils.LogErrorf("Invalid options, %v", err)
+		return nil, fmt.Errorf("invalid options, %w", err)
+	}
+	if 0 != sub.CloseAt && sub.CloseAt <= time.Now().Unix() {
+		utils.LogErrorf("Invalid deadline, %v", sub.CloseAt)
+		return nil, fmt.Errorf("deadline has already passed")
+	}
+	if 0 != sub.OpenAt && 0 != sub.CloseAt && sub.CloseAt <= sub.OpenAt {
+		utils.LogErrorf("Invalid voting period, %v-%v", sub.OpenAt, sub.CloseAt)
+		return nil, fmt.Errorf("deadline must be later than the opening time")
+	}
+	if sub.GetTreeLevel() != subject.DefaultTreeLevel && 0 == len(sub.VerificationKey) {
+		utils.LogErrorf("No verification key for tree level %d", sub.TreeLevel)
+		return nil, fmt.Errorf("verification key is required for tree level %d", sub.TreeLevel)
+	}
+
+	voter, err := m.propose(title, description, identityCommitmentHex, opts...)
 	if err != nil {
 		utils.LogErrorf("Propose error, %v", err)
-		return err
+		return nil, err
 	}
 	m.saveSubjects()
 	m.saveSubjectContent(*voter.GetSubject().HashHex())
-	return nil
+	return voter.GetSubject(), nil
 }
 
 // Vote ...
@@ -115,26 +214,28 @@ func (m *Manager) Vote(subjectHashHex string, proof string) error {
 }
 
 // Open ...
-func (m *Manager) Open(subjectHashHex string) (int, int) {
+// return the number of votes of each option of the subject
+func (m *Manager) Open(subjectHashHex string) ([]int, error) {
 	defer finally()
 
 	utils.LogInfof("Open subject: %v", subjectHashHex)
-	voter, ok := m.voters[subject.HashHex(utils.Remove0x(subjectHashHex))]
+	voter, ok := m.getVoter(subject.HashHex(utils.Remove0x(subjectHashHex)))
 	if !ok {
 		utils.LogErrorf("Can't get voter with subject hash: %v", subject.HashHex(utils.Remove0x(subjectHashHex)))
-		return -1, -1
+		return nil, fmt.Errorf("%w, %v", ErrSubjectNotFound, subject.HashHex(utils.Remove0x(subjectHashHex)))
 	}
-	return voter.Open()
+	return voter.Open(), nil
 }
 
 // InsertIdentity ...
-func (m *Manager) InsertIdentity(subjectHashHex string, identityCommitmentHex string) error {
+func (m *Manager) InsertIdentity(subjectHashHex string, identityCommitmentHex string, admission string) error {
 	defer finally()
-	return m.insertIdentity(subjectHashHex, identityCommitmentHex, true)
+	return m.insertIdentity(subjectHashHex, identityCommitmentHex, admission, true)
 }
 
 // OverwriteIdentities ...
-func (m *Manager) OverwriteIdentities(subjectHashHex string, identitySet []string) error {
+// admissions are in the same order as identitySet, nil if the subject doesn't require them.
+func (m *Manager) OverwriteIdentities(subjectHashHex string, identitySet []string, admissions []string) error {
 	defer finally()
 
 	utils.LogInfof("overwrite, subject:%s", subjectHashHex)
@@ -143,10 +244,10 @@ func (m *Manager) OverwriteIdentities(subjectHashHex string, identitySet []strin
 		return fmt.Errorf("invalid input")
 	}
 	subjHex := subject.HashHex(utils.Remove0x(subjectHashHex))
-	voter, ok := m.voters[subjHex]
+	voter, ok := m.getVoter(subjHex)
 	if !ok {
 		utils.LogErrorf("can't get voter with subject hash:%v", subjHex)
-		return fmt.Errorf("can't get voter with subject hash:%v", subjHex)
+		return fmt.Errorf("%w, %v", ErrSubjectNotFound, subjHex)
 	}
 
 	// Convert to Identity
@@ -155,7 +256,7 @@ func (m *Manager) OverwriteIdentities(subjectHashHex string, identitySet []strin
 		set = append(set, id.NewIdentity(idStr))
 	}
 
-	_, err := voter.OverwriteIds(set)
+	_, err := voter.OverwriteIds(set, admissions)
 	if nil != err {
 		utils.LogErrorf("identity pool registration error, %v", err.Error())
 		return err
@@ -165,8 +266,34 @@ func (m *Manager) OverwriteIdentities(subjectHashHex string, identitySet []strin
 	return nil
 }
 
-// Join an existing subject
-func (m *Manager) Join(subjectHashHex string, identityCommitmentHex string) error {
+// Admit issues the admission of an identity to a subject proposed by this node.
+// The admission is given to the joiner, who joins the subject with it.
+// Subjects which issue credentials only accept them, so the admission is a credential in JWT which never expires.
+func (m *Manager) Admit(subjectHashHex string, identityCommitmentHex string) (string, error) {
+	defer finally()
+
+	utils.LogInfof("Admit, subject:%s, id:%s", subjectHashHex, identityCommitmentHex)
+	identity := id.NewIdentity(identityCommitmentHex)
+	if nil == identity || !identity.IsValid() {
+		utils.LogErrorf("Invalid identity commitment, %v", identityCommitmentHex)
+		return "", fmt.Errorf("invalid identity commitment")
+	}
+	sub := m.Cache.GetACreatedSubject(subject.HashHex(utils.Remove0x(subjectHashHex)))
+	if nil == sub {
+		utils.LogErrorf("Admit, not a subject proposed by this node, %v", subjectHashHex)
+		return "", fmt.Errorf("Can NOT find a subject proposed by this node, %s", subjectHashHex)
+	}
+	if 0 != len(sub.Issuer) {
+		return m.IssueCredential(subjectHashHex, identityCommitmentHex, credential.FORMAT_JWT, 0)
+	}
+	return m.admit(sub, identity)
+}
+
+// Join an existing subject with the membership credential issued by its proposer,
+// either a verifiable credential in JSON-LD or JWT, or the admission in hex if the subject doesn't issue credentials.
+// The credential can be empty only if the subject doesn't require one, the proposer joins when it proposes
+// and admits the other identities by Admit, so joiners can't admit themselves on the node of the proposer.
+func (m *Manager) Join(subjectHashHex string, identityCommitmentHex string, presented string) error {
 	defer finally()
 
 	utils.LogInfof("Join, subject:%s, id:%s", subjectHashHex, identityCommitmentHex)
@@ -179,13 +306,50 @@ func (m *Manager) Join(subjectHashHex string, identityCommitmentHex string) erro
 
 	// No need to new a voter if the subjec is created by itself
 	createdSubs := m.Cache.GetCreatedSubjects()
-	if _, ok := createdSubs[subjHex]; ok {
-		return m.insertIdentity(subjectHashHex, identityCommitmentHex, true)
+	if sub, ok := createdSubs[subjHex]; ok {
+		identity := id.NewIdentity(identityCommitmentHex)
+		if nil == identity {
+			utils.LogErrorf("Invalid identity commitment, %v", identityCommitmentHex)
+			return fmt.Errorf("invalid identity commitment")
+		}
+		// Joiners present what the proposer issued by Admit, even on the node of the proposer
+		admission, err := m.admissionOf(sub, identity, presented)
+		if nil == err {
+			err = sub.VerifyAdmission(identity, admission)
+		}
+		if nil != err {
+			utils.LogErrorf("Join, %v", err)
+			return err
+		}
+		err = m.insertIdentity(subjectHashHex, identityCommitmentHex, admission, true)
+		if nil != err {
+			return err
+		}
+		m.publishJoined(subjHex, identity)
+		return nil
 	}
 
 	collectedSubs := m.Cache.GetCollectedSubjects()
 	if sub, ok := collectedSubs[subjHex]; ok {
-		_, err := m.initAVoter(sub, identityCommitmentHex, false)
+		if subject.StateClosed == sub.GetState(time.Now()) {
+			utils.LogErrorf("Join, subject has been closed")
+			return ErrSubjectClosed
+		}
+
+		identity := id.NewIdentity(identityCommitmentHex)
+		if nil == identity {
+			utils.LogErrorf("Invalid identity commitment, %v", identityCommitmentHex)
+			return fmt.Errorf("invalid identity commitment")
+		}
+		admission, err := m.admissionOf(sub, identity, presented)
+		if nil == err {
+			err = sub.VerifyAdmission(identity, admission)
+		}
+		if nil != err {
+			utils.LogErrorf("Join, %v", err)
+			return err
+		}
+		voter, err := m.newVoter(sub)
 		if nil != err {
 			utils.LogErrorf("Join, init voter error: %v", err)
 			return err
@@ -194,25 +358,121 @@ func (m *Manager) Join(subjectHashHex string, identityCommitmentHex string) erro
 		// Sync identities
 		ch, _ := m.SyncIdentities(subjHex)
 
-		// Sync ballots
-		go func(ch chan bool) {
-			<-ch
+		// The identity is inserted after the existing ones, then sync ballots
+		m.spawn(func() {
+			for range ch {
+			}
+
+			err := voter.SubmitIdentity(identity, admission)
+			if err != nil {
+				utils.LogErrorf("Join, submit identity error, %v", err)
+			} else {
+				m.publishJoined(subjHex, identity)
+			}
 
 			finished, err := m.SyncBallots(subjHex)
 			if err != nil {
 				utils.LogErrorf("SyncBallotIndex error, %v", err)
 			}
 
-			<-finished
+			for range finished {
+			}
 			m.saveSubjects()
 			m.saveSubjectContent(subjHex)
-		}(ch)
+		})
 
 		// TODO: return sync error
-		return err
+		return nil
 	}
 
-	return fmt.Errorf("Can NOT find subject, %s", subjectHashHex)
+	return fmt.Errorf("%w, %s", ErrSubjectNotFound, subjectHashHex)
+}
+
+// Export the voting state of a subject to an archive
+func (m *Manager) Export(subjectHashHex string) ([]byte, error) {
+	defer finally()
+
+	utils.LogInfof("Export, subject:%s", subjectHashHex)
+	subjHex := subject.HashHex(utils.Remove0x(subjectHashHex))
+	voter, ok := m.getVoter(subjHex)
+	if !ok {
+		utils.LogErrorf("Can't get voter with subject hash: %v", subjHex)
+		return nil, fmt.Errorf("%w, %v", ErrSubjectNotFound, subjHex)
+	}
+	return voter.Archive().Byte()
+}
+
+// Import a subject from an archive exported by another node.
+// Every ballot of the archive is verified again.
+func (m *Manager) Import(data []byte) (*subject.Subject, error) {
+	defer finally()
+
+	a, err := archive.NewArchive(data)
+	if err != nil {
+		utils.LogErrorf("Import, %v", err)
+		return nil, err
+	}
+
+	subjHex := *a.Subject.HashHex()
+	utils.LogInfof("Import, subject:%s", subjHex)
+	if _, ok := m.getVoter(subjHex); ok {
+		utils.LogErrorf("Import, subject already existed")
+		return nil, ErrSubjectExisted
+	}
+
+	voter, err := voter.NewVoterFromArchive(a, m.ps, m.Context, m.zkVerificationKey, m.peerScore)
+	if err != nil {
+		utils.LogErrorf("Import, rebuild voter error: %v", err)
+		return nil, err
+	}
+	if !m.addVoter(subjHex, voter) {
+		voter.Leave()
+		return nil, ErrSubjectExisted
+	}
+	m.Cache.InsertColletedSubject(subjHex, a.Subject)
+	m.Events.Publish(event.SubjectImported, subjHex.String(), map[string]interface{}{
+		"identities": len(a.Identities),
+		"ballots":    len(a.Ballots),
+	})
+
+	m.saveSubjects()
+	m.saveSubjectContent(subjHex)
+	return a.Subject, nil
+}
+
+// Audit recomputes the tally of a subject from its identities and ballots,
+// and compares it with the one counted by the voter.
+// The report is signed by this node.
+func (m *Manager) Audit(subjectHashHex string) (*audit.Report, error) {
+	defer finally()
+
+	utils.LogInfof("Audit, subject:%s", subjectHashHex)
+	subjHex := subject.HashHex(utils.Remove0x(subjectHashHex))
+	voter, ok := m.getVoter(subjHex)
+	if !ok {
+		utils.LogErrorf("Can't get voter with subject hash: %v", subjHex)
+		return nil, fmt.Errorf("%w, %v", ErrSubjectNotFound, subjHex)
+	}
+
+	report := audit.Audit(voter.Archive(), m.zkVerificationKey)
+	report.Compare(voter.Open())
+
+	err := report.Sign(m.Host.Peerstore().PrivKey(m.Host.ID()))
+	if err != nil {
+		utils.LogErrorf("Sign audit report error, %v", err)
+		return nil, err
+	}
+	return report, nil
+}
+
+// GetConflicts returns the identity insertions of a subject which disagree with the tree here
+func (m *Manager) GetConflicts(subjectHashHex string) ([]*voter.Conflict, error) {
+	subjHex := subject.HashHex(utils.Remove0x(subjectHashHex))
+	voter, ok := m.getVoter(subjHex)
+	if !ok {
+		return nil, fmt.Errorf("%w, %v", ErrSubjectNotFound, subjHex)
+	}
+	return voter.GetConflicts(), nil
 }
 
 // FindProposers ...
@@ -230,16 +490,26 @@ func (m *Manager) FindProposers() (<-chan peer.AddrInfo, error) {
 
 // SetProvider ...
 func (m *Manager) SetProvider(key peer.ID, value string) {
+	m.providerLock.Lock()
+	defer m.providerLock.Unlock()
 	m.providers[key] = value
 }
 
-// GetProviders ...
+// GetProviders returns a copy of the providers
 func (m *Manager) GetProviders() map[peer.ID]string {
-	return m.providers
+	m.providerLock.RLock()
+	defer m.providerLock.RUnlock()
+	providers := make(map[peer.ID]string, len(m.providers))
+	for k, v := range m.providers {
+		providers[k] = v
+	}
+	return providers
 }
 
 // GetProvider ...
 func (m *Manager) GetProvider(key peer.ID) string {
+	m.providerLock.RLock()
+	defer m.providerLock.RUnlock()
 	return m.providers[key]
 }
 
@@ -262,6 +532,35 @@ func (m *Manager) GetSubjectList() ([]*subject.Subject, error) {
 	return result, nil
 }
 
+// GetSubject ...
+func (m *Manager) GetSubject(subjectHashHex string) (*subject.Subject, error) {
+	subjHex := subject.HashHex(utils.Remove0x(subjectHashHex))
+	if s := m.Cache.GetACreatedSubject(subjHex); nil != s {
+		return s, nil
+	}
+	if s := m.Cache.GetACollectedSubject(subjHex); nil != s {
+		return s, nil
+	}
+	return nil, fmt.Errorf("%w, %s", ErrSubjectNotFound, subjectHashHex)
+}
+
+// GetMembers returns the identity commitments of a subject in the order of the tree
+func (m *Manager) GetMembers(subjectHashHex string) ([]string, error) {
+	defer finally()
+
+	subjHex := subject.HashHex(utils.Remove0x(subjectHashHex))
+	voter, ok := m.getVoter(subjHex)
+	if !ok {
+		return nil, fmt.Errorf("%w, %v", ErrSubjectNotFound, subjHex)
+	}
+	identities := voter.GetAllIdentities()
+	members := make([]string, len(identities))
+	for i, identity := range identities {
+		members[i] = identity.Hex()
+	}
+	return members, nil
+}
+
 // // GetJoinedSubjectTitles ...
 // func (m *Manager) GetJoinedSubjectTitles() []string {
 // 	topics := m.ps.GetTopics()
@@ -275,7 +574,7 @@ func (m *Manager) GetIdentityIndex() map[subject.HashHex][]id.Identity {
 	defer finally()
 
 	index := make(map[subject.HashHex][]id.Identity)
-	for k, v := range m.voters {
+	for k, v := range m.getVoters() {
 		index[k] = v.GetAllIdentities()
 	}
 	return index
@@ -289,10 +588,10 @@ func (m *Manager) GetIdentityPath(
 	[]string, []int, string, error) {
 	defer finally()
 
-	voter, ok := m.voters[subject.HashHex(utils.Remove0x(subjectHashHex))]
+	voter, ok := m.getVoter(subject.HashHex(utils.Remove0x(subjectHashHex)))
 	if !ok {
 		utils.LogWarningf("can't get voter with subject hash:%v", subject.HashHex(utils.Remove0x(subjectHashHex)))
-		return nil, nil, "", fmt.Errorf("can't get voter with subject hash:%v", subject.HashHex(utils.Remove0x(subjectHashHex)))
+		return nil, nil, "", fmt.Errorf("%w, %v", ErrSubjectNotFound, subject.HashHex(utils.Remove0x(subjectHashHex)))
 	}
 	idPaths, idPathIndexes, root, err := voter.GetIdentityPath(*id.NewIdentity(identityCommitmentHex))
 	if err != nil {
@@ -312,7 +611,7 @@ func (m *Manager) GetIdentityPath(
 func (m *Manager) GetVoterIdentities() map[subject.HashHex][]*id.IdPathElement {
 	result := make(map[subject.HashHex][]*id.IdPathElement)
 
-	for k, v := range m.voters {
+	for k, v := range m.getVoters() {
 		result[k] = v.GetAllIds()
 	}
 	return result
@@ -322,7 +621,7 @@ func (m *Manager) GetVoterIdentities() map[subject.HashHex][]*id.IdPathElement {
 func (m *Manager) GetBallotMaps() map[subject.HashHex]ba.Map {
 	result := make(map[subject.HashHex]ba.Map)
 
-	for k, v := range m.voters {
+	for k, v := range m.getVoters() {
 		result[k] = v.GetBallotMap()
 	}
 	return result
@@ -332,15 +631,21 @@ func (m *Manager) GetBallotMaps() map[subject.HashHex]ba.Map {
 // internal functions
 //
 
-func (m *Manager) propose(title string, description string, identityCommitmentHex string) (*voter.Voter, error) {
+func (m *Manager) propose(title string, description string, identityCommitmentHex string, opts ...subject.Opt) (*voter.Voter, error) {
 	// Store the new subject locally
 	identity := id.NewIdentity(identityCommitmentHex)
 	if nil == identity {
 		return nil, fmt.Errorf("Can not get identity object by commitment %v", identityCommitmentHex)
 	}
-	subject := subject.NewSubject(title, description, identity)
-	if _, ok := m.voters[*subject.HashHex()]; ok {
-		return nil, fmt.Errorf("subject already existed")
+	// This node orders the identity insertions of the subjects it proposes,
+	// admits the identities with its key and issues their credentials
+	opts = append(opts,
+		subject.WithSequencer(m.Host.ID().Pretty()),
+		subject.WithProposerKey(m.Host.Peerstore().PubKey(m.Host.ID())),
+		subject.WithIssuer(m.issuerDID))
+	subject := subject.NewSubject(title, description, identity, opts...)
+	if _, ok := m.getVoter(*subject.HashHex()); ok {
+		return nil, ErrSubjectExisted
 	}
 
 	voter, err := m.initAVoter(subject, identityCommitmentHex, true)
@@ -362,10 +667,10 @@ func (m *Manager) silentVote(subjectHashHex string, proof string, silent bool) e
 	}
 
 	subjHex := subject.HashHex(utils.Remove0x(subjectHashHex))
-	voter, ok := m.voters[subjHex]
+	voter, ok := m.getVoter(subjHex)
 	if !ok {
 		utils.LogErrorf("Can't get voter with subject hash: %v", subjHex)
-		return fmt.Errorf("Can't get voter with subject hash: %v", subjHex)
+		return fmt.Errorf("%w, %v", ErrSubjectNotFound, subjHex)
 	}
 	ballot, err := ba.NewBallot(proof)
 	if err != nil {
@@ -381,19 +686,38 @@ func (m *Manager) silentVote(subjectHashHex string, proof string, silent bool) e
 	return nil
 }
 
-func (m *Manager) insertIdentity(subjectHashHex string, identityCommitmentHex string, publish bool) error {
+func (m *Manager) restoreBallot(subjectHashHex string, proof string) error {
+	subjHex := subject.HashHex(utils.Remove0x(subjectHashHex))
+	voter, ok := m.getVoter(subjHex)
+	if !ok {
+		return fmt.Errorf("%w, %v", ErrSubjectNotFound, subjHex)
+	}
+	ballot, err := ba.NewBallot(proof)
+	if err != nil {
+		return err
+	}
+	return voter.Restore(ballot)
+}
+
+func (m *Manager) insertIdentity(subjectHashHex string, identityCommitmentHex string, admission string, publish bool) error {
 	utils.LogInfof("Insert, subject:%s, id:%v", subjectHashHex, identityCommitmentHex)
 	if 0 == len(subjectHashHex) || 0 == len(identityCommitmentHex) {
 		utils.LogWarningf("Invalid input")
 		return fmt.Errorf("invalid input")
 	}
 
-	voter, ok := m.voters[subject.HashHex(utils.Remove0x(subjectHashHex))]
+	voter, ok := m.getVoter(subject.HashHex(utils.Remove0x(subjectHashHex)))
 	if !ok {
-		return fmt.Errorf("Can't get voter with subject hash: %v", subject.HashHex(utils.Remove0x(subjectHashHex)))
+		return fmt.Errorf("%w, %v", ErrSubjectNotFound, subject.HashHex(utils.Remove0x(subjectHashHex)))
 	}
 
-	_, err := voter.InsertIdentity(id.NewIdentity(identityCommitmentHex), publish)
+	identity := id.NewIdentity(identityCommitmentHex)
+	var err error
+	if publish {
+		err = voter.SubmitIdentity(identity, admission)
+	} else {
+		_, err = voter.InsertIdentity(identity, admission, false)
+	}
 	if nil != err {
 		utils.LogWarningf("identity pool registration error, %v", err.Error())
 		return err
@@ -404,37 +728,68 @@ func (m *Manager) insertIdentity(subjectHashHex string, identityCommitmentHex st
 }
 
 func (m *Manager) initAVoter(sub *subject.Subject, idc string, publish bool) (*voter.Voter, error) {
-	// New a voter including proposal/id tree
-	utils.LogDebug("New a voter")
-	voter, err := voter.NewVoter(sub, m.ps, m.Context, m.zkVerificationKey)
+	voter, err := m.newVoter(sub)
 	if nil != err {
 		return nil, err
 	}
 
 	utils.LogInfof("Register, subject:%s, id:%v", sub.HashHex().String(), idc)
 	identity := id.NewIdentity(idc)
-	// Insert idenitty to identity pool
-	_, err = voter.InsertIdentity(identity, publish)
+	// The proposer admits itself
+	admission, err := m.admit(sub, identity)
+	if nil == err {
+		// Insert idenitty to identity pool
+		if publish {
+			err = voter.SubmitIdentity(identity, admission)
+		} else {
+			_, err = voter.InsertIdentity(identity, admission, false)
+		}
+	}
 	if nil != err {
+		voter.Leave()
+		m.removeVoter(*sub.HashHex())
 		return nil, err
 	}
+	return voter, nil
+}
 
-	m.voters[*sub.HashHex()] = voter
+func (m *Manager) publishJoined(subjHex subject.HashHex, identity *id.Identity) {
+	m.Events.Publish(event.SubjectJoined, subjHex.String(), map[string]interface{}{
+		"identity": identity.Hex(),
+	})
+}
+
+// admit signs the admission of an identity with the key of this node,
+// empty if the subject doesn't require admissions
+func (m *Manager) admit(sub *subject.Subject, identity *id.Identity) (string, error) {
+	if !sub.RequiresAdmission() {
+		return "", nil
+	}
+	return sub.SignAdmission(m.Host.Peerstore().PrivKey(m.Host.ID()), identity)
+}
 
-	if nil != m.chAnnounce {
-		m.chAnnounce <- true
+// newVoter news a voter with an empty identity pool
+func (m *Manager) newVoter(sub *subject.Subject) (*voter.Voter, error) {
+	// New a voter including proposal/id tree
+	utils.LogDebug("New a voter")
+	voter, err := voter.NewVoter(sub, m.ps, m.Context, m.zkVerificationKey, m.peerScore)
+	if nil != err {
+		return nil, err
+	}
+	if !m.addVoter(*sub.HashHex(), voter) {
+		voter.Leave()
+		return nil, ErrSubjectExisted
 	}
 
-	return m.voters[*sub.HashHex()], nil
+	m.announceOnce.Do(func() {
+		m.spawn(func() { m.announce() })
+	})
+	return voter, nil
 }
 
 // Announce that the node has a proposal to be discovered
 func (m *Manager) announce() error {
-	<-m.chAnnounce
-	close(m.chAnnounce)
-	m.chAnnounce = nil
-
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(m.ctx, 30*time.Second)
 	defer cancel()
 
 	// TODO: Check if the voter is ready for announcement
@@ -445,3 +800,39 @@ func (m *Manager) announce() error {
 	}
 	return err
 }
+
+// getVoter returns the voter of a subject
+func (m *Manager) getVoter(subjHex subject.HashHex) (*voter.Voter, bool) {
+	m.votersLock.RLock()
+	defer m.votersLock.RUnlock()
+	v, ok := m.voters[subjHex]
+	return v, ok
+}
+
+// getVoters returns a copy of the voters by their subjects
+func (m *Manager) getVoters() map[subject.HashHex]*voter.Voter {
+	m.votersLock.RLock()
+	defer m.votersLock.RUnlock()
+	voters := make(map[subject.HashHex]*voter.Voter, len(m.voters))
+	for k, v := range m.voters {
+		voters[k] = v
+	}
+	return voters
+}
+
+// addVoter returns false if the subject has a voter already
+func (m *Manager) addVoter(subjHex subject.HashHex, v *voter.Voter) bool {
+	m.votersLock.Lock()
+	defer m.votersLock.Unlock()
+	if _, ok := m.voters[subjHex]; ok {
+		return false
+	}
+	m.voters[subjHex] = v
+	return true
+}
+
+func (m *Manager) removeVoter(subjHex subject.HashHex) {
+	m.votersLock.Lock()
+	defer m.votersLock.Unlock()
+	delete(m.voters, subjHex)
+}
@@ -0,0 +1,225 @@
+package manager
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	ic "github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/unitychain/zkvote-node/zkvote/common/utils"
+	"github.com/unitychain/zkvote-node/zkvote/model/credential"
+	id "github.com/unitychain/zkvote-node/zkvote/model/identity"
+	"github.com/unitychain/zkvote-node/zkvote/model/subject"
+)
+
+const KEY_ISSUER = "issuer"
+const KEY_CREDENTIALS = "credentials"
+
+// issuedCredential is a credential issued by this node, by its id
+type issuedCredential struct {
+	Membership *credential.Membership `json:"membership"`
+	Revoked    bool                   `json:"revoked"`
+	ExpiresAt  int64                  `json:"expiresAt,omitempty"`
+}
+
+// GetDID returns the DID which issues the membership credentials of the subjects proposed by this node
+func (m *Manager) GetDID() string {
+	return m.issuerDID
+}
+
+// IssueCredential issues a membership credential of a subject proposed by this node.
+// format is credential.FORMAT_JSONLD or credential.FORMAT_JWT, expiresAt is in unix time and 0 for never.
+func (m *Manager) IssueCredential(subjectHashHex string, identityCommitmentHex string, format string, expiresAt int64) (string, error) {
+	defer finally()
+
+	utils.LogInfof("Issue credential, subject:%s, id:%s", subjectHashHex, identityCommitmentHex)
+	subjHex := subject.HashHex(utils.Remove0x(subjectHashHex))
+	sub := m.Cache.GetACreatedSubject(subjHex)
+	if nil == sub || sub.Issuer != m.issuerDID {
+		utils.LogErrorf("Issue credential, not a subject issued by this node, %v", subjectHashHex)
+		return "", fmt.Errorf("Can NOT find a subject issued by this node, %s", subjectHashHex)
+	}
+	if 0 != expiresAt && expiresAt <= time.Now().Unix() {
+		return "", fmt.Errorf("expiration has already passed")
+	}
+	identity := id.NewIdentity(identityCommitmentHex)
+	if nil == identity || !identity.IsValid() {
+		utils.LogErrorf("Invalid identity commitment, %v", identityCommitmentHex)
+		return "", fmt.Errorf("invalid identity commitment")
+	}
+	admission, err := m.admit(sub, identity)
+	if err != nil {
+		return "", err
+	}
+
+	membership := &credential.Membership{
+		SubjectHash:        subjHex.String(),
+		IdentityCommitment: identity.Hex(),
+		Admission:          admission,
+	}
+	c := credential.NewCredential(m.issuerDID, membership, expiresAt)
+	encoded, err := c.Encode(m.issuerKey, format)
+	if err != nil {
+		utils.LogErrorf("Issue credential error, %v", err)
+		return "", err
+	}
+
+	m.credentialLock.Lock()
+	defer m.credentialLock.Unlock()
+	issued := &issuedCredential{Membership: membership, ExpiresAt: expiresAt}
+	m.credentials[c.ID] = issued
+	m.expire(issued)
+	err = m.save(KEY_CREDENTIALS, m.credentials)
+	if err != nil {
+		return "", err
+	}
+	return encoded, nil
+}
+
+// RevokeCredential revokes a credential issued by this node.
+// The identity of the credential can't be inserted anymore, but stays in the tree if it has been inserted.
+func (m *Manager) RevokeCredential(credentialID string) error {
+	defer finally()
+
+	utils.LogInfof("Revoke credential %s", credentialID)
+	m.credentialLock.Lock()
+	defer m.credentialLock.Unlock()
+
+	c, ok := m.credentials[credentialID]
+	if !ok {
+		return fmt.Errorf("Can NOT find a credential issued by this node, %s", credentialID)
+	}
+	c.Revoked = true
+	m.revoke(c.Membership)
+	return m.save(KEY_CREDENTIALS, m.credentials)
+}
+
+//
+// internal functions
+//
+
+// admissionOf returns the admission presented by a joiner, which is a membership credential
+// or, if the subject doesn't issue credentials, an admission in hex.
+// A bare admission would skip the expiration and revocation of its credential.
+func (m *Manager) admissionOf(sub *subject.Subject, identity *id.Identity, presented string) (string, error) {
+	if 0 == len(presented) || nil == utils.CheckHex(presented) {
+		if 0 != len(sub.Issuer) {
+			return "", fmt.Errorf("subject requires a membership credential")
+		}
+		return presented, nil
+	}
+	return m.verifyCredential(sub, identity, presented)
+}
+
+// verifyCredential checks a membership credential in JSON-LD or JWT and returns the admission it carries
+func (m *Manager) verifyCredential(sub *subject.Subject, identity *id.Identity, data string) (string, error) {
+	if 0 == len(sub.Issuer) {
+		return "", fmt.Errorf("subject doesn't accept credentials")
+	}
+	c, err := credential.Parse(data)
+	if err != nil {
+		return "", err
+	}
+	if c.Issuer != sub.Issuer {
+		return "", fmt.Errorf("credential isn't issued by the proposer of the subject")
+	}
+	err = c.CheckExpiration(time.Now())
+	if err != nil {
+		return "", err
+	}
+	if c.CredentialSubject.SubjectHash != sub.HashHex().String() {
+		return "", fmt.Errorf("credential is for another subject")
+	}
+	holder := id.NewIdentity(c.CredentialSubject.IdentityCommitment)
+	if nil == holder || !holder.Equal(identity) {
+		return "", fmt.Errorf("credential is for another identity commitment")
+	}
+
+	m.credentialLock.Lock()
+	defer m.credentialLock.Unlock()
+	if issued, ok := m.credentials[c.ID]; ok && issued.Revoked {
+		return "", fmt.Errorf("credential has been revoked")
+	}
+	return c.CredentialSubject.Admission, nil
+}
+
+// loadIssuer loads the key which issues credentials, a new one is generated for the first time
+func (m *Manager) loadIssuer() error {
+	var prvKey ic.PrivKey
+	value, err := m.Store.GetLocal(KEY_ISSUER)
+	if nil == err && 0 != len(value) {
+		b, err := hex.DecodeString(value)
+		if err != nil {
+			return err
+		}
+		prvKey, err = ic.UnmarshalPrivateKey(b)
+		if err != nil {
+			return err
+		}
+	} else {
+		prvKey, _, err = ic.GenerateKeyPair(ic.Ed25519, 0)
+		if err != nil {
+			return err
+		}
+		b, err := ic.MarshalPrivateKey(prvKey)
+		if err != nil {
+			return err
+		}
+		err = m.Store.PutLocal(KEY_ISSUER, hex.EncodeToString(b))
+		if err != nil {
+			return err
+		}
+	}
+
+	did, err := credential.NewDIDKey(prvKey.GetPublic())
+	if err != nil {
+		return err
+	}
+	m.issuerKey = prvKey
+	m.issuerDID = did
+	utils.LogInfof("Issuer DID: %v", did)
+	return nil
+}
+
+// loadCredentials loads the credentials issued by this node
+func (m *Manager) loadCredentials() {
+	value, err := m.Store.GetLocal(KEY_CREDENTIALS)
+	if err != nil || 0 == len(value) {
+		return
+	}
+	err = json.Unmarshal([]byte(value), &m.credentials)
+	if err != nil {
+		utils.LogWarningf("unmarshal credentials error, %v", err)
+	}
+}
+
+// applyRevocations refuses the identities of the revoked or expired credentials
+func (m *Manager) applyRevocations() {
+	m.credentialLock.Lock()
+	defer m.credentialLock.Unlock()
+	for _, c := range m.credentials {
+		if c.Revoked {
+			m.revoke(c.Membership)
+		}
+		m.expire(c)
+	}
+}
+
+func (m *Manager) revoke(membership *credential.Membership) {
+	voter, ok := m.getVoter(subject.HashHex(membership.SubjectHash))
+	if !ok {
+		return
+	}
+	voter.Revoke(id.NewIdentity(membership.IdentityCommitment))
+}
+
+// expire refuses the identity of a credential once it expires,
+// this node sequences the subject so an admission taken out of the credential can't be inserted later
+func (m *Manager) expire(c *issuedCredential) {
+	voter, ok := m.getVoter(subject.HashHex(c.Membership.SubjectHash))
+	if !ok {
+		return
+	}
+	voter.ExpireAt(id.NewIdentity(c.Membership.IdentityCommitment), c.ExpiresAt)
+}
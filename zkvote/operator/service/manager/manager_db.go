@@ -12,9 +12,10 @@ import (
 )
 
 type storeObject struct {
-	Subject   subject.Subject `json:"subject"`
-	Ids       []id.Identity   `json:"ids"`
-	BallotMap ba.Map          `json:"ballots"`
+	Subject    subject.Subject `json:"subject"`
+	Ids        []id.Identity   `json:"ids"`
+	Admissions []string        `json:"admissions,omitempty"` // in the same order as Ids
+	BallotMap  ba.Map          `json:"ballots"`
 }
 
 func (m *Manager) save(key string, v interface{}) error {
@@ -34,9 +35,10 @@ func (m *Manager) save(key string, v interface{}) error {
 }
 
 func (m *Manager) saveSubjects() error {
-	subs := make([]subject.HashHex, len(m.voters))
+	voters := m.getVoters()
+	subs := make([]subject.HashHex, len(voters))
 	i := 0
-	for k := range m.voters {
+	for k := range voters {
 		subs[i] = k
 		i++
 	}
@@ -45,7 +47,7 @@ func (m *Manager) saveSubjects() error {
 }
 
 func (m *Manager) saveSubjectContent(subHex subject.HashHex) error {
-	voter, ok := m.voters[subHex]
+	voter, ok := m.getVoter(subHex)
 	if !ok {
 		return fmt.Errorf("Can't get voter with subject hash: %v", subHex)
 	}
@@ -58,6 +60,9 @@ func (m *Manager) saveSubjectContent(subHex subject.HashHex) error {
 		Ids:       ids,
 		BallotMap: ballotMap,
 	}
+	if subj.RequiresAdmission() {
+		s.Admissions = voter.GetAdmissions()
+	}
 	return m.save(subHex.Hash().Hex().String(), s)
 }
 
@@ -108,24 +113,40 @@ func (m *Manager) loadDB() {
 			continue
 		}
 
-		m.propose(obj.Subject.GetTitle(), obj.Subject.GetDescription(), obj.Subject.GetProposer().String())
+		sub := obj.Subject
+		voter, err := m.newVoter(&sub)
+		if err != nil {
+			utils.LogWarningf("new voter error, %v", err)
+			continue
+		}
+		if 0 == len(sub.Sequencer) || sub.Sequencer == m.Host.ID().Pretty() {
+			m.Cache.InsertCreatedSubject(*sub.HashHex(), &sub)
+		} else {
+			m.Cache.InsertColletedSubject(*sub.HashHex(), &sub)
+		}
 
-		for _, id := range obj.Ids {
-			if id.Equal(obj.Subject.GetProposer()) {
-				continue
-			}
-			m.insertIdentity(utils.Remove0x(obj.Subject.HashHex().String()), id.String(), true)
+		// The identities are stored in the order of the tree
+		ids := make([]*id.Identity, len(obj.Ids))
+		for i := range obj.Ids {
+			ids[i] = &obj.Ids[i]
+		}
+		_, err = voter.OverwriteIds(ids, obj.Admissions)
+		if err != nil {
+			utils.LogWarningf("restore identities error, %v", err)
 		}
 
-		go func() {
+		m.spawn(func() {
 			for _, b := range obj.BallotMap {
 				jStr, err := b.JSON()
 				if err != nil {
 					utils.LogWarningf("get json ballot error, %v", err)
 					break
 				}
-				m.silentVote(utils.Remove0x(obj.Subject.HashHex().String()), jStr, true)
+				err = m.restoreBallot(utils.Remove0x(sub.HashHex().String()), jStr)
+				if err != nil {
+					utils.LogWarningf("restore ballot error, %v", err)
+				}
 			}
-		}()
+		})
 	}
 }
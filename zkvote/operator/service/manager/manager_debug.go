@@ -0,0 +1,79 @@
+package manager
+
+import (
+	"fmt"
+	"sort"
+	"sync/atomic"
+
+	"github.com/unitychain/zkvote-node/zkvote/common/utils"
+	"github.com/unitychain/zkvote-node/zkvote/model/subject"
+	"github.com/unitychain/zkvote-node/zkvote/operator/service/manager/voter"
+)
+
+// SubjectDebug is the state of a subject on this node
+type SubjectDebug struct {
+	Subject            map[string]interface{} `json:"subject"`
+	Created            bool                   `json:"created"`
+	Joined             bool                   `json:"joined"`
+	Root               string                 `json:"root,omitempty"`
+	Identities         []string               `json:"identities"`
+	Nullifiers         []string               `json:"nullifiers"` // of the accepted ballots
+	Tally              []int                  `json:"tally"`
+	IdentityTopicPeers []string               `json:"identityTopicPeers"`
+	VoteTopicPeers     []string               `json:"voteTopicPeers"`
+	Conflicts          []*voter.Conflict      `json:"conflicts"`
+}
+
+// GetSubjectDebug returns the state of a created, collected or joined subject
+func (m *Manager) GetSubjectDebug(subjectHashHex string) (*SubjectDebug, error) {
+	defer finally()
+
+	subjHex := subject.HashHex(utils.Remove0x(subjectHashHex))
+	debug := &SubjectDebug{
+		Created:            nil != m.Cache.GetACreatedSubject(subjHex),
+		Identities:         make([]string, 0),
+		Nullifiers:         make([]string, 0),
+		Tally:              make([]int, 0),
+		IdentityTopicPeers: make([]string, 0),
+		VoteTopicPeers:     make([]string, 0),
+		Conflicts:          make([]*voter.Conflict, 0),
+	}
+	s, err := m.GetSubject(subjHex.String())
+	if nil == err {
+		debug.Subject = s.JSON()
+	}
+
+	v, ok := m.getVoter(subjHex)
+	if !ok {
+		if nil == debug.Subject {
+			return nil, fmt.Errorf("%w, %v", ErrSubjectNotFound, subjHex)
+		}
+		return debug, nil
+	}
+	debug.Joined = true
+	if nil == debug.Subject {
+		debug.Subject = v.GetSubject().JSON()
+	}
+	debug.Root = v.GetSyncState().Root
+	for _, identity := range v.GetAllIdentities() {
+		debug.Identities = append(debug.Identities, identity.Hex())
+	}
+	for nullifier := range v.GetBallotMap() {
+		debug.Nullifiers = append(debug.Nullifiers, string(nullifier))
+	}
+	sort.Strings(debug.Nullifiers)
+	debug.Tally = v.Open()
+	for _, p := range m.ps.ListPeers(v.GetIdentitySub().Topic()) {
+		debug.IdentityTopicPeers = append(debug.IdentityTopicPeers, p.String())
+	}
+	for _, p := range m.ps.ListPeers(v.GetVoteSub().Topic()) {
+		debug.VoteTopicPeers = append(debug.VoteTopicPeers, p.String())
+	}
+	debug.Conflicts = append(debug.Conflicts, v.GetConflicts()...)
+	return debug, nil
+}
+
+// GetPendingSyncs returns the number of identity and ballot syncs with peers in progress
+func (m *Manager) GetPendingSyncs() int {
+	return int(atomic.LoadInt32(&m.pendingSyncs))
+}
@@ -0,0 +1,72 @@
+package manager
+
+import (
+	"context"
+
+	"github.com/unitychain/zkvote-node/zkvote/common/utils"
+)
+
+// Start the background workers of the manager
+func (m *Manager) Start() {
+	m.spawn(m.syncSubjectWorker)
+}
+
+// Stop the background workers, and then save the subjects and stop the voters and the protocols.
+// The in-flight syncs are cancelled, ctx bounds how long they are waited for.
+// The subjects are saved even if the workers aren't done by then, ctx.Err() is returned in that case.
+func (m *Manager) Stop(ctx context.Context) error {
+	m.lifecycleLock.Lock()
+	if m.stopped {
+		m.lifecycleLock.Unlock()
+		return nil
+	}
+	m.stopped = true
+	m.lifecycleLock.Unlock()
+	m.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		m.workers.Wait()
+		close(done)
+	}()
+	var err error
+	select {
+	case <-done:
+	case <-ctx.Done():
+		utils.LogWarningf("Stop manager, workers aren't done, %v", ctx.Err())
+		err = ctx.Err()
+	}
+
+	// The voters are stopped first so that nothing changes after they are saved
+	voters := m.getVoters()
+	for _, v := range voters {
+		v.Stop()
+	}
+	if e := m.saveSubjects(); nil != e && nil == err {
+		err = e
+	}
+	for subjHex := range voters {
+		if e := m.saveSubjectContent(subjHex); nil != e && nil == err {
+			err = e
+		}
+	}
+
+	m.subjProtocol.Stop()
+	m.syncProtocol.Stop()
+	return err
+}
+
+// spawn runs fn in a worker which Stop waits for, false if the manager has been stopped
+func (m *Manager) spawn(fn func()) bool {
+	m.lifecycleLock.Lock()
+	defer m.lifecycleLock.Unlock()
+	if m.stopped {
+		return false
+	}
+	m.workers.Add(1)
+	go func() {
+		defer m.workers.Done()
+		fn()
+	}()
+	return true
+}
@@ -1,37 +1,45 @@
 package manager
 
 import (
-	"encoding/json"
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/libp2p/go-libp2p-core/peer"
+
 	"github.com/unitychain/zkvote-node/zkvote/common/utils"
+	ba "github.com/unitychain/zkvote-node/zkvote/model/ballot"
+	pb "github.com/unitychain/zkvote-node/zkvote/model/pb"
 	"github.com/unitychain/zkvote-node/zkvote/model/subject"
 )
 
 func (m *Manager) syncSubjectWorker() {
 	for {
 		m.SyncSubjects()
-		time.Sleep(60 * time.Second)
+		select {
+		case <-m.ctx.Done():
+			return
+		case <-time.After(m.syncInterval):
+		}
 	}
 }
 
-func (m *Manager) waitSubject(ch chan []string) {
-	select {
-	case results := <-ch:
-		for _, ret := range results {
-			var s subject.Subject
-			err := json.Unmarshal([]byte(ret), &s)
-			if err != nil {
-				utils.LogWarningf("Unmarshal error, %v", err)
-				continue
-			}
-			m.Cache.InsertColletedSubject(*s.HashHex(), &s)
-		}
-	case <-time.After(30 * time.Second):
-		utils.LogWarning("waitSubject timeout")
-	}
+// collectSubjects requests the subjects of a peer and collects them
+func (m *Manager) collectSubjects(p peer.ID) {
+	ctx, cancel := context.WithTimeout(m.ctx, m.syncTimeout)
+	defer cancel()
 
-	close(ch)
+	subjects, err := m.subjProtocol.SubmitRequest(ctx, p)
+	if err != nil {
+		utils.LogWarningf("Request subjects from %v error, %v", p, err)
+		return
+	}
+	for _, s := range subjects {
+		m.Cache.InsertColletedSubject(*s.HashHex(), s)
+	}
 }
 
 // SyncSubject ...
@@ -53,9 +61,10 @@ func (m *Manager) SyncSubjects() {
 		utils.LogInfof("found peer, %v", peer)
 		m.Host.Peerstore().AddAddrs(peer.ID, peer.Addrs, 24*time.Hour)
 
-		ch := make(chan []string)
-		m.subjProtocol.SubmitRequest(peer.ID, nil, ch)
-		go m.waitSubject(ch)
+		p := peer.ID
+		if !m.spawn(func() { m.collectSubjects(p) }) {
+			return
+		}
 	}
 }
 
@@ -63,95 +72,246 @@ func (m *Manager) SyncSubjects() {
 // Synchronizing functions
 //
 
-func (m *Manager) waitIdentities(subjHex subject.HashHex, chIDStrSet chan []string, finished chan bool) {
-	defer func() {
-		err := recover()
-		if err != nil {
-			utils.LogWarningf("PANIC: %v", err)
-		}
-		close(chIDStrSet)
-		m.idLock.Unlock()
-	}()
-
-	m.idLock.Lock()
-	select {
-	case idStrSet := <-chIDStrSet:
-		// CAUTION!
-		// Manager needs to overwrite the whole identity pool
-		// to keep the order of the tree the same
-		m.OverwriteIdentities(subjHex.String(), idStrSet)
-	case <-time.After(30 * time.Second):
-		utils.LogWarning("waitIdentities timeout")
-	}
-
-	finished <- true
-}
-
 // TODO: move to voter.go
 // SyncIdentity ...
+// Only the identities missing here are requested from each peer,
+// the returned channel gets a value for each peer and is closed when all are done
 func (m *Manager) SyncIdentities(subjHex subject.HashHex) (chan bool, error) {
 	defer finally()
 
-	voter := m.voters[subjHex]
-	subjHash := subjHex.Hash()
+	voter, ok := m.getVoter(subjHex)
+	if !ok {
+		return nil, fmt.Errorf("%w, %v", ErrSubjectNotFound, subjHex)
+	}
 
 	// Get peers from the same pubsub
 	strTopic := voter.GetIdentitySub().Topic()
 	peers := m.ps.ListPeers(strTopic)
 	utils.LogDebugf("SyncIdentities peers: %v", peers)
 
+	// chPeers is closed once all peers are done
 	chPeers := make(chan bool, len(peers))
-	for _, peer := range peers {
-		ch := make(chan []string)
-		m.idProtocol.SubmitRequest(peer, &subjHash, ch)
-		go m.waitIdentities(subjHash.Hex(), ch, chPeers)
-	}
-	return chPeers, nil
-}
-
-func (m *Manager) waitBallots(subjHex subject.HashHex, chBallotStrSet chan []string, finished chan bool) {
-	defer func() {
-		err := recover()
-		if err != nil {
-			utils.LogWarningf("PANIC: %v", err)
-		}
-		close(chBallotStrSet)
-		m.ballotLock.Unlock()
+	var wg sync.WaitGroup
+	wg.Add(len(peers))
+	go func() {
+		wg.Wait()
+		close(chPeers)
 	}()
+	atomic.AddInt32(&m.pendingSyncs, int32(len(peers)))
+	for _, p := range peers {
+		p := p
+		syncFrom := func() {
+			defer wg.Done()
+			defer atomic.AddInt32(&m.pendingSyncs, -1)
+			defer finally()
 
-	m.ballotLock.Lock()
-
-	select {
-	case ballotStrSet := <-chBallotStrSet:
-		utils.LogDebugf("ballot num: %d", len(ballotStrSet))
-		for _, bs := range ballotStrSet {
-			err := m.silentVote(subjHex.String(), bs, true)
+			m.idLock.Lock()
+			defer m.idLock.Unlock()
+			err := m.syncIdentitiesFrom(p, subjHex)
 			if err != nil {
-				utils.LogErrorf("waitBallots, vote error, %v", err.Error())
+				utils.LogWarningf("SyncIdentities from %v error, %v", p, err)
 			}
+			chPeers <- true
+		}
+		// Stopping, the peer is skipped
+		if !m.spawn(syncFrom) {
+			wg.Done()
+			atomic.AddInt32(&m.pendingSyncs, -1)
 		}
-	case <-time.After(30 * time.Second):
-		utils.LogWarning("waitBallots timeout")
 	}
-
-	finished <- true
+	return chPeers, nil
 }
 
 // SyncBallot ...
+// Only the ballots missing here are requested from each peer,
+// the returned channel gets a value for each peer and is closed when all are done
 func (m *Manager) SyncBallots(subjHex subject.HashHex) (chan bool, error) {
 	defer finally()
 
-	voter := m.voters[subjHex]
-	subjHash := subjHex.Hash()
+	voter, ok := m.getVoter(subjHex)
+	if !ok {
+		return nil, fmt.Errorf("%w, %v", ErrSubjectNotFound, subjHex)
+	}
 	// Get peers from the same pubsub
 	peers := m.ps.ListPeers(voter.GetVoteSub().Topic())
 	utils.LogDebugf("SyncBallots peers: %v", peers)
 
+	// chPeers is closed once all peers are done
 	chPeers := make(chan bool, len(peers))
-	for _, peer := range peers {
-		ch := make(chan []string)
-		m.ballotProtocol.SubmitRequest(peer, &subjHash, ch)
-		go m.waitBallots(subjHash.Hex(), ch, chPeers)
+	var wg sync.WaitGroup
+	wg.Add(len(peers))
+	go func() {
+		wg.Wait()
+		close(chPeers)
+	}()
+	atomic.AddInt32(&m.pendingSyncs, int32(len(peers)))
+	for _, p := range peers {
+		p := p
+		syncFrom := func() {
+			defer wg.Done()
+			defer atomic.AddInt32(&m.pendingSyncs, -1)
+			defer finally()
+
+			m.ballotLock.Lock()
+			defer m.ballotLock.Unlock()
+			err := m.syncBallotsFrom(p, subjHex)
+			if err != nil {
+				utils.LogWarningf("SyncBallots from %v error, %v", p, err)
+			}
+			chPeers <- true
+		}
+		// Stopping, the peer is skipped
+		if !m.spawn(syncFrom) {
+			wg.Done()
+			atomic.AddInt32(&m.pendingSyncs, -1)
+		}
 	}
 	return chPeers, nil
 }
+
+// syncIdentitiesFrom appends the leaves following ours if the peer has more.
+// Leaves of a sequenced subject are only taken from its sequencer.
+// If the trees diverge, the conflict is reported and our tree is kept.
+func (m *Manager) syncIdentitiesFrom(p peer.ID, subjHex subject.HashHex) error {
+	voter, ok := m.getVoter(subjHex)
+	if !ok {
+		return fmt.Errorf("%w, %v", ErrSubjectNotFound, subjHex)
+	}
+	if !voter.SyncsFrom(p) {
+		utils.LogDebugf("peer %v isn't the sequencer of %v", p, subjHex)
+		return nil
+	}
+	remote, err := m.requestSync(p, subjHex, &pb.SyncRequest{Kind: pb.SyncKind_STATE})
+	if err != nil {
+		return err
+	}
+	local := voter.GetSyncState()
+	if isSameRoot(local.Root, remote.Root) {
+		return nil
+	}
+	if int(remote.LeafCount) <= local.LeafCount {
+		utils.LogInfof("peer %v doesn't have more identities, %d/%d", p, remote.LeafCount, local.LeafCount)
+		return nil
+	}
+
+	resp, err := m.requestSync(p, subjHex, &pb.SyncRequest{Kind: pb.SyncKind_LEAVES, From: int32(local.LeafCount), To: remote.LeafCount})
+	if err != nil {
+		return err
+	}
+	if 0 == len(resp.Leaves) {
+		return fmt.Errorf("no leaves from %v", p)
+	}
+	err = voter.AppendLeaves(resp.PrefixRoot, resp.Leaves, resp.Admissions)
+	if err != nil {
+		voter.ReportDivergence(p, local.LeafCount, resp.PrefixRoot, resp.Leaves[0], err.Error())
+		return fmt.Errorf("identities diverge from %v, %w", p, err)
+	}
+	utils.LogInfof("synced %d identities from %v", len(resp.Leaves), p)
+
+	if !isSameRoot(voter.GetSyncState().Root, remote.Root) {
+		utils.LogWarningf("root doesn't match the one of %v after sync", p)
+	}
+	return m.saveSubjectContent(subjHex)
+}
+
+// syncBallotsFrom requests the ballots whose nullifiers are in the buckets different from ours.
+// They're counted by Voter.SyncBallot, even if the subject has closed since.
+func (m *Manager) syncBallotsFrom(p peer.ID, subjHex subject.HashHex) error {
+	voter, ok := m.getVoter(subjHex)
+	if !ok {
+		return fmt.Errorf("%w, %v", ErrSubjectNotFound, subjHex)
+	}
+	remote, err := m.requestSync(p, subjHex, &pb.SyncRequest{Kind: pb.SyncKind_STATE})
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(voter.GetSyncState().BallotDigest, remote.BallotDigest) {
+		return nil
+	}
+
+	buckets := voter.DiffBuckets(remote.BucketDigests)
+	req := &pb.SyncRequest{Kind: pb.SyncKind_NULLIFIERS, Buckets: make([]int32, len(buckets))}
+	for i, b := range buckets {
+		req.Buckets[i] = int32(b)
+	}
+	resp, err := m.requestSync(p, subjHex, req)
+	if err != nil {
+		return err
+	}
+	missing := voter.GetMissingNullifiers(resp.Nullifiers)
+	if 0 == len(missing) {
+		return nil
+	}
+
+	resp, err = m.requestSync(p, subjHex, &pb.SyncRequest{Kind: pb.SyncKind_BALLOTS, Nullifiers: missing})
+	if err != nil {
+		return err
+	}
+	utils.LogDebugf("ballot num: %d", len(resp.Ballots))
+	for _, bs := range resp.Ballots {
+		ballot, err := ba.NewBallot(bs)
+		if nil == err {
+			err = voter.SyncBallot(ballot)
+		}
+		if err != nil {
+			utils.LogErrorf("syncBallotsFrom, vote error, %v", err.Error())
+		}
+	}
+	return m.saveSubjectContent(subjHex)
+}
+
+// requestSync sends a sync request and waits for its response at most syncTimeout
+func (m *Manager) requestSync(p peer.ID, subjHex subject.HashHex, req *pb.SyncRequest) (*pb.SyncResponse, error) {
+	ctx, cancel := context.WithTimeout(m.ctx, m.syncTimeout)
+	defer cancel()
+
+	subjHash := subjHex.Hash()
+	resp, err := m.syncProtocol.SubmitRequest(ctx, p, &subjHash, req)
+	if err != nil {
+		return nil, fmt.Errorf("sync request to %v error, %w", p, err)
+	}
+	return resp, nil
+}
+
+// handleSync serves the sync requests of remote peers
+func (m *Manager) handleSync(subjectHash *subject.Hash, req *pb.SyncRequest, resp *pb.SyncResponse) error {
+	voter, ok := m.getVoter(subjectHash.Hex())
+	if !ok {
+		return fmt.Errorf("Can't get voter with subject hash: %v", subjectHash.Hex())
+	}
+
+	switch req.Kind {
+	case pb.SyncKind_STATE:
+		state := voter.GetSyncState()
+		resp.Root = state.Root
+		resp.LeafCount = int32(state.LeafCount)
+		resp.BallotDigest = state.BallotDigest
+		resp.BucketDigests = state.BucketDigests
+	case pb.SyncKind_LEAVES:
+		prefixRoot, leaves, admissions, err := voter.GetLeaves(int(req.From), int(req.To))
+		if err != nil {
+			return err
+		}
+		resp.PrefixRoot = prefixRoot
+		resp.Leaves = leaves
+		if voter.GetSubject().RequiresAdmission() {
+			resp.Admissions = admissions
+		}
+	case pb.SyncKind_NULLIFIERS:
+		buckets := make([]int, len(req.Buckets))
+		for i, b := range req.Buckets {
+			buckets[i] = int(b)
+		}
+		resp.Nullifiers = voter.GetNullifiers(buckets)
+	case pb.SyncKind_BALLOTS:
+		resp.Ballots = voter.GetBallotsByNullifiers(req.Nullifiers)
+	default:
+		return fmt.Errorf("unknown sync kind %v", req.Kind)
+	}
+	return nil
+}
+
+func isSameRoot(a string, b string) bool {
+	x, y := utils.GetBigIntFromHexString(a), utils.GetBigIntFromHexString(b)
+	return nil != x && nil != y && 0 == x.Cmp(y)
+}
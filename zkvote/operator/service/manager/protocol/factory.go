@@ -1,22 +1,16 @@
 package protocol
 
-import "github.com/unitychain/zkvote-node/zkvote/model/context"
+import localContext "github.com/unitychain/zkvote-node/zkvote/model/context"
 
 type ProtocolType int
 
 const (
-	BallotProtocolType ProtocolType = 1 << iota
-	IdentityProtocolType
-	SubjectProtocolType
+	SubjectProtocolType ProtocolType = 1 << iota
 )
 
 // NewProtocol .
-func NewProtocol(t ProtocolType, context *context.Context) Protocol {
+func NewProtocol(t ProtocolType, context *localContext.Context) Protocol {
 	switch t {
-	case BallotProtocolType:
-		return NewBallotProtocol(context)
-	case IdentityProtocolType:
-		return NewIdentityProtocol(context)
 	case SubjectProtocolType:
 		return NewSubjectProtocol(context)
 	}
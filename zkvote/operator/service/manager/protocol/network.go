@@ -2,43 +2,106 @@ package protocol
 
 import (
 	"context"
-	"log"
+	"fmt"
 	"time"
 
 	ggio "github.com/gogo/protobuf/io"
 	proto "github.com/gogo/protobuf/proto"
-	"github.com/libp2p/go-libp2p-core/helpers"
+	"github.com/libp2p/go-libp2p-core/crypto"
 	"github.com/libp2p/go-libp2p-core/host"
+	"github.com/libp2p/go-libp2p-core/network"
 	"github.com/libp2p/go-libp2p-core/peer"
 	"github.com/libp2p/go-libp2p-core/protocol"
 	"github.com/unitychain/zkvote-node/zkvote/common/utils"
 	pb "github.com/unitychain/zkvote-node/zkvote/model/pb"
 )
 
-// SendProtoMessage helper method - writes a protobuf go data object to a network stream
-// data: reference of protobuf go data object to send (not the object itself)
-// s: network stream to write the data to
-func SendProtoMessage(host host.Host, id peer.ID, p protocol.ID, data proto.Message) bool {
-	s, err := host.NewStream(context.Background(), id, p)
+const (
+	// MAX_MESSAGE_SIZE is the largest request or response read from a stream
+	MAX_MESSAGE_SIZE = 16 << 20
+	// STREAM_TIMEOUT bounds how long a peer can take to send its request and read the response
+	STREAM_TIMEOUT = time.Minute
+)
+
+// message is a protobuf message of the protocols, all of them carry the metadata
+type message interface {
+	proto.Message
+	GetMetadata() *pb.Metadata
+}
+
+// sendRequest signs a request, sends it on a new stream of the protocol and reads the response from the same stream.
+// The response has to be authenticated and carry the id of the request, the stream is reset once ctx is done.
+func sendRequest(ctx context.Context, host host.Host, id peer.ID, p protocol.ID, req message, resp message) error {
+	err := signMessage(host, req, req.GetMetadata())
 	if err != nil {
-		log.Println(err)
-		return false
+		return fmt.Errorf("failed to sign request, %v", err)
 	}
-	writer := ggio.NewFullWriter(s)
-	err = writer.WriteMsg(data)
+	s, err := host.NewStream(ctx, id, p)
 	if err != nil {
-		log.Println(err)
-		s.Reset()
-		return false
+		return err
+	}
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			s.Reset()
+		case <-done:
+		}
+	}()
+
+	err = ggio.NewDelimitedWriter(s).WriteMsg(req)
+	if nil == err {
+		err = s.Close()
+	}
+	if nil == err {
+		err = readMessage(s, resp)
 	}
-	// FullClose closes the stream and waits for the other side to close their half.
-	err = helpers.FullClose(s)
 	if err != nil {
-		log.Println(err)
 		s.Reset()
-		return false
+		if nil != ctx.Err() {
+			return ctx.Err()
+		}
+		return err
+	}
+
+	if !authenticateMessage(resp, resp.GetMetadata(), id) {
+		return fmt.Errorf("failed to authenticate response from %v", id)
+	}
+	if resp.GetMetadata().Id != req.GetMetadata().Id {
+		return fmt.Errorf("response (%v) doesn't match request (%v)", resp.GetMetadata().Id, req.GetMetadata().Id)
 	}
-	return true
+	return nil
+}
+
+// readRequest reads a request from a stream and authenticates it
+func readRequest(s network.Stream, req message) error {
+	err := readMessage(s, req)
+	if err != nil {
+		return err
+	}
+	if !authenticateMessage(req, req.GetMetadata(), s.Conn().RemotePeer()) {
+		return fmt.Errorf("failed to authenticate request")
+	}
+	return nil
+}
+
+// writeResponse signs a response and writes it back on the stream of its request
+func writeResponse(host host.Host, s network.Stream, resp message) error {
+	err := signMessage(host, resp, resp.GetMetadata())
+	if err != nil {
+		return fmt.Errorf("failed to sign response, %v", err)
+	}
+	err = ggio.NewDelimitedWriter(s).WriteMsg(resp)
+	if err != nil {
+		return err
+	}
+	return s.Close()
+}
+
+// readMessage reads a length-delimited message, messages larger than MAX_MESSAGE_SIZE are refused
+func readMessage(s network.Stream, data proto.Message) error {
+	return ggio.NewDelimitedReader(s, MAX_MESSAGE_SIZE).ReadMsg(data)
 }
 
 // NewMetadata helper method - generate message data shared between all node's p2p protocols
@@ -60,3 +123,97 @@ func NewMetadata(host host.Host, messageID string, gossip bool) *pb.Metadata {
 		Id:            messageID,
 		Gossip:        gossip}
 }
+
+// authenticateMessage authenticates an incoming p2p message
+// message: a protobufs go data object
+// data: common p2p message data
+// remotePeer: the peer which sent the message
+func authenticateMessage(message proto.Message, data *pb.Metadata, remotePeer peer.ID) bool {
+	if nil == data {
+		utils.LogWarning("No metadata in message")
+		return false
+	}
+
+	// store a temp ref to signature and remove it from message data
+	sign := data.Sign
+	data.Sign = nil
+
+	// marshall data without the signature to protobufs3 binary format
+	bin, err := proto.Marshal(message)
+
+	// restore sig in message data (for possible future use)
+	data.Sign = sign
+	if err != nil {
+		utils.LogWarningf("failed to marshal pb message, %v", err)
+		return false
+	}
+
+	// restore peer id binary format from base58 encoded node id data
+	peerID, err := peer.IDB58Decode(data.NodeId)
+	if err != nil {
+		utils.LogWarningf("Failed to decode node id from base58, %v", err)
+		return false
+	}
+
+	// messages are not forwarded, so the author has to be the sender
+	if peerID != remotePeer {
+		utils.LogWarningf("Node id (%v) and remote peer (%v) mismatch", peerID, remotePeer)
+		return false
+	}
+
+	// verify the data was authored by the signing peer identified by the public key
+	// and signature included in the message
+	return verifyData(bin, sign, peerID, data.NodePubKey)
+}
+
+// signMessage signs an outgoing p2p message with the key of the host
+// and stores the signature in its metadata
+func signMessage(host host.Host, message proto.Message, data *pb.Metadata) error {
+	data.Sign = nil
+	bin, err := proto.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	key := host.Peerstore().PrivKey(host.ID())
+	sign, err := key.Sign(bin)
+	if err != nil {
+		return err
+	}
+	data.Sign = sign
+	return nil
+}
+
+// verifyData verifies incoming p2p message data integrity
+// data: data to verify
+// signature: author signature provided in the message payload
+// peerID: author peer id from the message payload
+// pubKeyData: author public key from the message payload
+func verifyData(data []byte, signature []byte, peerID peer.ID, pubKeyData []byte) bool {
+	key, err := crypto.UnmarshalPublicKey(pubKeyData)
+	if err != nil {
+		utils.LogWarningf("Failed to extract key from message key data, %v", err)
+		return false
+	}
+
+	// extract node id from the provided public key
+	idFromKey, err := peer.IDFromPublicKey(key)
+	if err != nil {
+		utils.LogWarningf("Failed to extract peer id from public key, %v", err)
+		return false
+	}
+
+	// verify that message author node id matches the provided node public key
+	if idFromKey != peerID {
+		utils.LogWarningf("Node id and provided public key mismatch")
+		return false
+	}
+
+	res, err := key.Verify(data, signature)
+	if err != nil {
+		utils.LogWarningf("Error authenticating data, %v", err)
+		return false
+	}
+
+	return res
+}
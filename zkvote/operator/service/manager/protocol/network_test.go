@@ -0,0 +1,54 @@
+package protocol
+
+import (
+	"context"
+	"testing"
+
+	"github.com/libp2p/go-libp2p"
+	"github.com/libp2p/go-libp2p-core/host"
+	"github.com/stretchr/testify/assert"
+	pb "github.com/unitychain/zkvote-node/zkvote/model/pb"
+)
+
+func newTestHost(t *testing.T) host.Host {
+	h, err := libp2p.New(context.Background(), libp2p.NoListenAddrs)
+	assert.Nil(t, err)
+	return h
+}
+
+func TestAuthenticateMessage(t *testing.T) {
+	h := newTestHost(t)
+	defer h.Close()
+
+	req := &pb.SubjectRequest{Metadata: NewMetadata(h, "id", false), Message: "hello"}
+	assert.Nil(t, signMessage(h, req, req.Metadata))
+	assert.NotEmpty(t, req.Metadata.Sign)
+	assert.True(t, authenticateMessage(req, req.Metadata, h.ID()))
+}
+
+func TestAuthenticateMessage_Tampered(t *testing.T) {
+	h := newTestHost(t)
+	defer h.Close()
+
+	req := &pb.SubjectRequest{Metadata: NewMetadata(h, "id", false), Message: "hello"}
+	assert.Nil(t, signMessage(h, req, req.Metadata))
+
+	req.Message = "forged"
+	assert.False(t, authenticateMessage(req, req.Metadata, h.ID()))
+}
+
+func TestAuthenticateMessage_WrongSender(t *testing.T) {
+	h := newTestHost(t)
+	defer h.Close()
+	other := newTestHost(t)
+	defer other.Close()
+
+	req := &pb.SubjectRequest{Metadata: NewMetadata(h, "id", false), Message: "hello"}
+	assert.Nil(t, signMessage(h, req, req.Metadata))
+	assert.False(t, authenticateMessage(req, req.Metadata, other.ID()))
+
+	// unsigned messages are rejected
+	req.Metadata.Sign = nil
+	assert.False(t, authenticateMessage(req, req.Metadata, h.ID()))
+	assert.False(t, authenticateMessage(req, nil, h.ID()))
+}
@@ -2,13 +2,12 @@ package protocol
 
 import (
 	"github.com/libp2p/go-libp2p-core/network"
-	"github.com/libp2p/go-libp2p-core/peer"
-	"github.com/unitychain/zkvote-node/zkvote/model/subject"
 )
 
+// Protocol is a request/response protocol between peers.
+// A request is sent on a new stream and its response is written back on the same stream.
 type Protocol interface {
 	onRequest(s network.Stream)
-	onResponse(s network.Stream)
 
-	SubmitRequest(peerID peer.ID, subjectHash *subject.Hash, ch chan<- []string) bool
+	Stop()
 }
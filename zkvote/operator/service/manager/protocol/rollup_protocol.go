@@ -0,0 +1,103 @@
+package protocol
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	uuid "github.com/google/uuid"
+	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/unitychain/zkvote-node/zkvote/common/utils"
+	localContext "github.com/unitychain/zkvote-node/zkvote/model/context"
+	pb "github.com/unitychain/zkvote-node/zkvote/model/pb"
+	"github.com/unitychain/zkvote-node/zkvote/model/subject"
+)
+
+// pattern: /protocol-name/version, the response is written back on the stream of the request
+const rollupProtocol = "/rollup/0.0.2"
+
+// RollupHandler handles a rollup proof of a subject and returns the new root of the votes
+type RollupHandler func(subjectHash *subject.Hash, prevRoot string, proof string) (string, error)
+
+// RollupProtocol type
+// A node with a handler accepts rollup proofs, the others can only submit them.
+type RollupProtocol struct {
+	context *localContext.Context
+	handler RollupHandler
+}
+
+// NewRollupProtocol ...
+func NewRollupProtocol(context *localContext.Context, handler RollupHandler) *RollupProtocol {
+	rp := &RollupProtocol{
+		context: context,
+		handler: handler,
+	}
+	rp.context.Host.SetStreamHandler(rollupProtocol, rp.onRequest)
+	return rp
+}
+
+// Stop removes the stream handler, the peers can't request this node anymore
+func (rp *RollupProtocol) Stop() {
+	rp.context.Host.RemoveStreamHandler(rollupProtocol)
+}
+
+// remote peer requests handler
+func (rp *RollupProtocol) onRequest(s network.Stream) {
+	// the peer has STREAM_TIMEOUT to send its request and read the response
+	s.SetDeadline(time.Now().Add(STREAM_TIMEOUT))
+	data := &pb.RollupRequest{}
+	err := readRequest(s, data)
+	if err != nil {
+		s.Reset()
+		utils.LogWarningf("Failed to read rollup request from %s, %v", s.Conn().RemotePeer(), err)
+		return
+	}
+
+	utils.LogInfof("Received rollup request from %s. Message: %s", s.Conn().RemotePeer(), data.Message)
+
+	subjectHash := subject.Hash(data.SubjectHash)
+	var root, errMsg string
+	if nil == rp.handler {
+		errMsg = "not a rollup node"
+	} else {
+		root, err = rp.handler(&subjectHash, data.PrevRoot, data.Proof)
+		if err != nil {
+			errMsg = err.Error()
+		}
+	}
+	resp := &pb.RollupResponse{Metadata: NewMetadata(rp.context.Host, data.Metadata.Id, false),
+		Message: fmt.Sprintf("Rollup response from %s", rp.context.Host.ID()), SubjectHash: subjectHash.Byte(), Root: root, Error: errMsg}
+
+	// send the response back on the same stream
+	err = writeResponse(rp.context.Host, s, resp)
+	if err != nil {
+		s.Reset()
+		utils.LogErrorf("Failed to send rollup response to %s, %v", s.Conn().RemotePeer(), err)
+		return
+	}
+	utils.LogInfof("Rollup response(%v, %v) to %s sent.", root, errMsg, s.Conn().RemotePeer().String())
+}
+
+// SubmitRequest submits a rollup proof of a subject to a rollup node and returns the new root,
+// ctx bounds how long the response is waited for.
+// The error the rollup node rejected the proof with is returned as well.
+func (rp *RollupProtocol) SubmitRequest(ctx context.Context, peerID peer.ID, subjectHash *subject.Hash, prevRoot string, proof string) (string, error) {
+	utils.LogInfof("Sending rollup request to: %s....", peerID)
+
+	req := &pb.RollupRequest{Metadata: NewMetadata(rp.context.Host, uuid.New().String(), false),
+		Message: fmt.Sprintf("Rollup request from %s", rp.context.Host.ID()), SubjectHash: subjectHash.Byte(),
+		PrevRoot: prevRoot, Proof: proof}
+	resp := &pb.RollupResponse{}
+	err := sendRequest(ctx, rp.context.Host, peerID, rollupProtocol, req, resp)
+	if err != nil {
+		return "", err
+	}
+	utils.LogInfof("Received rollup response from %s. Message id:%s. Message: %s.", peerID, resp.Metadata.Id, resp.Message)
+
+	if 0 != len(resp.Error) {
+		return "", errors.New(resp.Error)
+	}
+	return resp.Root, nil
+}
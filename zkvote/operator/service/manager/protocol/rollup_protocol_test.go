@@ -0,0 +1,137 @@
+package protocol
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p"
+	"github.com/libp2p/go-libp2p-core/host"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/stretchr/testify/assert"
+	localContext "github.com/unitychain/zkvote-node/zkvote/model/context"
+	"github.com/unitychain/zkvote-node/zkvote/model/subject"
+)
+
+func newConnectedHosts(t *testing.T) (host.Host, host.Host) {
+	ctx := context.Background()
+	h1, err := libp2p.New(ctx, libp2p.ListenAddrStrings("/ip4/127.0.0.1/tcp/0"))
+	assert.Nil(t, err)
+	h2, err := libp2p.New(ctx, libp2p.ListenAddrStrings("/ip4/127.0.0.1/tcp/0"))
+	assert.Nil(t, err)
+	assert.Nil(t, h2.Connect(ctx, peer.AddrInfo{ID: h1.ID(), Addrs: h1.Addrs()}))
+	return h1, h2
+}
+
+func newTestContext(h host.Host) *localContext.Context {
+	ctx := context.Background()
+	return localContext.NewContext(new(sync.RWMutex), h, nil, nil, &ctx)
+}
+
+func TestRollupProtocol(t *testing.T) {
+	h1, h2 := newConnectedHosts(t)
+	defer h1.Close()
+	defer h2.Close()
+
+	var gotPrevRoot, gotProof string
+	NewRollupProtocol(newTestContext(h1), func(subjectHash *subject.Hash, prevRoot string, proof string) (string, error) {
+		gotPrevRoot, gotProof = prevRoot, proof
+		if "bad" == proof {
+			return "", fmt.Errorf("invalid proof")
+		}
+		return "42", nil
+	})
+	client := NewRollupProtocol(newTestContext(h2), nil)
+
+	subjectHash := subject.Hash([]byte{0x01, 0x02})
+	root, err := client.SubmitRequest(context.Background(), h1.ID(), &subjectHash, "7", "{}")
+	assert.Nil(t, err)
+	assert.Equal(t, "42", root)
+	assert.Equal(t, "7", gotPrevRoot)
+	assert.Equal(t, "{}", gotProof)
+
+	root, err = client.SubmitRequest(context.Background(), h1.ID(), &subjectHash, "7", "bad")
+	assert.EqualError(t, err, "invalid proof")
+	assert.Empty(t, root)
+}
+
+func TestRollupProtocol_NotRollupNode(t *testing.T) {
+	h1, h2 := newConnectedHosts(t)
+	defer h1.Close()
+	defer h2.Close()
+
+	NewRollupProtocol(newTestContext(h1), nil)
+	client := NewRollupProtocol(newTestContext(h2), nil)
+
+	subjectHash := subject.Hash([]byte{0x01, 0x02})
+	_, err := client.SubmitRequest(context.Background(), h1.ID(), &subjectHash, "7", "{}")
+	assert.EqualError(t, err, "not a rollup node")
+}
+
+func TestRollupProtocol_Concurrent(t *testing.T) {
+	h1, h2 := newConnectedHosts(t)
+	defer h1.Close()
+	defer h2.Close()
+
+	NewRollupProtocol(newTestContext(h1), func(subjectHash *subject.Hash, prevRoot string, proof string) (string, error) {
+		return proof, nil
+	})
+	client := NewRollupProtocol(newTestContext(h2), nil)
+
+	// Each response comes back on the stream of its request
+	subjectHash := subject.Hash([]byte{0x01, 0x02})
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		proof := fmt.Sprintf("proof %d", i)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			root, err := client.SubmitRequest(context.Background(), h1.ID(), &subjectHash, "7", proof)
+			assert.Nil(t, err)
+			assert.Equal(t, proof, root)
+		}()
+	}
+	wg.Wait()
+}
+
+func TestRollupProtocol_Timeout(t *testing.T) {
+	h1, h2 := newConnectedHosts(t)
+	defer h1.Close()
+	defer h2.Close()
+
+	release := make(chan struct{})
+	defer close(release)
+	NewRollupProtocol(newTestContext(h1), func(subjectHash *subject.Hash, prevRoot string, proof string) (string, error) {
+		<-release
+		return "42", nil
+	})
+	client := NewRollupProtocol(newTestContext(h2), nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	subjectHash := subject.Hash([]byte{0x01, 0x02})
+	_, err := client.SubmitRequest(ctx, h1.ID(), &subjectHash, "7", "{}")
+	assert.Equal(t, context.DeadlineExceeded, err)
+}
+
+func TestRollupProtocol_Oversized(t *testing.T) {
+	h1, h2 := newConnectedHosts(t)
+	defer h1.Close()
+	defer h2.Close()
+
+	called := false
+	NewRollupProtocol(newTestContext(h1), func(subjectHash *subject.Hash, prevRoot string, proof string) (string, error) {
+		called = true
+		return "42", nil
+	})
+	client := NewRollupProtocol(newTestContext(h2), nil)
+
+	// The peer refuses requests larger than MAX_MESSAGE_SIZE without reading them
+	subjectHash := subject.Hash([]byte{0x01, 0x02})
+	_, err := client.SubmitRequest(context.Background(), h1.ID(), &subjectHash, "7", strings.Repeat("0", MAX_MESSAGE_SIZE))
+	assert.NotNil(t, err)
+	assert.False(t, called)
+}
@@ -1,163 +1,114 @@
 package protocol
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
-	"io/ioutil"
+	"time"
 
 	"github.com/libp2p/go-libp2p-core/network"
 	"github.com/libp2p/go-libp2p-core/peer"
 
-	proto "github.com/gogo/protobuf/proto"
 	uuid "github.com/google/uuid"
 	"github.com/unitychain/zkvote-node/zkvote/common/utils"
-	"github.com/unitychain/zkvote-node/zkvote/model/context"
+	localContext "github.com/unitychain/zkvote-node/zkvote/model/context"
 	"github.com/unitychain/zkvote-node/zkvote/model/identity"
 	pb "github.com/unitychain/zkvote-node/zkvote/model/pb"
 	"github.com/unitychain/zkvote-node/zkvote/model/subject"
 )
 
-// pattern: /protocol-name/request-or-response-message/version
-const subjectRequest = "/subject/req/0.0.1"
-const subjectResponse = "/subject/res/0.0.1"
+// pattern: /protocol-name/version, the response is written back on the stream of the request
+const subjectProtocol = "/subject/0.0.2"
 
 // SubjectProtocol type
 type SubjectProtocol struct {
-	channel  map[peer.ID]chan<- []string
-	context  *context.Context
-	requests map[string]*pb.SubjectRequest // used to access request data from response handlers
+	context *localContext.Context
 }
 
 // NewSubjectProtocol ...
-func NewSubjectProtocol(context *context.Context) Protocol {
+func NewSubjectProtocol(context *localContext.Context) *SubjectProtocol {
 	sp := &SubjectProtocol{
-		context:  context,
-		requests: make(map[string]*pb.SubjectRequest),
+		context: context,
 	}
-	sp.channel = make(map[peer.ID]chan<- []string)
-	sp.context.Host.SetStreamHandler(subjectRequest, sp.onRequest)
-	sp.context.Host.SetStreamHandler(subjectResponse, sp.onResponse)
+	sp.context.Host.SetStreamHandler(subjectProtocol, sp.onRequest)
 	return sp
 }
 
+// Stop removes the stream handler, the peers can't request this node anymore
+func (sp *SubjectProtocol) Stop() {
+	sp.context.Host.RemoveStreamHandler(subjectProtocol)
+}
+
 // remote peer requests handler
 func (sp *SubjectProtocol) onRequest(s network.Stream) {
-
-	// get request data
+	// the peer has STREAM_TIMEOUT to send its request and read the response
+	s.SetDeadline(time.Now().Add(STREAM_TIMEOUT))
 	data := &pb.SubjectRequest{}
-	buf, err := ioutil.ReadAll(s)
+	err := readRequest(s, data)
 	if err != nil {
 		s.Reset()
-		utils.LogErrorf("%v", err)
-		return
-	}
-	s.Close()
-
-	// unmarshal it
-	proto.Unmarshal(buf, data)
-	if err != nil {
-		utils.LogErrorf("%v", err)
+		utils.LogWarningf("Failed to read subject request from %s, %v", s.Conn().RemotePeer(), err)
 		return
 	}
 
 	utils.LogInfof("Received subject request from %s. Message: %s", s.Conn().RemotePeer(), data.Message)
 
-	// generate response message
-	// utils.LogInfof("Sending subject response to %s. Message id: %s...", s.Conn().RemotePeer(), data.Metadata.Id)
-
 	// List created subjects
 	subjects := make([]*pb.Subject, 0)
 	for _, s := range sp.context.Cache.GetCreatedSubjects() {
-		identity := s.GetProposer()
-		subject := &pb.Subject{Title: s.GetTitle(), Description: s.GetDescription(), Proposer: identity.String()}
-		subjects = append(subjects, subject)
+		subjects = append(subjects, toPbSubject(s))
 	}
 	for _, s := range sp.context.Cache.GetCollectedSubjects() {
-		identity := s.GetProposer()
-		subject := &pb.Subject{Title: s.GetTitle(), Description: s.GetDescription(), Proposer: identity.String()}
-		subjects = append(subjects, subject)
+		subjects = append(subjects, toPbSubject(s))
 	}
 	resp := &pb.SubjectResponse{Metadata: NewMetadata(sp.context.Host, data.Metadata.Id, false),
 		Message: fmt.Sprintf("Subject response from %s", sp.context.Host.ID()), Subjects: subjects}
 
-	// send the response
-	ok := SendProtoMessage(sp.context.Host, s.Conn().RemotePeer(), subjectResponse, resp)
-	if ok {
-		utils.LogInfof("Subject response(%v) to %s sent.", subjects, s.Conn().RemotePeer().String())
-	}
-}
-
-// remote ping response handler
-func (sp *SubjectProtocol) onResponse(s network.Stream) {
-	// results := make([]*subject.Subject, 0)
-
-	data := &pb.SubjectResponse{}
-	buf, err := ioutil.ReadAll(s)
+	// send the response back on the same stream
+	err = writeResponse(sp.context.Host, s, resp)
 	if err != nil {
 		s.Reset()
-		utils.LogErrorf("%v", err)
-		return
-	}
-	s.Close()
-
-	// unmarshal it
-	proto.Unmarshal(buf, data)
-	if err != nil {
-		utils.LogErrorf("%v", err)
+		utils.LogErrorf("Failed to send subject response to %s, %v", s.Conn().RemotePeer(), err)
 		return
 	}
-
-	defer func() {
-		err := recover()
-		if err != nil {
-			utils.LogWarningf("panic: %v", err)
-		}
-	}()
-	// Store all topics]
-	var results []string
-	for _, sub := range data.Subjects {
-		identity := identity.NewIdentity(sub.Proposer)
-		subject := subject.NewSubject(sub.Title, sub.Description, identity)
-
-		b, err := json.Marshal(subject)
-		if err != nil {
-			utils.LogWarningf("Marshal failed, %v", err)
-		}
-		results = append(results, string(b))
-	}
-	sp.channel[s.Conn().RemotePeer()] <- results
-
-	// locate request data and remove it if found
-	_, ok := sp.requests[data.Metadata.Id]
-	if ok {
-		// remove request from map as we have processed it here
-		delete(sp.requests, data.Metadata.Id)
-	} else {
-		utils.LogWarning("Failed to locate request data boject for response")
-		return
-	}
-	utils.LogInfof("Received subject response from %s. Message id:%s. Message: %s.", s.Conn().RemotePeer(), data.Metadata.Id, data.Message)
-
+	utils.LogInfof("Subject response(%v) to %s sent.", subjects, s.Conn().RemotePeer().String())
 }
 
-// SubmitRequest ...
-// TODO: use callback instead of channel
-func (sp *SubjectProtocol) SubmitRequest(peerID peer.ID, subjectHash *subject.Hash, ch chan<- []string) bool {
+// SubmitRequest requests the subjects created or collected by a peer,
+// ctx bounds how long the response is waited for.
+func (sp *SubjectProtocol) SubmitRequest(ctx context.Context, peerID peer.ID) ([]*subject.Subject, error) {
 	utils.LogInfof("Sending subject request to: %s....", peerID)
-	_ = subjectHash
 
-	// create message data
 	req := &pb.SubjectRequest{Metadata: NewMetadata(sp.context.Host, uuid.New().String(), false),
 		Message: fmt.Sprintf("Subject request from %s", sp.context.Host.ID())}
+	resp := &pb.SubjectResponse{}
+	err := sendRequest(ctx, sp.context.Host, peerID, subjectProtocol, req, resp)
+	if err != nil {
+		return nil, err
+	}
+	utils.LogInfof("Received subject response from %s. Message id:%s. Message: %s.", peerID, resp.Metadata.Id, resp.Message)
 
-	ok := SendProtoMessage(sp.context.Host, peerID, subjectRequest, req)
-	if !ok {
-		return false
+	results := make([]*subject.Subject, 0, len(resp.Subjects))
+	for _, sub := range resp.Subjects {
+		identity := identity.NewIdentity(sub.Proposer)
+		if nil == identity {
+			utils.LogWarningf("Invalid proposer of subject %v, %v", sub.Title, sub.Proposer)
+			continue
+		}
+		s := subject.NewSubject(sub.Title, sub.Description, identity, subject.WithOptions(sub.Options...), subject.WithPeriod(sub.OpenAt, sub.CloseAt),
+			subject.WithTreeLevel(uint8(sub.TreeLevel), sub.VerificationKey), subject.WithSequencer(sub.Sequencer),
+			subject.WithProposerKeyHex(sub.ProposerKey), subject.WithIssuer(sub.Issuer))
+		if err := s.CheckOptions(); err != nil {
+			utils.LogWarningf("Invalid options of subject %v, %v", sub.Title, err)
+			continue
+		}
+		results = append(results, s)
 	}
+	return results, nil
+}
 
-	sp.channel[peerID] = ch
-	// store ref request so response handler has access to it
-	sp.requests[req.Metadata.Id] = req
-	// utils.LogInfof("Subject request to: %s was sent. Message Id: %s, Message: %s", peerID, req.Metadata.Id, req.Message)
-	return true
+// toPbSubject carries every attribute in the hash of a subject, so the peers rebuild the same subject
+func toPbSubject(s *subject.Subject) *pb.Subject {
+	return &pb.Subject{Title: s.GetTitle(), Description: s.GetDescription(), Proposer: s.GetProposer().String(), Options: s.Options,
+		OpenAt: s.OpenAt, CloseAt: s.CloseAt, TreeLevel: uint32(s.TreeLevel), VerificationKey: s.VerificationKey,
+		Sequencer: s.Sequencer, ProposerKey: s.ProposerKey, Issuer: s.Issuer}
 }
@@ -0,0 +1,39 @@
+package protocol
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/unitychain/zkvote-node/zkvote/common/store"
+	localContext "github.com/unitychain/zkvote-node/zkvote/model/context"
+	"github.com/unitychain/zkvote-node/zkvote/model/identity"
+	"github.com/unitychain/zkvote-node/zkvote/model/subject"
+)
+
+func TestSubjectProtocol(t *testing.T) {
+	h1, h2 := newConnectedHosts(t)
+	defer h1.Close()
+	defer h2.Close()
+
+	cache, err := store.NewCache()
+	assert.Nil(t, err)
+	ctx := context.Background()
+	NewSubjectProtocol(localContext.NewContext(new(sync.RWMutex), h1, nil, cache, &ctx))
+	client := NewSubjectProtocol(newTestContext(h2))
+
+	proposed := subject.NewSubject("title", "desc", identity.NewIdentity("0x1f"),
+		subject.WithOptions("alice", "bob"), subject.WithPeriod(10, 20), subject.WithTreeLevel(12, "{}"),
+		subject.WithSequencer(h1.ID().Pretty()), subject.WithProposerKey(h1.Peerstore().PubKey(h1.ID())), subject.WithIssuer("did:key:z6Mk"))
+	cache.InsertCreatedSubject(*proposed.HashHex(), proposed)
+
+	// The collected subject is the proposed one, with the same hash and topics
+	subjects, err := client.SubmitRequest(context.Background(), h1.ID())
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(subjects))
+	assert.Equal(t, *proposed.HashHex(), *subjects[0].HashHex())
+	assert.Equal(t, proposed.Sequencer, subjects[0].Sequencer)
+	assert.True(t, subjects[0].RequiresAdmission())
+	assert.Equal(t, proposed.Issuer, subjects[0].Issuer)
+}
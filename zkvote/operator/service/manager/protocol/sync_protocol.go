@@ -0,0 +1,110 @@
+package protocol
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	uuid "github.com/google/uuid"
+	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/unitychain/zkvote-node/zkvote/common/metrics"
+	"github.com/unitychain/zkvote-node/zkvote/common/utils"
+	localContext "github.com/unitychain/zkvote-node/zkvote/model/context"
+	pb "github.com/unitychain/zkvote-node/zkvote/model/pb"
+	"github.com/unitychain/zkvote-node/zkvote/model/subject"
+)
+
+// pattern: /protocol-name/version, the response is written back on the stream of the request
+const syncProtocol = "/sync/0.0.2"
+
+var (
+	syncMessages = metrics.NewCounter("zkvote_sync_messages_total", "Number of sync messages by message and direction", "message", "direction")
+	syncTimeouts = metrics.NewCounter("zkvote_sync_timeouts_total", "Number of sync requests whose response timed out")
+)
+
+// SyncHandler serves a sync request of a subject by filling the response
+type SyncHandler func(subjectHash *subject.Hash, req *pb.SyncRequest, resp *pb.SyncResponse) error
+
+// SyncProtocol type
+// Peers exchange the state of the subject first and then request only what they are missing.
+type SyncProtocol struct {
+	context *localContext.Context
+	handler SyncHandler
+}
+
+// NewSyncProtocol ...
+func NewSyncProtocol(context *localContext.Context, handler SyncHandler) *SyncProtocol {
+	sp := &SyncProtocol{
+		context: context,
+		handler: handler,
+	}
+	sp.context.Host.SetStreamHandler(syncProtocol, sp.onRequest)
+	return sp
+}
+
+// Stop removes the stream handler, the peers can't request this node anymore
+func (sp *SyncProtocol) Stop() {
+	sp.context.Host.RemoveStreamHandler(syncProtocol)
+}
+
+// remote peer requests handler
+func (sp *SyncProtocol) onRequest(s network.Stream) {
+	// the peer has STREAM_TIMEOUT to send its request and read the response
+	s.SetDeadline(time.Now().Add(STREAM_TIMEOUT))
+	data := &pb.SyncRequest{}
+	err := readRequest(s, data)
+	if err != nil {
+		s.Reset()
+		utils.LogWarningf("Failed to read sync request from %s, %v", s.Conn().RemotePeer(), err)
+		return
+	}
+
+	syncMessages.Inc("request", "received")
+	utils.LogInfof("Received sync(%v) request from %s. Message: %s", data.Kind, s.Conn().RemotePeer(), data.Message)
+
+	subjectHash := subject.Hash(data.SubjectHash)
+	resp := &pb.SyncResponse{Metadata: NewMetadata(sp.context.Host, data.Metadata.Id, false),
+		Message: fmt.Sprintf("Sync response from %s", sp.context.Host.ID()), SubjectHash: subjectHash.Byte(), Kind: data.Kind}
+	err = sp.handler(&subjectHash, data, resp)
+	if err != nil {
+		resp.Error = err.Error()
+	}
+
+	// send the response back on the same stream
+	err = writeResponse(sp.context.Host, s, resp)
+	if err != nil {
+		s.Reset()
+		utils.LogErrorf("Failed to send sync response to %s, %v", s.Conn().RemotePeer(), err)
+		return
+	}
+	syncMessages.Inc("response", "sent")
+	utils.LogInfof("Sync(%v) response to %s sent.", data.Kind, s.Conn().RemotePeer().String())
+}
+
+// SubmitRequest sends a sync request of a subject and returns its response,
+// ctx bounds how long the response is waited for.
+// The error the peer failed to serve the request with is returned as well.
+func (sp *SyncProtocol) SubmitRequest(ctx context.Context, peerID peer.ID, subjectHash *subject.Hash, req *pb.SyncRequest) (*pb.SyncResponse, error) {
+	utils.LogInfof("Sending sync(%v) request to: %s....", req.Kind, peerID)
+
+	req.Metadata = NewMetadata(sp.context.Host, uuid.New().String(), false)
+	req.Message = fmt.Sprintf("Sync request from %s", sp.context.Host.ID())
+	req.SubjectHash = subjectHash.Byte()
+	resp := &pb.SyncResponse{}
+	syncMessages.Inc("request", "sent")
+	err := sendRequest(ctx, sp.context.Host, peerID, syncProtocol, req, resp)
+	if err != nil {
+		if context.DeadlineExceeded == err {
+			syncTimeouts.Inc()
+		}
+		return nil, err
+	}
+	syncMessages.Inc("response", "received")
+	utils.LogInfof("Received sync(%v) response from %s. Message id:%s. Message: %s.", resp.Kind, peerID, resp.Metadata.Id, resp.Message)
+
+	if 0 != len(resp.Error) {
+		return nil, fmt.Errorf("sync request error, %v", resp.Error)
+	}
+	return resp, nil
+}
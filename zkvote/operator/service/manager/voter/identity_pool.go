@@ -1,16 +1,22 @@
 package voter
 
 import (
+	"sync"
+
 	. "github.com/unitychain/zkvote-node/zkvote/model/identity"
+	"github.com/unitychain/zkvote-node/zkvote/model/subject"
 )
 
 // IdentityPool ...
+// It's safe for concurrent use, the voter orders the insertions.
 type IdentityPool struct {
 	rootHistory []*TreeContent
 	tree        *MerkleTree
+	treeLevel   uint8
+	lock        sync.RWMutex
 }
 
-const TREE_LEVEL uint8 = 10
+const TREE_LEVEL uint8 = subject.DefaultTreeLevel
 
 // NewIdentityPool ...
 func NewIdentityPool() (*IdentityPool, error) {
@@ -28,11 +34,18 @@ func NewIdentityPoolWithTreeLevel(treeLevel uint8) (*IdentityPool, error) {
 	return &IdentityPool{
 		rootHistory: rootHistory,
 		tree:        tree,
+		treeLevel:   treeLevel,
 	}, nil
 }
 
 // InsertIdc : register id
 func (i *IdentityPool) InsertIdc(idCommitment *IdPathElement) (int, error) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	return i.insertIdc(idCommitment)
+}
+
+func (i *IdentityPool) insertIdc(idCommitment *IdPathElement) (int, error) {
 	c := idCommitment.Content()
 	idx, err := i.tree.Insert(c)
 	if err != nil {
@@ -46,13 +59,15 @@ func (i *IdentityPool) InsertIdc(idCommitment *IdPathElement) (int, error) {
 // OverwriteIdElements .
 // return total len and error
 func (i *IdentityPool) OverwriteIdElements(commitmentSet []*IdPathElement) (int, error) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
 
 	// backup tree and history
 	bckTree := i.tree
 	bckRootHistory := i.rootHistory
 
 	// Iniitalize a new merkle tree
-	tree, err := NewMerkleTree(TREE_LEVEL)
+	tree, err := NewMerkleTree(i.treeLevel)
 	if err != nil {
 		return i.tree.Len(), err
 	}
@@ -63,7 +78,7 @@ func (i *IdentityPool) OverwriteIdElements(commitmentSet []*IdPathElement) (int,
 	// insert values to the new merkle tree
 	var idx int = 0
 	for _, e := range commitmentSet {
-		idx, err = i.InsertIdc(e)
+		idx, err = i.insertIdc(e)
 		if err != nil {
 			break
 		}
@@ -81,6 +96,8 @@ func (i *IdentityPool) OverwriteIdElements(commitmentSet []*IdPathElement) (int,
 
 // Update : update id
 func (i *IdentityPool) Update(index uint, oldIDCommitment, newIDCommitment *IdPathElement) error {
+	i.lock.Lock()
+	defer i.lock.Unlock()
 	old, new := oldIDCommitment.Content(), newIDCommitment.Content()
 	err := i.tree.Update(index, old, new)
 	if err != nil {
@@ -93,6 +110,8 @@ func (i *IdentityPool) Update(index uint, oldIDCommitment, newIDCommitment *IdPa
 
 // IsMember : check if the merkle root is in the root list or not
 func (i *IdentityPool) IsMember(root *IdPathElement) bool {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
 	for _, r := range i.rootHistory {
 		if b, _ := r.Equals(root.Content()); b {
 			return true
@@ -103,12 +122,16 @@ func (i *IdentityPool) IsMember(root *IdPathElement) bool {
 
 // HasRegistered .
 func (i *IdentityPool) HasRegistered(idc *IdPathElement) bool {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
 	c := idc.Content()
 	return i.tree.IsExisted(&c)
 }
 
 // GetAllIds .
 func (i *IdentityPool) GetAllIds() []*IdPathElement {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
 	treeContents := i.tree.GetAllContent()
 	elements := make([]*IdPathElement, len(treeContents))
 	for i, c := range treeContents {
@@ -117,14 +140,29 @@ func (i *IdentityPool) GetAllIds() []*IdPathElement {
 	return elements
 }
 
+// GetRootHistory : get all roots of the tree, from the empty one to the current one
+func (i *IdentityPool) GetRootHistory() []*IdPathElement {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+	roots := make([]*IdPathElement, len(i.rootHistory))
+	for j, r := range i.rootHistory {
+		roots[j] = NewIdPathElement(r)
+	}
+	return roots
+}
+
 // GetIndex .
 func (i *IdentityPool) GetIndex(value *IdPathElement) int {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
 	c := value.Content()
 	return i.tree.GetIndexByValue(&c)
 }
 
 // GetIdentityTreePath .
 func (i *IdentityPool) GetIdentityTreePath(value *IdPathElement) ([]*IdPathElement, []int, *IdPathElement) {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
 	c := value.Content()
 	inters, interIdxs, root := i.tree.GetIntermediateValues(&c)
 	elements := make([]*IdPathElement, len(inters))
@@ -134,6 +172,25 @@ func (i *IdentityPool) GetIdentityTreePath(value *IdPathElement) ([]*IdPathEleme
 	return elements, interIdxs, NewIdPathElement(root)
 }
 
+// GetRoot : get the current root of the tree
+func (i *IdentityPool) GetRoot() *TreeContent {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+	return i.tree.GetRoot()
+}
+
+// GetLeafCount : get the number of the identities in the tree
+func (i *IdentityPool) GetLeafCount() int {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+	return i.tree.Len()
+}
+
+// GetTreeLevel .
+func (i *IdentityPool) GetTreeLevel() uint8 {
+	return i.treeLevel
+}
+
 //
 // Internal functions
 //
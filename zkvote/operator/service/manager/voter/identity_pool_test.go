@@ -136,7 +136,7 @@ func TestOverwrite(t *testing.T) {
 		commitmentSet[i] = NewIdPathElement(NewTreeContent(idc))
 	}
 
-	num, err := id.Overwrite(commitmentSet)
+	num, err := id.OverwriteIdElements(commitmentSet)
 	assert.Nil(t, err, "overwrite error")
 	assert.Equal(t, 10, num)
 }
@@ -157,7 +157,7 @@ func TestOverwrite2(t *testing.T) {
 		commitmentSet[i] = NewIdPathElement(NewTreeContent(idc))
 	}
 
-	num, err := id.Overwrite(commitmentSet)
+	num, err := id.OverwriteIdElements(commitmentSet)
 	assert.Nil(t, err, "overwrite error")
 	assert.Equal(t, 3, num)
 }
@@ -178,7 +178,32 @@ func TestOverwrite_ForceError(t *testing.T) {
 		commitmentSet[i] = NewIdPathElement(NewTreeContent(big.NewInt(0)))
 	}
 
-	num, err := id.Overwrite(commitmentSet)
+	num, err := id.OverwriteIdElements(commitmentSet)
 	assert.NotNil(t, err, "overwrite should have errors")
 	assert.Equal(t, 3, num)
 }
+
+func TestOverwrite_KeepTreeLevel(t *testing.T) {
+	id, err := NewIdentityPoolWithTreeLevel(4)
+	assert.Nil(t, err, "new identity instance error")
+
+	commitmentSet := make([]*IdPathElement, 3)
+	for i := 0; i < 3; i++ {
+		commitmentSet[i] = NewIdPathElement(NewTreeContent(big.NewInt(int64(100*i + 1))))
+	}
+
+	num, err := id.OverwriteIdElements(commitmentSet)
+	assert.Nil(t, err, "overwrite error")
+	assert.Equal(t, 3, num)
+	assert.Equal(t, uint8(4), id.GetTreeLevel())
+
+	paths, _, _ := id.GetIdentityTreePath(commitmentSet[0])
+	assert.Equal(t, 4, len(paths))
+}
+
+func TestNewIdentityPool_InvalidTreeLevel(t *testing.T) {
+	_, err := NewIdentityPoolWithTreeLevel(0)
+	assert.NotNil(t, err)
+	_, err = NewIdentityPoolWithTreeLevel(MaxLevels + 1)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,143 @@
+package voter
+
+import (
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/host"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/unitychain/zkvote-node/zkvote/common/utils"
+)
+
+// Penalties of the messages rejected by the topic validators
+const (
+	// PENALTY_MALFORMED is for messages which can't be parsed or are not for the subject
+	PENALTY_MALFORMED = 5
+	// PENALTY_INVALID_PROOF is for ballots whose proof can't be verified
+	PENALTY_INVALID_PROOF = 10
+	// BLACKLIST_THRESHOLD is the score below which a peer is blacklisted
+	BLACKLIST_THRESHOLD = -20
+	// BLACKLIST_DURATION is how long the messages and streams of a blacklisted peer are refused
+	BLACKLIST_DURATION = time.Hour
+	// SCORE_DECAY is how much a score recovers towards 0 every SCORE_DECAY_INTERVAL
+	SCORE_DECAY          = 1
+	SCORE_DECAY_INTERVAL = time.Minute
+)
+
+const scoreTag = "zkvote-score"
+
+type peerScore struct {
+	score            int
+	updatedAt        time.Time
+	blacklistedUntil time.Time
+}
+
+// PeerScore keeps the scores of the peers relaying gossip messages.
+// Peers relaying invalid messages are penalized, the penalties decay over time,
+// and peers are blacklisted for BLACKLIST_DURATION once their score drops below BLACKLIST_THRESHOLD.
+//
+// go-libp2p-pubsub v0.2.1 has no peer scoring and its blacklist is permanent,
+// so the scores are kept here and PeerScore is the blacklist of the pubsub, given by pubsub.WithBlacklist.
+type PeerScore struct {
+	host  host.Host
+	peers map[peer.ID]*peerScore
+	lock  sync.Mutex
+	now   func() time.Time
+}
+
+// NewPeerScore ...
+func NewPeerScore(host host.Host) *PeerScore {
+	return &PeerScore{
+		host:  host,
+		peers: make(map[peer.ID]*peerScore),
+		now:   time.Now,
+	}
+}
+
+// Penalize lowers the score of a peer
+func (s *PeerScore) Penalize(p peer.ID, penalty int) {
+	s.lock.Lock()
+	e := s.decay(p)
+	e.score -= penalty
+	utils.LogWarningf("Penalize peer %v by %d, score: %d", p, penalty, e.score)
+
+	// let the connection manager prune the connections of bad peers first
+	if nil != s.host {
+		s.host.ConnManager().TagPeer(p, scoreTag, e.score)
+	}
+	blacklist := BLACKLIST_THRESHOLD >= e.score && !s.now().Before(e.blacklistedUntil)
+	if blacklist {
+		e.blacklistedUntil = s.now().Add(BLACKLIST_DURATION)
+		utils.LogWarningf("Blacklist peer %v until %v", p, e.blacklistedUntil)
+	}
+	s.lock.Unlock()
+
+	// The pubsub drops the streams of the peer and refuses new ones while it's blacklisted
+	if blacklist && nil != s.host {
+		s.host.Network().ClosePeer(p)
+	}
+}
+
+// GetScore returns the score of a peer
+func (s *PeerScore) GetScore(p peer.ID) int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	e := s.decay(p)
+	s.prune(p, e)
+	return e.score
+}
+
+// IsBlacklisted .
+func (s *PeerScore) IsBlacklisted(p peer.ID) bool {
+	return s.Contains(p)
+}
+
+// Add blacklists a peer for BLACKLIST_DURATION, it's called by pubsub.BlacklistPeer
+func (s *PeerScore) Add(p peer.ID) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.decay(p).blacklistedUntil = s.now().Add(BLACKLIST_DURATION)
+}
+
+// Contains returns true if a peer is blacklisted now
+func (s *PeerScore) Contains(p peer.ID) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	e, ok := s.peers[p]
+	if !ok {
+		return false
+	}
+	s.decay(p)
+	s.prune(p, e)
+	return s.now().Before(e.blacklistedUntil)
+}
+
+// decay recovers the score of a peer for the time passed since it's updated, must be called with lock held
+func (s *PeerScore) decay(p peer.ID) *peerScore {
+	now := s.now()
+	e, ok := s.peers[p]
+	if !ok {
+		e = &peerScore{updatedAt: now}
+		s.peers[p] = e
+		return e
+	}
+
+	if steps := int(now.Sub(e.updatedAt) / SCORE_DECAY_INTERVAL); 0 < steps {
+		e.score += steps * SCORE_DECAY
+		if 0 < e.score {
+			e.score = 0
+		}
+		e.updatedAt = e.updatedAt.Add(time.Duration(steps) * SCORE_DECAY_INTERVAL)
+	}
+	return e
+}
+
+// prune forgets a peer which has recovered, must be called with lock held
+func (s *PeerScore) prune(p peer.ID, e *peerScore) {
+	if 0 == e.score && !s.now().Before(e.blacklistedUntil) {
+		delete(s.peers, p)
+	}
+}
@@ -3,6 +3,7 @@ package voter
 import (
 	"fmt"
 	"math/big"
+	"sync"
 
 	crypto "github.com/ethereum/go-ethereum/crypto"
 	"github.com/unitychain/zkvote-node/zkvote/common/utils"
@@ -13,7 +14,7 @@ import (
 
 type state struct {
 	records  []*big.Int
-	opinion  []bool
+	opinion  []int
 	finished bool
 }
 
@@ -24,34 +25,48 @@ type nullifier struct {
 }
 
 // Proposal ...
+// It's safe for concurrent use.
 // TODO: Rename
 type Proposal struct {
-	nullifiers map[int]*nullifier
-	ballotMap  ba.Map
-	index      int
+	nullifiers   map[int]*nullifier
+	ballotMap    ba.Map
+	ballotList   []*ba.Ballot // in the order the ballots are accepted
+	index        int
+	signalHashes []string
+	lock         sync.RWMutex
 }
 
-const HASH_YES = "43379584054787486383572605962602545002668015983485933488536749112829893476306"
-const HASH_NO = "85131057757245807317576516368191972321038229705283732634690444270750521936266"
-
 // NewProposal ...
-func NewProposal() (*Proposal, error) {
+// options are the ordered options of the subject,
+// a ballot is only valid if its signal hash is one of theirs.
+func NewProposal(options []string) (*Proposal, error) {
+	if 2 > len(options) {
+		return nil, fmt.Errorf("at least 2 options are required, got %d", len(options))
+	}
+
 	nullifiers := map[int]*nullifier{
 		0: &nullifier{
 			hash:    big.NewInt(0).SetBytes(crypto.Keccak256([]byte("empty"))),
 			content: "empty",
 			voteState: state{
 				records:  []*big.Int{},
-				opinion:  []bool{},
+				opinion:  []int{},
 				finished: false,
 			},
 		}}
 	index := 0
 
+	signalHashes := make([]string, len(options))
+	for i := range options {
+		signalHashes[i] = ba.SignalHash(i)
+	}
+
 	return &Proposal{
-		nullifiers: nullifiers,
-		ballotMap:  ba.NewMap(),
-		index:      index,
+		nullifiers:   nullifiers,
+		ballotMap:    ba.NewMap(),
+		ballotList:   []*ba.Ballot{},
+		index:        index,
+		signalHashes: signalHashes,
 	}, nil
 }
 
@@ -61,6 +76,8 @@ func (p *Proposal) ProposeSubject(subHash subject.HashHex) int {
 		utils.LogWarning("input qeustion in empty")
 		return -1
 	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
 
 	// bigHashQus := big.NewInt(0).SetBytes(crypto.Keccak256([]byte(q)))
 	bigHashQus := utils.GetBigIntFromHexString(subHash.String())
@@ -69,7 +86,7 @@ func (p *Proposal) ProposeSubject(subHash subject.HashHex) int {
 		hash:    bigHashQus.Div(bigHashQus, big.NewInt(8)),
 		content: subHash.String(),
 		voteState: state{
-			opinion:  []bool{},
+			opinion:  []int{},
 			finished: false,
 		},
 	}
@@ -80,25 +97,71 @@ func (p *Proposal) ProposeSubject(subHash subject.HashHex) int {
 
 // VoteWithProof : vote with zk proof
 func (p *Proposal) VoteWithProof(ballot *ba.Ballot, vkString string) error {
-	if b, e := p.isValidVote(ballot, vkString); !b {
-		return e
+	p.lock.RLock()
+	err := p.checkOpen()
+	p.lock.RUnlock()
+	if err != nil {
+		return err
 	}
+	return p.voteWithProof(ballot, vkString, false)
+}
 
-	bigNullHash, _ := big.NewInt(0).SetString(ballot.NullifierHash, 10)
-	p.nullifiers[0].voteState.records = append(p.nullifiers[0].voteState.records, bigNullHash)
+// RestoreVoteWithProof : vote with zk proof even if the proposal has been closed.
+// Only for ballots which have been accepted before, e.g. loaded from the database.
+func (p *Proposal) RestoreVoteWithProof(ballot *ba.Ballot, vkString string) error {
+	return p.voteWithProof(ballot, vkString, true)
+}
+
+// InsertVerifiedVote : vote with a ballot whose proof has been verified already,
+// e.g. by the pubsub validator of the vote topic.
+func (p *Proposal) InsertVerifiedVote(ballot *ba.Ballot) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.insertVote(ballot, false)
+}
 
-	if ballot.PublicSignal[2] == HASH_YES {
-		p.nullifiers[0].voteState.opinion = append(p.nullifiers[0].voteState.opinion, true)
-	} else {
-		p.nullifiers[0].voteState.opinion = append(p.nullifiers[0].voteState.opinion, false)
+// voteWithProof verifies the proof without holding the lock since it takes a while,
+// so the ballot is checked again when it's recorded
+func (p *Proposal) voteWithProof(ballot *ba.Ballot, vkString string, evenIfFinished bool) error {
+	err := p.verifyVote(ballot, vkString)
+	if err != nil {
+		return err
 	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.insertVote(ballot, evenIfFinished)
+}
 
-	p.ballotMap[ballot.NullifierHashHex()] = ballot
+// insertVote must be called with lock held
+func (p *Proposal) insertVote(ballot *ba.Ballot, evenIfFinished bool) error {
+	if !evenIfFinished {
+		err := p.checkOpen()
+		if err != nil {
+			return err
+		}
+	}
+	err := p.checkBallot(ballot)
+	if err != nil {
+		return err
+	}
+	p.recordVote(ballot)
 	return nil
 }
 
+func (p *Proposal) recordVote(ballot *ba.Ballot) {
+	bigNullHash, _ := big.NewInt(0).SetString(ballot.NullifierSignal(), 10)
+	p.nullifiers[0].voteState.records = append(p.nullifiers[0].voteState.records, bigNullHash)
+
+	p.nullifiers[0].voteState.opinion = append(p.nullifiers[0].voteState.opinion, p.getOptionIndex(ballot.SignalHash()))
+
+	p.ballotMap[ballot.NullifierHashHex()] = ballot
+	p.ballotList = append(p.ballotList, ballot)
+}
+
 // Remove : remove a proposal from the list
 func (p *Proposal) Remove(idx int) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
 	if !p.checkIndex(idx) {
 		return
 	}
@@ -107,6 +170,8 @@ func (p *Proposal) Remove(idx int) {
 
 // Close : close a proposal which means can't vote anymore
 func (p *Proposal) Close(idx int) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
 	if !p.checkIndex(idx) {
 		return
 	}
@@ -115,21 +180,41 @@ func (p *Proposal) Close(idx int) {
 
 // InsertBallot ...
 func (p *Proposal) InsertBallot(ballot *ba.Ballot) error {
-	if nil == ballot {
+	if nil == ballot || 4 != len(ballot.PublicSignal) {
 		return fmt.Errorf("invalid input")
 	}
 
+	p.lock.Lock()
+	defer p.lock.Unlock()
 	p.ballotMap[ballot.NullifierHashHex()] = ballot
 	return nil
 }
 
+// GetBallots : get a copy of the ballots by their nullifier hashes
 func (p *Proposal) GetBallots() ba.Map {
-	return p.ballotMap
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	ballots := ba.NewMap()
+	for k, b := range p.ballotMap {
+		ballots[k] = b
+	}
+	return ballots
+}
+
+// GetBallotList : get the ballots in the order they were accepted
+func (p *Proposal) GetBallotList() []*ba.Ballot {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	list := make([]*ba.Ballot, len(p.ballotList))
+	copy(list, p.ballotList)
+	return list
 }
 
 // HasProposal : check proposal exists or not
 // return : -1, not exists, proposal index otherwise
 func (p *Proposal) HasProposal(q string) int {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
 	for i, e := range p.nullifiers {
 		if e.content == q {
 			return i
@@ -141,6 +226,8 @@ func (p *Proposal) HasProposal(q string) int {
 // HasProposalByHash : check proposal exists or not
 // return : -1, not exists, proposal index otherwise
 func (p *Proposal) HasProposalByHash(hash *big.Int) int {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
 	for i, e := range p.nullifiers {
 		if 0 == e.hash.Cmp(hash) {
 			return i
@@ -151,25 +238,25 @@ func (p *Proposal) HasProposalByHash(hash *big.Int) int {
 
 // GetCurrentIdex : get current index of whole questions
 func (p *Proposal) GetCurrentIdex() int {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
 	return p.index
 }
 
-// GetVotes : get total votes
-func (p *Proposal) GetVotes(idx int) (yes, no int) {
+// GetVotes : get total votes of each option
+func (p *Proposal) GetVotes(idx int) []int {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
 	nul := p.getProposal(idx)
 	if nul == nil {
-		return -1, -1
+		return nil
 	}
-	ops := nul.voteState.opinion
 
-	for _, o := range ops {
-		if true == o {
-			yes++
-		} else {
-			no++
-		}
+	votes := make([]int, len(p.signalHashes))
+	for _, o := range nul.voteState.opinion {
+		votes[o]++
 	}
-	return yes, no
+	return votes
 }
 
 // GetProposal : Get a proposal instance
@@ -193,39 +280,73 @@ func (p *Proposal) isFinished() bool {
 	return p.nullifiers[0].voteState.finished
 }
 
-func (p *Proposal) isValidVote(ballot *ba.Ballot, vkString string) (bool, error) {
-	if 0 == len(vkString) {
-		utils.LogWarningf("invalid input: %s", vkString)
-		return false, fmt.Errorf("vk string is empty")
-	}
+// checkOpen must be called with lock held
+func (p *Proposal) checkOpen() error {
 	if p.isFinished() {
 		utils.LogWarningf("this question has been closed")
-		return false, fmt.Errorf("this question has been closed")
+		return fmt.Errorf("this question has been closed")
 	}
+	return nil
+}
 
+// checkVote checks everything of a ballot except its proof
+func (p *Proposal) checkVote(ballot *ba.Ballot) error {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	return p.checkBallot(ballot)
+}
+
+// checkBallot must be called with lock held
+func (p *Proposal) checkBallot(ballot *ba.Ballot) error {
 	nullifierHash := ballot.PublicSignal[1]
 	singalHash := ballot.PublicSignal[2]
 	externalNullifier := ballot.PublicSignal[3]
 
 	bigExternalNull, _ := big.NewInt(0).SetString(externalNullifier, 10)
-	if 0 != p.nullifiers[0].hash.Cmp(bigExternalNull) {
+	if nil == bigExternalNull || 0 != p.nullifiers[0].hash.Cmp(bigExternalNull) {
 		utils.LogWarningf("question doesn't match (%v)/(%v)", p.nullifiers[0].hash, bigExternalNull)
-		return false, fmt.Errorf(fmt.Sprintf("question doesn't match (%v)/(%v)", p.nullifiers[0].hash, bigExternalNull))
+		return fmt.Errorf(fmt.Sprintf("question doesn't match (%v)/(%v)", p.nullifiers[0].hash, bigExternalNull))
 	}
-	if p.isVoted(nullifierHash) {
+	if p.hasRecord(nullifierHash) {
 		utils.LogWarningf("Voted already, %v", nullifierHash)
-		return false, fmt.Errorf("voted already")
+		return fmt.Errorf("voted already")
 	}
-	if !isValidOpinion(singalHash) {
+	if -1 == p.getOptionIndex(singalHash) {
 		utils.LogWarningf("Not a valid vote hash, %v", singalHash)
-		return false, fmt.Errorf(fmt.Sprintf("Not a valid vote hash, %v", singalHash))
+		return fmt.Errorf(fmt.Sprintf("Not a valid vote hash, %v", singalHash))
 	}
+	return nil
+}
 
-	return snark.Verify(vkString, ballot.Proof, ballot.PublicSignal), nil
+// verifyVote checks a ballot and verifies its proof
+func (p *Proposal) verifyVote(ballot *ba.Ballot, vkString string) error {
+	if 0 == len(vkString) {
+		utils.LogWarningf("invalid input: %s", vkString)
+		return fmt.Errorf("vk string is empty")
+	}
+	err := p.checkVote(ballot)
+	if err != nil {
+		return err
+	}
+	if !snark.Verify(vkString, ballot.Proof, ballot.PublicSignal) {
+		utils.LogWarningf("Invalid proof, %v", ballot.NullifierSignal())
+		return fmt.Errorf("invalid proof")
+	}
+	return nil
 }
 
 func (p *Proposal) isVoted(nullifierHash string) bool {
-	bigNullHash, _ := big.NewInt(0).SetString(nullifierHash, 10)
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	return p.hasRecord(nullifierHash)
+}
+
+// hasRecord must be called with lock held
+func (p *Proposal) hasRecord(nullifierHash string) bool {
+	bigNullHash, ok := big.NewInt(0).SetString(nullifierHash, 10)
+	if !ok {
+		return false
+	}
 	for _, r := range p.nullifiers[0].voteState.records {
 		if 0 == bigNullHash.Cmp(r) {
 			return true
@@ -234,11 +355,14 @@ func (p *Proposal) isVoted(nullifierHash string) bool {
 	return false
 }
 
-func isValidOpinion(hash string) bool {
-	if hash == HASH_NO || hash == HASH_YES {
-		return true
+// getOptionIndex returns the index of the option whose signal hash is given, -1 if none
+func (p *Proposal) getOptionIndex(signalHash string) int {
+	for i, h := range p.signalHashes {
+		if h == signalHash {
+			return i
+		}
 	}
-	return false
+	return -1
 }
 
 func (p *Proposal) checkIndex(idx int) bool {
@@ -246,7 +370,7 @@ func (p *Proposal) checkIndex(idx int) bool {
 		utils.LogWarningf("invalid index, %d", idx)
 		return false
 	}
-	if idx > p.GetCurrentIdex() {
+	if idx > p.index {
 		utils.LogWarningf("index (%d) is incorrect, max index is %d", idx, p.index)
 		return false
 	}
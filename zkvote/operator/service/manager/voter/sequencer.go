@@ -0,0 +1,201 @@
+package voter
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/unitychain/zkvote-node/zkvote/common/event"
+	"github.com/unitychain/zkvote-node/zkvote/common/utils"
+	id "github.com/unitychain/zkvote-node/zkvote/model/identity"
+)
+
+// Conflict is an identity insertion which disagrees with the identity tree here.
+// It is reported instead of being inserted, so the tree never forks silently.
+type Conflict struct {
+	Index      int    `json:"index"`
+	Identity   string `json:"identity"`
+	PrevRoot   string `json:"prevRoot"`
+	From       string `json:"from"`
+	Reason     string `json:"reason"`
+	DetectedAt int64  `json:"detectedAt"` // unix time
+}
+
+// Bounds of the insertions and conflicts kept from the identity topic
+const (
+	// MAX_PENDING_AHEAD is how far past the last leaf an insertion can be buffered
+	MAX_PENDING_AHEAD = 256
+	// PENDING_TIMEOUT is how long an insertion stays buffered waiting for the ones before it
+	PENDING_TIMEOUT = 10 * time.Minute
+	// MAX_CONFLICTS is how many conflicts are kept, the oldest are dropped first
+	MAX_CONFLICTS = 100
+)
+
+type pendingInsertion struct {
+	*id.Insertion
+	from       peer.ID
+	receivedAt time.Time
+}
+
+// SubmitIdentity asks for an identity commitment to be inserted.
+// The sequencer of the subject inserts and publishes it at the next index,
+// other peers publish a request and insert it once the sequenced insertion arrives.
+// Subjects without a sequencer are ordered by the publisher.
+func (v *Voter) SubmitIdentity(identity *id.Identity, admission string) error {
+	if nil == identity {
+		return fmt.Errorf("invalid input")
+	}
+	if 0 == len(v.sequencer) || v.isSequencer() {
+		_, err := v.InsertIdentity(identity, admission, true)
+		return err
+	}
+
+	if v.HasRegistered(identity.PathElement()) {
+		return fmt.Errorf("identity has been registered")
+	}
+	// The sequencer would drop a request without a valid admission
+	err := v.subject.VerifyAdmission(identity, admission)
+	if nil != err {
+		return err
+	}
+	utils.LogInfof("Request %v to insert %v", v.sequencer, identity.String())
+	return v.ps.Publish(v.identityTopic(), id.NewInsertionRequest(identity, admission).Byte())
+}
+
+// GetConflicts returns the insertions which disagree with the identity tree here
+func (v *Voter) GetConflicts() []*Conflict {
+	v.orderLock.Lock()
+	defer v.orderLock.Unlock()
+
+	conflicts := make([]*Conflict, len(v.conflicts))
+	copy(conflicts, v.conflicts)
+	return conflicts
+}
+
+//
+// internals
+//
+
+func (v *Voter) isSequencer() bool {
+	return 0 != len(v.sequencer) && v.sequencer == v.Host.ID()
+}
+
+// sequence inserts a requested identity at the next index and publishes the insertion
+func (v *Voter) sequence(ins *id.Insertion) {
+	v.orderLock.Lock()
+	defer v.orderLock.Unlock()
+
+	identity := ins.GetIdentity()
+	if v.HasRegistered(identity.PathElement()) {
+		utils.LogInfof("Got registed id commitment, %v", identity.String())
+		return
+	}
+	i, err := v.insertIdentity(identity, ins.Admission, true)
+	if err != nil {
+		utils.LogWarningf("Sequence id error, %v", err.Error())
+		return
+	}
+	utils.LogInfof("Sequenced id %v at %d", identity.String(), i)
+}
+
+// applyInsertion inserts an identity if it follows the last leaf,
+// buffers it if some insertions before it are missing
+// and reports it if it disagrees with the tree.
+func (v *Voter) applyInsertion(ins *id.Insertion, from peer.ID) {
+	v.orderLock.Lock()
+	defer v.orderLock.Unlock()
+
+	if ins.Index > v.GetLeafCount() {
+		v.expirePending(time.Now())
+		if ins.Index > v.GetLeafCount()+MAX_PENDING_AHEAD {
+			utils.LogWarningf("Drop id %v at %d from %v, too far after %d leaves", ins.Identity, ins.Index, from, v.GetLeafCount())
+			return
+		}
+		if p, ok := v.pending[ins.Index]; ok && p.Identity != ins.Identity {
+			v.reportConflict(ins, from, fmt.Sprintf("another identity %v is pending at the same index", p.Identity))
+			return
+		}
+		utils.LogDebugf("Buffer id %v at %d, %d leaves", ins.Identity, ins.Index, v.GetLeafCount())
+		v.pending[ins.Index] = &pendingInsertion{ins, from, time.Now()}
+		return
+	}
+
+	v.insertAt(ins, from)
+	v.applyPending()
+}
+
+// applyPending applies the buffered insertions following the last leaf
+func (v *Voter) applyPending() {
+	indexes := make([]int, 0, len(v.pending))
+	for i := range v.pending {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+
+	for _, i := range indexes {
+		if i > v.GetLeafCount() {
+			return
+		}
+		p := v.pending[i]
+		delete(v.pending, i)
+		v.insertAt(p.Insertion, p.from)
+	}
+}
+
+// expirePending drops the buffered insertions received before now - PENDING_TIMEOUT,
+// must be called with orderLock held
+func (v *Voter) expirePending(now time.Time) {
+	for i, p := range v.pending {
+		if now.Sub(p.receivedAt) > PENDING_TIMEOUT {
+			utils.LogWarningf("Expire id %v at %d from %v", p.Identity, i, p.from)
+			delete(v.pending, i)
+		}
+	}
+}
+
+// insertAt inserts an identity at its index, which must not be after the last leaf
+func (v *Voter) insertAt(ins *id.Insertion, from peer.ID) {
+	identity := ins.GetIdentity()
+	if ins.Index < v.GetLeafCount() {
+		existing := v.GetAllIds()[ins.Index]
+		if 0 == existing.BigInt().Cmp(identity.PathElement().BigInt()) {
+			return
+		}
+		v.reportConflict(ins, from, fmt.Sprintf("identity %v is at the same index", existing.Hex()))
+		return
+	}
+
+	if !isSameRoot(v.GetRoot().Hex(), ins.PrevRoot) {
+		v.reportConflict(ins, from, fmt.Sprintf("previous root is %v", v.GetRoot().Hex()))
+		return
+	}
+	_, err := v.insertIdentity(identity, ins.Admission, false)
+	if err != nil {
+		v.reportConflict(ins, from, err.Error())
+	}
+}
+
+func (v *Voter) reportConflict(ins *id.Insertion, from peer.ID, reason string) {
+	utils.LogErrorf("Conflicting insertion of %v at %d from %v, %v", ins.Identity, ins.Index, from, reason)
+	c := &Conflict{
+		Index:      ins.Index,
+		Identity:   ins.Identity,
+		PrevRoot:   ins.PrevRoot,
+		From:       from.Pretty(),
+		Reason:     reason,
+		DetectedAt: time.Now().Unix(),
+	}
+	v.conflicts = append(v.conflicts, c)
+	if len(v.conflicts) > MAX_CONFLICTS {
+		v.conflicts = v.conflicts[len(v.conflicts)-MAX_CONFLICTS:]
+	}
+	v.Events.Publish(event.IdentityConflict, v.subject.HashHex().String(), map[string]interface{}{
+		"conflict": c,
+	})
+}
+
+func isSameRoot(a string, b string) bool {
+	x, y := utils.GetBigIntFromHexString(a), utils.GetBigIntFromHexString(b)
+	return nil != x && nil != y && 0 == x.Cmp(y)
+}
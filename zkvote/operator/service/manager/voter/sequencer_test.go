@@ -0,0 +1,105 @@
+package voter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/stretchr/testify/assert"
+	id "github.com/unitychain/zkvote-node/zkvote/model/identity"
+	"github.com/unitychain/zkvote-node/zkvote/model/subject"
+)
+
+func newTestSequencer(t *testing.T) peer.ID {
+	_, pubKey, err := crypto.GenerateKeyPair(crypto.ECDSA, 0)
+	assert.Nil(t, err)
+	p, err := peer.IDFromPublicKey(pubKey)
+	assert.Nil(t, err)
+	return p
+}
+
+func TestApplyInsertion(t *testing.T) {
+	sequencer := newTestSequencer(t)
+	v, ref := newTestVoter(t), newTestVoter(t)
+	defer v.Host.Close()
+	defer ref.Host.Close()
+
+	insertions := make([]*id.Insertion, 0)
+	for _, idc := range []string{"0x1234", "0x5678", "0x9abc"} {
+		ins, err := id.ParseInsertion(newTestInsertion(ref, idc))
+		assert.Nil(t, err)
+		insertions = append(insertions, ins)
+		_, err = ref.InsertIdentity(id.NewIdentity(idc), "", false)
+		assert.Nil(t, err)
+	}
+
+	// out of order insertions are buffered
+	v.applyInsertion(insertions[2], sequencer)
+	v.applyInsertion(insertions[1], sequencer)
+	assert.Equal(t, 0, v.tree.Len())
+	v.applyInsertion(insertions[0], sequencer)
+	assert.Equal(t, 3, v.tree.Len())
+	assert.Equal(t, ref.GetSyncState().Root, v.GetSyncState().Root)
+
+	// duplicates are ignored
+	v.applyInsertion(insertions[1], sequencer)
+	assert.Equal(t, 0, len(v.GetConflicts()))
+
+	// another identity at the same index
+	conflicting := id.NewInsertion(id.NewIdentity("0xdef0"), 1, insertions[1].PrevRoot, "")
+	v.applyInsertion(conflicting, sequencer)
+	// a different history before the next index
+	forked := id.NewInsertion(id.NewIdentity("0xdef0"), 3, insertions[1].PrevRoot, "")
+	v.applyInsertion(forked, sequencer)
+
+	conflicts := v.GetConflicts()
+	assert.Equal(t, 2, len(conflicts))
+	assert.Equal(t, 1, conflicts[0].Index)
+	assert.Equal(t, 3, conflicts[1].Index)
+	assert.Equal(t, 3, v.tree.Len())
+}
+
+func TestApplyInsertion_Bounds(t *testing.T) {
+	sequencer := newTestSequencer(t)
+	v := newTestVoter(t)
+	defer v.Host.Close()
+
+	// too far after the last leaf
+	v.applyInsertion(id.NewInsertion(id.NewIdentity("0x1234"), MAX_PENDING_AHEAD+1, "0x0", ""), sequencer)
+	assert.Equal(t, 0, len(v.pending))
+	v.applyInsertion(id.NewInsertion(id.NewIdentity("0x1234"), MAX_PENDING_AHEAD, "0x0", ""), sequencer)
+	assert.Equal(t, 1, len(v.pending))
+
+	// stale insertions expire
+	v.pending[MAX_PENDING_AHEAD].receivedAt = time.Now().Add(-PENDING_TIMEOUT - time.Second)
+	v.applyInsertion(id.NewInsertion(id.NewIdentity("0x5678"), 2, "0x0", ""), sequencer)
+	assert.Equal(t, 1, len(v.pending))
+	assert.NotNil(t, v.pending[2])
+
+	// the oldest conflicts are dropped
+	for i := 0; i < MAX_CONFLICTS+1; i++ {
+		v.applyInsertion(id.NewInsertion(id.NewIdentity("0x9abc"), 2, "0x0", ""), sequencer)
+	}
+	assert.Equal(t, MAX_CONFLICTS, len(v.GetConflicts()))
+}
+
+func TestValidateIdentity_Sequencer(t *testing.T) {
+	sequencer := newTestSequencer(t)
+	v := newTestVoter(t, subject.WithSequencer(sequencer.Pretty()))
+	defer v.Host.Close()
+
+	request := newTestMessage(id.NewInsertionRequest(id.NewIdentity("0x5678"), "").Byte())
+	assert.True(t, v.validateIdentity(context.Background(), remotePeer, request))
+
+	// only insertions published by the sequencer are accepted
+	insertion := newTestMessage(newTestInsertion(v, "0x1234"))
+	insertion.From = []byte(remotePeer)
+	assert.False(t, v.validateIdentity(context.Background(), remotePeer, insertion))
+	assert.Equal(t, -PENALTY_MALFORMED, v.score.GetScore(remotePeer))
+
+	insertion.From = []byte(sequencer)
+	assert.True(t, v.validateIdentity(context.Background(), remotePeer, insertion))
+	assert.Equal(t, -PENALTY_MALFORMED, v.score.GetScore(remotePeer))
+}
@@ -0,0 +1,230 @@
+package voter
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"sort"
+
+	crypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/unitychain/zkvote-node/zkvote/common/event"
+	"github.com/unitychain/zkvote-node/zkvote/common/utils"
+	ba "github.com/unitychain/zkvote-node/zkvote/model/ballot"
+	id "github.com/unitychain/zkvote-node/zkvote/model/identity"
+)
+
+// SYNC_BUCKETS is the number of buckets the nullifiers are divided into,
+// peers only exchange the nullifiers of the buckets whose digests differ.
+const SYNC_BUCKETS = 16
+
+// SyncState is the summary of a subject exchanged before synchronizing
+type SyncState struct {
+	Root          string
+	LeafCount     int
+	BallotDigest  []byte
+	BucketDigests [][]byte
+}
+
+// GetSyncState .
+func (v *Voter) GetSyncState() *SyncState {
+	buckets := v.getNullifierBuckets()
+	digests := make([][]byte, SYNC_BUCKETS)
+	for i, b := range buckets {
+		digests[i] = digestNullifiers(b)
+	}
+
+	return &SyncState{
+		Root:          v.GetRoot().Hex(),
+		LeafCount:     v.GetLeafCount(),
+		BallotDigest:  crypto.Keccak256(digests...),
+		BucketDigests: digests,
+	}
+}
+
+// GetLeaves returns the identity commitments in [from, to) and their admissions,
+// with the root of the tree which only has the leaves before from.
+func (v *Voter) GetLeaves(from int, to int) (string, []string, []string, error) {
+	ids := v.GetAllIds()
+	if 0 > from || from > to || to > len(ids) {
+		return "", nil, nil, fmt.Errorf("invalid leaf range [%d, %d) of %d leaves", from, to, len(ids))
+	}
+	// Every insertion appends a root, unless the tree has been updated in place
+	history := v.GetRootHistory()
+	if len(history) != len(ids)+1 {
+		return "", nil, nil, fmt.Errorf("root history doesn't match the leaves")
+	}
+
+	leaves := make([]string, to-from)
+	for i, e := range ids[from:to] {
+		leaves[i] = e.Hex()
+	}
+	admissions := v.GetAdmissions()
+	if len(admissions) < to {
+		return "", nil, nil, fmt.Errorf("admissions don't match the leaves")
+	}
+	return history[from].Hex(), leaves, admissions[from:to], nil
+}
+
+// AppendLeaves inserts identity commitments following the current leaves.
+// prefixRoot must be the current root, i.e. both peers agree on the existing leaves.
+// admissions are in the same order as leaves, nil if the subject doesn't require them.
+func (v *Voter) AppendLeaves(prefixRoot string, leaves []string, admissions []string) error {
+	v.orderLock.Lock()
+	defer v.orderLock.Unlock()
+	defer v.applyPending()
+
+	root := utils.GetBigIntFromHexString(prefixRoot)
+	if nil == root || 0 != v.GetRoot().BigInt().Cmp(root) {
+		return fmt.Errorf("prefix root doesn't match (%v)/(%v)", v.GetRoot().Hex(), prefixRoot)
+	}
+
+	if 0 != len(admissions) && len(admissions) != len(leaves) {
+		return fmt.Errorf("%d admissions for %d leaves", len(admissions), len(leaves))
+	}
+	for i, h := range leaves {
+		identity, err := identityFromHex(h)
+		if err != nil {
+			return err
+		}
+		var admission string
+		if 0 != len(admissions) {
+			admission = admissions[i]
+		}
+		_, err = v.insertIdentity(identity, admission, false)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SyncsFrom returns whether the leaves of a peer can be appended here.
+// The order of a sequenced subject only comes from its sequencer,
+// any other peer could reorder the leaves it hasn't inserted yet.
+func (v *Voter) SyncsFrom(p peer.ID) bool {
+	return 0 == len(v.sequencer) || v.sequencer == p
+}
+
+// ReportDivergence reports the leaves of a peer which don't follow the ones here.
+// The tree is kept, the conflict has to be resolved by the operator.
+func (v *Voter) ReportDivergence(from peer.ID, index int, prefixRoot string, leaf string, reason string) {
+	v.orderLock.Lock()
+	defer v.orderLock.Unlock()
+
+	v.reportConflict(&id.Insertion{Identity: leaf, Index: index, PrevRoot: prefixRoot}, from, reason)
+}
+
+// SyncBallot counts a ballot synced from a peer, its membership, nullifier and proof are checked as Restore does.
+// The voting period isn't checked: a ballot cast before the subject closed could arrive after it,
+// and the ballot doesn't prove when it was cast, so the peers it was gossiped to decided that.
+func (v *Voter) SyncBallot(ballot *ba.Ballot) error {
+	err := v.Restore(ballot)
+	if err != nil {
+		return err
+	}
+	v.publishBallot(event.BallotAccepted, ballot.NullifierSignal(), "")
+	return nil
+}
+
+// DiffBuckets returns the buckets whose digests are different from the remote ones
+func (v *Voter) DiffBuckets(remote [][]byte) []int {
+	local := v.GetSyncState().BucketDigests
+	diff := make([]int, 0)
+	for i := range local {
+		if i >= len(remote) || !bytes.Equal(local[i], remote[i]) {
+			diff = append(diff, i)
+		}
+	}
+	return diff
+}
+
+// GetNullifiers returns the nullifiers of the ballots in the buckets
+func (v *Voter) GetNullifiers(buckets []int) []string {
+	all := v.getNullifierBuckets()
+	nullifiers := make([]string, 0)
+	for _, b := range buckets {
+		if 0 > b || SYNC_BUCKETS <= b {
+			continue
+		}
+		nullifiers = append(nullifiers, all[b]...)
+	}
+	return nullifiers
+}
+
+// GetMissingNullifiers returns the nullifiers which don't have ballots here
+func (v *Voter) GetMissingNullifiers(nullifiers []string) []string {
+	ballots := v.GetBallotMap()
+	missing := make([]string, 0)
+	for _, n := range nullifiers {
+		if _, ok := ballots[ba.NullifierHashHex(n)]; !ok {
+			missing = append(missing, n)
+		}
+	}
+	return missing
+}
+
+// GetBallotsByNullifiers returns the ballots of the nullifiers in json, unknown ones are skipped
+func (v *Voter) GetBallotsByNullifiers(nullifiers []string) []string {
+	ballots := v.GetBallotMap()
+	result := make([]string, 0)
+	for _, n := range nullifiers {
+		b, ok := ballots[ba.NullifierHashHex(n)]
+		if !ok {
+			continue
+		}
+		s, err := b.JSON()
+		if err != nil {
+			utils.LogWarningf("get json ballot error, %v", err)
+			continue
+		}
+		result = append(result, s)
+	}
+	return result
+}
+
+//
+// internals
+//
+
+// getNullifierBuckets divides the sorted nullifiers into buckets by their values
+func (v *Voter) getNullifierBuckets() [][]string {
+	buckets := make([][]string, SYNC_BUCKETS)
+	for i := range buckets {
+		buckets[i] = []string{}
+	}
+	for k := range v.GetBallotMap() {
+		n := string(k)
+		buckets[nullifierBucket(n)] = append(buckets[nullifierBucket(n)], n)
+	}
+	for _, b := range buckets {
+		sort.Strings(b)
+	}
+	return buckets
+}
+
+func nullifierBucket(nullifier string) int {
+	n, ok := big.NewInt(0).SetString(nullifier, 10)
+	if !ok {
+		return 0
+	}
+	return int(n.Mod(n, big.NewInt(SYNC_BUCKETS)).Int64())
+}
+
+func digestNullifiers(nullifiers []string) []byte {
+	data := make([][]byte, len(nullifiers))
+	for i, n := range nullifiers {
+		data[i] = []byte(n + ",")
+	}
+	return crypto.Keccak256(data...)
+}
+
+// identityFromHex converts the hex of a leaf to an identity,
+// the leaf of an empty commitment is 0x0.
+func identityFromHex(h string) (*id.Identity, error) {
+	value := utils.GetBigIntFromHexString(h)
+	if nil == value {
+		return nil, fmt.Errorf("invalid identity commitment, %v", h)
+	}
+	return id.NewIdentityFromBytes(value.Bytes()), nil
+}
@@ -0,0 +1,92 @@
+package voter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	ba "github.com/unitychain/zkvote-node/zkvote/model/ballot"
+	id "github.com/unitychain/zkvote-node/zkvote/model/identity"
+	"github.com/unitychain/zkvote-node/zkvote/model/subject"
+)
+
+func TestSyncLeaves(t *testing.T) {
+	remote, local := newTestVoter(t), newTestVoter(t)
+	defer remote.Host.Close()
+	defer local.Host.Close()
+
+	for _, idc := range []string{"0x1234", "0x5678", "0x9abc"} {
+		_, err := remote.InsertIdentity(id.NewIdentity(idc), "", false)
+		assert.Nil(t, err)
+	}
+	_, err := local.InsertIdentity(id.NewIdentity("0x1234"), "", false)
+	assert.Nil(t, err)
+
+	state := remote.GetSyncState()
+	assert.Equal(t, 3, state.LeafCount)
+
+	prefixRoot, leaves, _, err := remote.GetLeaves(1, 3)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(leaves))
+	assert.Nil(t, local.AppendLeaves(prefixRoot, leaves, nil))
+	assert.Equal(t, state.Root, local.GetSyncState().Root)
+
+	// the trees diverge
+	_, err = local.InsertIdentity(id.NewIdentity("0xdef0"), "", false)
+	assert.Nil(t, err)
+	_, err = remote.InsertIdentity(id.NewIdentity("0x1111"), "", false)
+	assert.Nil(t, err)
+	prefixRoot, leaves, _, err = remote.GetLeaves(3, 4)
+	assert.Nil(t, err)
+	root := local.GetSyncState().Root
+	err = local.AppendLeaves(prefixRoot, leaves, nil)
+	assert.NotNil(t, err)
+
+	// the tree is kept and the divergence is reported
+	local.ReportDivergence(remote.Host.ID(), 3, prefixRoot, leaves[0], err.Error())
+	assert.Equal(t, root, local.GetSyncState().Root)
+	conflicts := local.GetConflicts()
+	assert.Equal(t, 1, len(conflicts))
+	assert.Equal(t, 3, conflicts[0].Index)
+	assert.Equal(t, remote.Host.ID().Pretty(), conflicts[0].From)
+
+	_, _, _, err = remote.GetLeaves(2, 5)
+	assert.NotNil(t, err)
+}
+
+func newTestBallot(t *testing.T, nullifierHash string) *ba.Ballot {
+	b, err := ba.NewBallot(`{"root":"1","nullifier_hash":"` + nullifierHash + `","proof":{},"public_signal":["1","` + nullifierHash + `","3","4"]}`)
+	assert.Nil(t, err)
+	return b
+}
+
+func TestSyncBallots(t *testing.T) {
+	remote, local := newTestVoter(t), newTestVoter(t)
+	defer remote.Host.Close()
+	defer local.Host.Close()
+
+	for _, n := range []string{"1", "2", "17"} {
+		assert.Nil(t, remote.InsertBallot(newTestBallot(t, n)))
+	}
+	assert.Nil(t, local.InsertBallot(newTestBallot(t, "2")))
+	assert.NotEqual(t, remote.GetSyncState().BallotDigest, local.GetSyncState().BallotDigest)
+
+	// 1 and 17 are in the same bucket
+	buckets := local.DiffBuckets(remote.GetSyncState().BucketDigests)
+	assert.Equal(t, []int{1}, buckets)
+
+	missing := local.GetMissingNullifiers(remote.GetNullifiers(buckets))
+	assert.Equal(t, []string{"1", "17"}, missing)
+	assert.Equal(t, 2, len(remote.GetBallotsByNullifiers(missing)))
+}
+
+func TestSyncBallot_Closed(t *testing.T) {
+	now := time.Now().Unix()
+	v := newTestVoter(t, subject.WithPeriod(now-20, now-10))
+	defer v.Host.Close()
+
+	// A synced ballot is checked as a restored one, it could have been cast before the subject closed
+	b := newTestBallot(t, "1")
+	assert.Contains(t, v.Vote(b, true).Error(), "closed")
+	assert.EqualError(t, v.SyncBallot(b), "Not a member")
+}
@@ -0,0 +1,144 @@
+package voter
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	"github.com/unitychain/zkvote-node/zkvote/common/event"
+	"github.com/unitychain/zkvote-node/zkvote/common/utils"
+	ba "github.com/unitychain/zkvote-node/zkvote/model/ballot"
+	id "github.com/unitychain/zkvote-node/zkvote/model/identity"
+)
+
+// registerValidators registers the validators of the identity and vote topics,
+// messages rejected by them are neither delivered nor forwarded to other peers.
+func (v *Voter) registerValidators() error {
+	err := v.ps.RegisterTopicValidator(v.identityTopic(), v.validateIdentity)
+	if err != nil {
+		return err
+	}
+	err = v.ps.RegisterTopicValidator(v.voteTopic(), v.validateVote)
+	if err != nil {
+		v.ps.UnregisterTopicValidator(v.identityTopic())
+		return err
+	}
+	return nil
+}
+
+func (v *Voter) unregisterValidators() {
+	v.ps.UnregisterTopicValidator(v.identityTopic())
+	v.ps.UnregisterTopicValidator(v.voteTopic())
+}
+
+// validateIdentity rejects malformed identity insertions and requests, those without a valid admission of the proposer,
+// and the insertions of a sequenced subject which aren't published by its sequencer.
+// Commitments registered already are rejected but the peer is not penalized.
+// Conflicting insertions are delivered so that they are reported.
+func (v *Voter) validateIdentity(ctx context.Context, src peer.ID, m *pubsub.Message) bool {
+	// published by ourselves, checked by InsertIdentity already
+	if src == v.Host.ID() {
+		return true
+	}
+
+	ins, err := id.ParseInsertion(m.GetData())
+	if err != nil || !ins.GetIdentity().IsValid() {
+		utils.LogWarningf("validateIdentity: invalid identity insertion from %v", src)
+		v.score.Penalize(src, PENALTY_MALFORMED)
+		return false
+	}
+	identity := ins.GetIdentity()
+
+	err = v.subject.VerifyAdmission(identity, ins.Admission)
+	if err != nil {
+		utils.LogWarningf("validateIdentity: %v from %v", err.Error(), src)
+		v.score.Penalize(src, PENALTY_MALFORMED)
+		return false
+	}
+
+	if ins.IsRequest() {
+		if 0 == len(v.sequencer) {
+			utils.LogWarningf("validateIdentity: subject without sequencer, request from %v", src)
+			v.score.Penalize(src, PENALTY_MALFORMED)
+			return false
+		}
+		if v.HasRegistered(identity.PathElement()) {
+			utils.LogDebugf("validateIdentity: registered identity commitment, %v", identity.String())
+			return false
+		}
+		return true
+	}
+
+	if 0 != len(v.sequencer) && peer.ID(m.GetFrom()) != v.sequencer {
+		utils.LogWarningf("validateIdentity: insertion not sequenced by %v, from %v", v.sequencer, src)
+		v.score.Penalize(src, PENALTY_MALFORMED)
+		return false
+	}
+	if idx := v.GetIndex(identity.PathElement()); 0 <= idx && idx == ins.Index {
+		utils.LogDebugf("validateIdentity: registered identity commitment, %v", identity.String())
+		return false
+	}
+	return true
+}
+
+// validateVote rejects ballots which can't be counted:
+// malformed, for another subject, from a non-member, voted already or with an invalid proof.
+// Only the peers relaying malformed ballots or invalid proofs are penalized,
+// since the others could be caused by a different view of the subject.
+func (v *Voter) validateVote(ctx context.Context, src peer.ID, m *pubsub.Message) bool {
+	// published by ourselves, checked by Vote already
+	if src == v.Host.ID() {
+		return true
+	}
+
+	ballot, err := ba.NewBallot(string(m.GetData()))
+	if err != nil {
+		utils.LogWarningf("validateVote: %v from %v", err.Error(), src)
+		v.score.Penalize(src, PENALTY_MALFORMED)
+		return false
+	}
+
+	err = v.checkPeriod()
+	if err != nil {
+		utils.LogWarningf("validateVote: %v", err.Error())
+		v.publishBallot(event.BallotRejected, ballot.NullifierSignal(), err.Error())
+		return false
+	}
+
+	// Check membership
+	bigRoot, _ := big.NewInt(0).SetString(ballot.RootSignal(), 10)
+	if nil == bigRoot || !v.IsMember(id.NewIdPathElement(id.NewTreeContent(bigRoot))) {
+		utils.LogWarningf("validateVote: Not a member, %v", ballot.RootSignal())
+		v.publishBallot(event.BallotRejected, ballot.NullifierSignal(), "Not a member")
+		return false
+	}
+
+	// Check subject, option and nullifier before the expensive proof verification
+	err = v.checkVote(ballot)
+	if err != nil {
+		v.publishBallot(event.BallotRejected, ballot.NullifierSignal(), err.Error())
+		if v.isVoted(ballot.NullifierSignal()) {
+			return false
+		}
+		v.score.Penalize(src, PENALTY_MALFORMED)
+		return false
+	}
+
+	err = v.verifyVote(ballot, v.verificationKey)
+	if err != nil {
+		utils.LogWarningf("validateVote: %v from %v", err.Error(), src)
+		v.publishBallot(event.BallotRejected, ballot.NullifierSignal(), err.Error())
+		v.score.Penalize(src, PENALTY_INVALID_PROOF)
+		return false
+	}
+	return true
+}
+
+func (v *Voter) identityTopic() string {
+	return "identity/" + v.subject.HashHex().String()
+}
+
+func (v *Voter) voteTopic() string {
+	return "vote/" + v.subject.HashHex().String()
+}
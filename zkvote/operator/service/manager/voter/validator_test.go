@@ -0,0 +1,170 @@
+package voter
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p"
+	"github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/peer"
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	pubsubPb "github.com/libp2p/go-libp2p-pubsub/pb"
+	"github.com/stretchr/testify/assert"
+	"github.com/unitychain/zkvote-node/zkvote/common/store"
+	localContext "github.com/unitychain/zkvote-node/zkvote/model/context"
+	id "github.com/unitychain/zkvote-node/zkvote/model/identity"
+	"github.com/unitychain/zkvote-node/zkvote/model/subject"
+)
+
+const remotePeer = peer.ID("remote")
+
+func newTestVoter(t *testing.T, opts ...subject.Opt) *Voter {
+	ctx := context.Background()
+	h, err := libp2p.New(ctx, libp2p.NoListenAddrs)
+	assert.Nil(t, err)
+	score := NewPeerScore(h)
+	ps, err := pubsub.NewGossipSub(ctx, h, pubsub.WithBlacklist(score))
+	assert.Nil(t, err)
+	cache, err := store.NewCache()
+	assert.Nil(t, err)
+	lc := localContext.NewContext(&sync.RWMutex{}, h, nil, cache, &ctx)
+
+	s := subject.NewSubject("title", "description", id.NewIdentity("0x1f"), opts...)
+	v, err := NewVoter(s, ps, lc, "{}", score)
+	assert.Nil(t, err)
+	return v
+}
+
+func newTestMessage(data []byte) *pubsub.Message {
+	return &pubsub.Message{Message: &pubsubPb.Message{Data: data}}
+}
+
+func newTestInsertion(v *Voter, identity string) []byte {
+	return id.NewInsertion(id.NewIdentity(identity), v.tree.Len(), v.tree.GetRoot().Hex(), "").Byte()
+}
+
+func TestValidateIdentity(t *testing.T) {
+	v := newTestVoter(t)
+	defer v.Host.Close()
+
+	insertion := newTestInsertion(v, "0x1234")
+	assert.True(t, v.validateIdentity(context.Background(), remotePeer, newTestMessage(insertion)))
+	assert.Equal(t, 0, v.score.GetScore(remotePeer))
+
+	assert.False(t, v.validateIdentity(context.Background(), remotePeer, newTestMessage([]byte{0x12, 0x34})))
+	assert.Equal(t, -PENALTY_MALFORMED, v.score.GetScore(remotePeer))
+
+	// the subject has no sequencer to request
+	request := id.NewInsertionRequest(id.NewIdentity("0x5678"), "").Byte()
+	assert.False(t, v.validateIdentity(context.Background(), remotePeer, newTestMessage(request)))
+	assert.Equal(t, -2*PENALTY_MALFORMED, v.score.GetScore(remotePeer))
+
+	// registered already, not penalized
+	_, err := v.InsertIdentity(id.NewIdentity("0x1234"), "", false)
+	assert.Nil(t, err)
+	assert.False(t, v.validateIdentity(context.Background(), remotePeer, newTestMessage(insertion)))
+	assert.Equal(t, -2*PENALTY_MALFORMED, v.score.GetScore(remotePeer))
+}
+
+func TestValidateVote_Malformed(t *testing.T) {
+	v := newTestVoter(t)
+	defer v.Host.Close()
+
+	assert.False(t, v.validateVote(context.Background(), remotePeer, newTestMessage([]byte("not a ballot"))))
+	assert.False(t, v.validateVote(context.Background(), remotePeer, newTestMessage([]byte(`{"proof":{},"public_signal":["1"]}`))))
+	assert.Equal(t, -2*PENALTY_MALFORMED, v.score.GetScore(remotePeer))
+}
+
+func TestValidateVote_NotMember(t *testing.T) {
+	v := newTestVoter(t)
+	defer v.Host.Close()
+
+	// an unknown root could be caused by a different view of the identities
+	ballot := `{"root":"1","nullifier_hash":"2","proof":{},"public_signal":["1","2","3","4"]}`
+	assert.False(t, v.validateVote(context.Background(), remotePeer, newTestMessage([]byte(ballot))))
+	assert.Equal(t, 0, v.score.GetScore(remotePeer))
+}
+
+func TestValidate_OwnMessage(t *testing.T) {
+	v := newTestVoter(t)
+	defer v.Host.Close()
+
+	assert.True(t, v.validateVote(context.Background(), v.Host.ID(), newTestMessage([]byte("not a ballot"))))
+	assert.True(t, v.validateIdentity(context.Background(), v.Host.ID(), newTestMessage([]byte{})))
+}
+
+func TestPeerScore_Blacklist(t *testing.T) {
+	v := newTestVoter(t)
+	defer v.Host.Close()
+
+	for i := 0; i < -BLACKLIST_THRESHOLD/PENALTY_INVALID_PROOF-1; i++ {
+		v.score.Penalize(remotePeer, PENALTY_INVALID_PROOF)
+	}
+	assert.False(t, v.score.IsBlacklisted(remotePeer))
+	v.score.Penalize(remotePeer, PENALTY_INVALID_PROOF)
+	assert.True(t, v.score.IsBlacklisted(remotePeer))
+
+	// The score recovers and the peer is refused only for a while
+	now := time.Now()
+	v.score.now = func() time.Time { return now.Add(10 * SCORE_DECAY_INTERVAL) }
+	assert.Equal(t, BLACKLIST_THRESHOLD+10*SCORE_DECAY, v.score.GetScore(remotePeer))
+	assert.True(t, v.score.IsBlacklisted(remotePeer))
+	v.score.now = func() time.Time { return now.Add(BLACKLIST_DURATION) }
+	assert.False(t, v.score.IsBlacklisted(remotePeer))
+	assert.Equal(t, 0, v.score.GetScore(remotePeer))
+}
+
+func TestValidateIdentity_Admission(t *testing.T) {
+	prvKey, pubKey, err := crypto.GenerateKeyPair(crypto.Ed25519, 0)
+	assert.Nil(t, err)
+	v := newTestVoter(t, subject.WithProposerKey(pubKey))
+	defer v.Host.Close()
+
+	admission, err := v.GetSubject().SignAdmission(prvKey, id.NewIdentity("0x1234"))
+	assert.Nil(t, err)
+	other, err := v.GetSubject().SignAdmission(prvKey, id.NewIdentity("0x5678"))
+	assert.Nil(t, err)
+
+	// unsigned or wrongly signed admissions are rejected
+	unsigned := id.NewInsertion(id.NewIdentity("0x1234"), 0, v.tree.GetRoot().Hex(), "").Byte()
+	assert.False(t, v.validateIdentity(context.Background(), remotePeer, newTestMessage(unsigned)))
+	wrong := id.NewInsertion(id.NewIdentity("0x1234"), 0, v.tree.GetRoot().Hex(), other).Byte()
+	assert.False(t, v.validateIdentity(context.Background(), remotePeer, newTestMessage(wrong)))
+	assert.Equal(t, -2*PENALTY_MALFORMED, v.score.GetScore(remotePeer))
+	signed := id.NewInsertion(id.NewIdentity("0x1234"), 0, v.tree.GetRoot().Hex(), admission).Byte()
+	assert.True(t, v.validateIdentity(context.Background(), remotePeer, newTestMessage(signed)))
+
+	_, err = v.InsertIdentity(id.NewIdentity("0x1234"), "", false)
+	assert.NotNil(t, err)
+	_, err = v.InsertIdentity(id.NewIdentity("0x1234"), other, false)
+	assert.NotNil(t, err)
+	_, err = v.InsertIdentity(id.NewIdentity("0x1234"), admission, false)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{admission}, v.GetAdmissions())
+
+	_, err = v.OverwriteIds([]*id.Identity{id.NewIdentity("0x1234"), id.NewIdentity("0x5678")}, nil)
+	assert.NotNil(t, err)
+	_, err = v.OverwriteIds([]*id.Identity{id.NewIdentity("0x1234"), id.NewIdentity("0x5678")}, []string{admission, other})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, v.tree.Len())
+
+	// revoked identities can't be inserted even with an admission
+	revoked, err := v.GetSubject().SignAdmission(prvKey, id.NewIdentity("0x9abc"))
+	assert.Nil(t, err)
+	v.Revoke(id.NewIdentity("0x9abc"))
+	_, err = v.InsertIdentity(id.NewIdentity("0x9abc"), revoked, false)
+	assert.NotNil(t, err)
+
+	// nor after their credentials expire, unless another credential doesn't
+	expired, err := v.GetSubject().SignAdmission(prvKey, id.NewIdentity("0xdef0"))
+	assert.Nil(t, err)
+	v.ExpireAt(id.NewIdentity("0xdef0"), time.Now().Unix()-1)
+	_, err = v.InsertIdentity(id.NewIdentity("0xdef0"), expired, false)
+	assert.NotNil(t, err)
+	v.ExpireAt(id.NewIdentity("0xdef0"), 0)
+	v.ExpireAt(id.NewIdentity("0xdef0"), time.Now().Unix()-1)
+	_, err = v.InsertIdentity(id.NewIdentity("0xdef0"), expired, false)
+	assert.Nil(t, err)
+}
@@ -3,9 +3,15 @@ package voter
 import (
 	"fmt"
 	"math/big"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
 
 	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	"github.com/unitychain/zkvote-node/zkvote/common/event"
 	"github.com/unitychain/zkvote-node/zkvote/common/utils"
+	"github.com/unitychain/zkvote-node/zkvote/model/archive"
 	ba "github.com/unitychain/zkvote-node/zkvote/model/ballot"
 	localContext "github.com/unitychain/zkvote-node/zkvote/model/context"
 	id "github.com/unitychain/zkvote-node/zkvote/model/identity"
@@ -25,9 +31,29 @@ type Voter struct {
 	verificationKey string
 
 	*localContext.Context
-	ps           *pubsub.PubSub
-	subscription *voterSubscription
-	pubMsg       map[string][]*pubsub.Message
+	ps       *pubsub.PubSub
+	pubMsg   map[string][]*pubsub.Message
+	score    *PeerScore
+	handlers sync.WaitGroup // of the subscriptions
+
+	// Start and Stop can be called from the lifecycle, the syncs and the REST API concurrently
+	lifecycleLock sync.Mutex
+	running       bool
+	subscription  *voterSubscription
+	closeTimer    *time.Timer
+
+	// identity insertions are ordered by the sequencer of the subject
+	sequencer peer.ID
+	pending   map[int]*pendingInsertion
+	conflicts []*Conflict
+	orderLock sync.Mutex
+
+	// signatures of the proposer admitting the identities, by their commitments
+	admissions map[string]string
+	// identities whose credentials are revoked can't be inserted
+	revoked map[string]bool
+	// identities can't be inserted after their credentials expire, in unix time and 0 for never
+	expirations map[string]int64
 }
 
 // NewVoter ...
@@ -36,23 +62,25 @@ func NewVoter(
 	ps *pubsub.PubSub,
 	lc *localContext.Context,
 	verificationKey string,
+	score *PeerScore,
 ) (*Voter, error) {
-	id, err := NewIdentityPool()
+	id, err := NewIdentityPoolWithTreeLevel(subject.GetTreeLevel())
 	if nil != err {
 		return nil, err
 	}
-	p, err := NewProposal()
-	if nil != err {
-		return nil, err
+	if 0 != len(subject.VerificationKey) {
+		verificationKey = subject.VerificationKey
 	}
-
-	identitySub, err := ps.Subscribe("identity/" + subject.HashHex().String())
-	if err != nil {
+	p, err := NewProposal(subject.GetOptions())
+	if nil != err {
 		return nil, err
 	}
-	voteSub, err := ps.Subscribe("vote/" + subject.HashHex().String())
-	if err != nil {
-		return nil, err
+	var sequencer peer.ID
+	if 0 != len(subject.Sequencer) {
+		sequencer, err = peer.IDB58Decode(subject.Sequencer)
+		if nil != err {
+			return nil, fmt.Errorf("invalid sequencer, %v", err)
+		}
 	}
 
 	v := &Voter{
@@ -62,16 +90,44 @@ func NewVoter(
 		ps:              ps,
 		Context:         lc,
 		verificationKey: verificationKey,
-		subscription: &voterSubscription{
-			idSub:   identitySub,
-			voteSub: voteSub,
-		},
+		score:           score,
+		sequencer:       sequencer,
+		pending:         make(map[int]*pendingInsertion),
+		conflicts:       []*Conflict{},
+		admissions:      make(map[string]string),
+		revoked:         make(map[string]bool),
+		expirations:     make(map[string]int64),
 	}
+
 	v.Propose()
+	err = v.Start()
+	if err != nil {
+		return nil, err
+	}
 
-	go v.identitySubHandler(v.subject.Hash(), v.subscription.idSub)
-	go v.voteSubHandler(v.subscription.voteSub)
+	return v, nil
+}
+
+// NewVoterFromArchive rebuilds a voter from an exported archive.
+// The identity tree has to reproduce the root history of the archive,
+// and every ballot is verified again.
+func NewVoterFromArchive(
+	a *archive.Archive,
+	ps *pubsub.PubSub,
+	lc *localContext.Context,
+	verificationKey string,
+	score *PeerScore,
+) (*Voter, error) {
+	v, err := NewVoter(a.Subject, ps, lc, verificationKey, score)
+	if nil != err {
+		return nil, err
+	}
 
+	err = v.restoreArchive(a)
+	if nil != err {
+		v.Leave()
+		return nil, err
+	}
 	return v, nil
 }
 
@@ -79,24 +135,40 @@ func NewVoter(
 // Identities
 //
 
-// InsertIdentity .
-func (v *Voter) InsertIdentity(identity *id.Identity, publish bool) (int, error) {
-	if nil == identity {
-		return -1, fmt.Errorf("invalid input")
-	}
+// InsertIdentity inserts an identity at the next index.
+// The admission has to be signed by the proposer if the subject requires it.
+// If publish, the insertion is published with its index, the previous root and the admission.
+func (v *Voter) InsertIdentity(identity *id.Identity, admission string, publish bool) (int, error) {
+	v.orderLock.Lock()
+	defer v.orderLock.Unlock()
 
-	i, err := v.InsertIdc(identity.PathElement())
+	i, err := v.insertIdentity(identity, admission, publish)
 	if nil != err {
 		return -1, err
 	}
+	v.applyPending()
+	return i, nil
+}
 
-	v.Cache.InsertIdentity(v.subject.Hash().Hex(), *identity)
+// Revoke refuses the insertion of an identity from now on.
+// The identity stays in the tree if it has been inserted already.
+func (v *Voter) Revoke(identity *id.Identity) {
+	v.orderLock.Lock()
+	defer v.orderLock.Unlock()
+	v.revoked[admissionKey(identity.PathElement())] = true
+}
 
-	if publish {
-		return i, v.ps.Publish(v.GetIdentitySub().Topic(), identity.Byte())
-	}
+// ExpireAt refuses the insertion of an identity after expiresAt, 0 for never.
+// The latest expiration is kept if the identity has several credentials.
+func (v *Voter) ExpireAt(identity *id.Identity, expiresAt int64) {
+	v.orderLock.Lock()
+	defer v.orderLock.Unlock()
 
-	return i, nil
+	key := admissionKey(identity.PathElement())
+	if at, ok := v.expirations[key]; ok && (0 == at || (0 != expiresAt && at > expiresAt)) {
+		return
+	}
+	v.expirations[key] = expiresAt
 }
 
 // Join .
@@ -105,14 +177,42 @@ func (v *Voter) InsertIdentity(identity *id.Identity, publish bool) (int, error)
 // }
 
 // OverwriteIds .
-func (v *Voter) OverwriteIds(identities []*id.Identity) (int, error) {
+// admissions are in the same order as identities, nil if the subject doesn't require them.
+func (v *Voter) OverwriteIds(identities []*id.Identity, admissions []string) (int, error) {
+	v.orderLock.Lock()
+	defer v.orderLock.Unlock()
+
+	if 0 != len(admissions) && len(admissions) != len(identities) {
+		return 0, fmt.Errorf("%d admissions for %d identities", len(admissions), len(identities))
+	}
+	admissionMap := make(map[string]string)
 	idElements := make([]*id.IdPathElement, len(identities))
 	for i, e := range identities {
+		var admission string
+		if 0 != len(admissions) {
+			admission = admissions[i]
+		}
+		err := v.subject.VerifyAdmission(e, admission)
+		if nil != err {
+			return 0, err
+		}
+		if 0 != len(admission) {
+			admissionMap[admissionKey(e.PathElement())] = admission
+		}
 		idElements[i] = e.PathElement()
-		v.Cache.InsertIdentity(v.subject.Hash().Hex(), *e)
 	}
 
-	return v.OverwriteIdElements(idElements)
+	n, err := v.OverwriteIdElements(idElements)
+	if nil != err {
+		return n, err
+	}
+	v.admissions = admissionMap
+	for _, e := range identities {
+		v.Cache.InsertIdentity(v.subject.Hash().Hex(), *e)
+	}
+	v.publishRoot()
+	v.applyPending()
+	return n, nil
 }
 
 //
@@ -120,13 +220,28 @@ func (v *Voter) OverwriteIds(identities []*id.Identity) (int, error) {
 //
 // Vote .
 func (v *Voter) Vote(ballot *ba.Ballot, silent bool) error {
+	err := v.vote(ballot, silent)
+	if err != nil {
+		v.publishBallot(event.BallotRejected, ballot.NullifierSignal(), err.Error())
+		return err
+	}
+	v.publishBallot(event.BallotAccepted, ballot.NullifierSignal(), "")
+	return nil
+}
+
+func (v *Voter) vote(ballot *ba.Ballot, silent bool) error {
 	bytes, err := ballot.Byte()
 	if err != nil {
 		return err
 	}
 
+	err = v.checkPeriod()
+	if err != nil {
+		return err
+	}
+
 	// Check membership
-	bigRoot, _ := big.NewInt(0).SetString(ballot.Root, 10)
+	bigRoot, _ := big.NewInt(0).SetString(ballot.RootSignal(), 10)
 	if !v.IsMember(id.NewIdPathElement(id.NewTreeContent(bigRoot))) {
 		return fmt.Errorf("Not a member")
 	}
@@ -145,8 +260,117 @@ func (v *Voter) Vote(ballot *ba.Ballot, silent bool) error {
 	return nil
 }
 
+// Restore a ballot which has been accepted before.
+// Unlike Vote, the voting period is not checked.
+func (v *Voter) Restore(ballot *ba.Ballot) error {
+	bigRoot, _ := big.NewInt(0).SetString(ballot.RootSignal(), 10)
+	if !v.IsMember(id.NewIdPathElement(id.NewTreeContent(bigRoot))) {
+		return fmt.Errorf("Not a member")
+	}
+
+	err := v.RestoreVoteWithProof(ballot, v.verificationKey)
+	if err != nil {
+		return err
+	}
+
+	v.Context.Cache.InsertBallot(v.subject.Hash().Hex(), ballot)
+	return nil
+}
+
+// Archive exports the voting state of the subject
+func (v *Voter) Archive() *archive.Archive {
+	ids := v.GetAllIds()
+	identities := make([]string, len(ids))
+	for i, e := range ids {
+		identities[i] = e.Hex()
+	}
+	var admissions []string
+	if v.subject.RequiresAdmission() {
+		admissions = v.GetAdmissions()
+	}
+
+	history := v.GetRootHistory()
+	roots := make([]string, len(history))
+	for i, r := range history {
+		roots[i] = r.Hex()
+	}
+
+	return &archive.Archive{
+		Format:          archive.FORMAT,
+		Version:         archive.VERSION,
+		CreatedAt:       time.Now().Unix(),
+		Subject:         v.subject,
+		Identities:      identities,
+		Admissions:      admissions,
+		Roots:           roots,
+		Ballots:         v.GetBallotList(),
+		VerificationKey: v.verificationKey,
+	}
+}
+
+// Start subscribes the topics of the subject, NewVoter starts the voter
+func (v *Voter) Start() error {
+	v.lifecycleLock.Lock()
+	defer v.lifecycleLock.Unlock()
+	if v.running {
+		return nil
+	}
+
+	// Validators have to be registered before subscribing,
+	// otherwise messages could be delivered without validation
+	err := v.registerValidators()
+	if err != nil {
+		return err
+	}
+	identitySub, err := v.ps.Subscribe(v.identityTopic())
+	if err != nil {
+		v.unregisterValidators()
+		return err
+	}
+	voteSub, err := v.ps.Subscribe(v.voteTopic())
+	if err != nil {
+		identitySub.Cancel()
+		v.unregisterValidators()
+		return err
+	}
+	v.subscription = &voterSubscription{
+		idSub:   identitySub,
+		voteSub: voteSub,
+	}
+	v.scheduleClose()
+	v.running = true
+
+	v.handlers.Add(2)
+	go v.identitySubHandler(v.subject.Hash(), v.subscription.idSub)
+	go v.voteSubHandler(v.subscription.voteSub)
+	return nil
+}
+
+// Stop unsubscribes the topics and waits for the messages being handled
+func (v *Voter) Stop() {
+	v.lifecycleLock.Lock()
+	defer v.lifecycleLock.Unlock()
+	if !v.running {
+		return
+	}
+	v.running = false
+	if nil != v.closeTimer {
+		v.closeTimer.Stop()
+	}
+	v.subscription.idSub.Cancel()
+	v.subscription.voteSub.Cancel()
+	v.unregisterValidators()
+	v.handlers.Wait()
+}
+
+// Leave stops following the subject
+func (v *Voter) Leave() {
+	v.Stop()
+}
+
 // Open .
-func (v *Voter) Open() (yes, no int) {
+// return the number of votes of each option
+func (v *Voter) Open() []int {
 	return v.GetVotes(0)
 }
 
@@ -171,11 +395,15 @@ func (v *Voter) GetSubject() *subject.Subject {
 
 // GetIdentitySub ...
 func (v *Voter) GetIdentitySub() *pubsub.Subscription {
+	v.lifecycleLock.Lock()
+	defer v.lifecycleLock.Unlock()
 	return v.subscription.idSub
 }
 
 // GetVoteSub ...
 func (v *Voter) GetVoteSub() *pubsub.Subscription {
+	v.lifecycleLock.Lock()
+	defer v.lifecycleLock.Unlock()
 	return v.subscription.voteSub
 }
 
@@ -185,15 +413,36 @@ func (v *Voter) GetBallotMap() ba.Map {
 }
 
 // GetAllIdentities .
+// return the identities in the order of the tree
 func (v *Voter) GetAllIdentities() []id.Identity {
 	ids := v.GetAllIds()
 	hexArray := make([]id.Identity, len(ids))
-	for _, _id := range ids {
-		hexArray = append(hexArray, *id.NewIdentity(_id.Hex()))
+	for i, _id := range ids {
+		hexArray[i] = *id.NewIdentityFromBytes(_id.BigInt().Bytes())
 	}
 	return hexArray
 }
 
+// GetAdmission returns the signature of the proposer admitting the identity, empty if there isn't one
+func (v *Voter) GetAdmission(identity *id.Identity) string {
+	v.orderLock.Lock()
+	defer v.orderLock.Unlock()
+	return v.admissions[admissionKey(identity.PathElement())]
+}
+
+// GetAdmissions returns the admissions of the identities in the order of the tree
+func (v *Voter) GetAdmissions() []string {
+	v.orderLock.Lock()
+	defer v.orderLock.Unlock()
+
+	ids := v.GetAllIds()
+	admissions := make([]string, len(ids))
+	for i, e := range ids {
+		admissions[i] = v.admissions[admissionKey(e)]
+	}
+	return admissions
+}
+
 // GetIdentityPath .
 func (v *Voter) GetIdentityPath(identity id.Identity) ([]*id.IdPathElement, []int, *id.IdPathElement, error) {
 	elements, paths, root := v.GetIdentityTreePath(identity.PathElement())
@@ -207,40 +456,96 @@ func (v *Voter) GetIdentityPath(identity id.Identity) ([]*id.IdPathElement, []in
 // internals
 //
 
+// insertIdentity must be called with orderLock held
+func (v *Voter) insertIdentity(identity *id.Identity, admission string, publish bool) (int, error) {
+	if nil == identity {
+		return -1, fmt.Errorf("invalid input")
+	}
+	err := v.subject.VerifyAdmission(identity, admission)
+	if nil != err {
+		return -1, err
+	}
+	if v.revoked[admissionKey(identity.PathElement())] {
+		return -1, fmt.Errorf("admission of identity %v has been revoked", identity.String())
+	}
+	if at := v.expirations[admissionKey(identity.PathElement())]; 0 != at && at <= time.Now().Unix() {
+		return -1, fmt.Errorf("admission of identity %v has expired", identity.String())
+	}
+
+	prevRoot := v.GetRoot().Hex()
+	i, err := v.InsertIdc(identity.PathElement())
+	if nil != err {
+		return -1, err
+	}
+
+	if 0 != len(admission) {
+		v.admissions[admissionKey(identity.PathElement())] = admission
+	}
+	v.Cache.InsertIdentity(v.subject.Hash().Hex(), *identity)
+	v.Events.Publish(event.IdentityInserted, v.subject.HashHex().String(), map[string]interface{}{
+		"identity": identity.Hex(),
+		"index":    i,
+	})
+	v.publishRoot()
+
+	if publish {
+		return i, v.ps.Publish(v.identityTopic(), id.NewInsertion(identity, i, prevRoot, admission).Byte())
+	}
+	return i, nil
+}
+
+// identitySubHandler returns once the subscription is cancelled or the context is done
 func (v *Voter) identitySubHandler(subjectHash *subject.Hash, subscription *pubsub.Subscription) {
+	defer v.handlers.Done()
 	for {
 		m, err := subscription.Next(*v.Ctx)
 		if err != nil {
-			utils.LogErrorf("Failed to get identity subscription, %v", err.Error())
-			continue
+			utils.LogDebugf("identitySubHandler: %v", err)
+			return
 		}
 		utils.LogDebugf("identitySubHandler: Received message")
 
-		// TODO: Same logic as Register
-		identity := id.NewIdentityFromBytes(m.GetData())
-		if v.HasRegistered(identity.PathElement()) {
-			utils.LogInfof("Got registed id commitment, %v", identity.String())
+		// Published by ourselves, inserted already
+		if m.ReceivedFrom == v.Host.ID() {
 			continue
 		}
 
-		// TODO: Implement consensus for insert
-		_, err = v.InsertIdentity(identity, false)
+		ins, err := id.ParseInsertion(m.GetData())
 		if nil != err {
-			utils.LogWarningf("Insert id from pubsub error, %v", err.Error())
+			utils.LogWarningf("identitySubHandler: %v", err.Error())
 			continue
 		}
+		err = v.subject.VerifyAdmission(ins.GetIdentity(), ins.Admission)
+		if nil != err {
+			utils.LogWarningf("identitySubHandler: %v", err.Error())
+			continue
+		}
+		if ins.IsRequest() {
+			if v.isSequencer() {
+				v.sequence(ins)
+			}
+			continue
+		}
+		v.applyInsertion(ins, peer.ID(m.GetFrom()))
 	}
 }
 
+// voteSubHandler returns once the subscription is cancelled or the context is done
 func (v *Voter) voteSubHandler(sub *pubsub.Subscription) {
+	defer v.handlers.Done()
 	for {
 		m, err := sub.Next(*v.Ctx)
 		if err != nil {
-			utils.LogErrorf("Failed to get vote subscription, %v", err.Error())
-			continue
+			utils.LogDebugf("voteSubHandler: %v", err)
+			return
 		}
 		utils.LogDebugf("voteSubHandler: Received message")
 
+		// Published by ourselves, counted by Vote already
+		if m.ReceivedFrom == v.Host.ID() {
+			continue
+		}
+
 		// Get Ballot
 		ballotStr := string(m.GetData())
 		ballot, err := ba.NewBallot(ballotStr)
@@ -249,20 +554,101 @@ func (v *Voter) voteSubHandler(sub *pubsub.Subscription) {
 			continue
 		}
 
-		// TODO: Same logic as Vote
-		// Check membership
-		bigRoot, _ := big.NewInt(0).SetString(ballot.Root, 10)
-		if !v.IsMember(id.NewIdPathElement(id.NewTreeContent(bigRoot))) {
-			err = fmt.Errorf("Not a member")
-			utils.LogWarningf("voteSubHandler: %v, %v", err.Error(), bigRoot)
-			continue
-		}
-
-		// Update voteState
-		err = v.VoteWithProof(ballot, v.verificationKey)
+		// The proof has been verified by validateVote
+		err = v.InsertVerifiedVote(ballot)
 		if err != nil {
 			utils.LogWarningf("voteSubHandler: %v", err.Error())
+			v.publishBallot(event.BallotRejected, ballot.NullifierSignal(), err.Error())
 			continue
 		}
+		v.publishBallot(event.BallotAccepted, ballot.NullifierSignal(), "")
 	}
 }
+
+// scheduleClose closes the proposal when the deadline of the subject passes, must be called with lifecycleLock held
+func (v *Voter) scheduleClose() {
+	if 0 == v.subject.CloseAt {
+		return
+	}
+
+	d := time.Until(time.Unix(v.subject.CloseAt, 0))
+	v.closeTimer = time.AfterFunc(d, func() {
+		utils.LogInfof("Deadline passed, close subject %v", v.subject.HashHex().String())
+		v.Close(0)
+		v.Events.Publish(event.SubjectClosed, v.subject.HashHex().String(), map[string]interface{}{
+			"tally": v.Open(),
+		})
+	})
+}
+
+// restoreArchive inserts the identities and ballots of an archive
+func (v *Voter) restoreArchive(a *archive.Archive) error {
+	identities := make([]*id.Identity, len(a.Identities))
+	for i, h := range a.Identities {
+		identity, err := identityFromHex(h)
+		if nil != err {
+			return err
+		}
+		identities[i] = identity
+	}
+	if 0 != len(identities) {
+		_, err := v.OverwriteIds(identities, a.Admissions)
+		if nil != err {
+			return err
+		}
+	}
+
+	history := v.GetRootHistory()
+	if len(history) != len(a.Roots) {
+		return fmt.Errorf("root history doesn't match, %d/%d roots", len(history), len(a.Roots))
+	}
+	for i, r := range history {
+		root := utils.GetBigIntFromHexString(a.Roots[i])
+		if nil == root || 0 != r.BigInt().Cmp(root) {
+			return fmt.Errorf("root %d doesn't match (%v)/(%v)", i, r.Hex(), a.Roots[i])
+		}
+	}
+
+	for _, b := range a.Ballots {
+		err := v.Restore(b)
+		if nil != err {
+			return fmt.Errorf("invalid ballot %v, %v", b.NullifierSignal(), err)
+		}
+	}
+	return nil
+}
+
+func (v *Voter) publishRoot() {
+	v.Events.Publish(event.RootChanged, v.subject.HashHex().String(), map[string]interface{}{
+		"root":      v.GetRoot().Hex(),
+		"leafCount": v.GetLeafCount(),
+	})
+}
+
+func (v *Voter) publishBallot(t event.Type, nullifierHash string, reason string) {
+	data := map[string]interface{}{"nullifierHash": nullifierHash}
+	if 0 != len(reason) {
+		data["reason"] = reason
+	}
+	v.Events.Publish(t, v.subject.HashHex().String(), data)
+	if event.BallotAccepted == t {
+		v.Events.Publish(event.TallyChanged, v.subject.HashHex().String(), map[string]interface{}{
+			"tally": v.Open(),
+		})
+	}
+}
+
+// admissionKey normalizes an identity commitment, e.g. 0x01 and 0x1 share the same admission
+func admissionKey(e *id.IdPathElement) string {
+	return e.BigInt().Text(16)
+}
+
+func (v *Voter) checkPeriod() error {
+	switch v.subject.GetState(time.Now()) {
+	case subject.StatePending:
+		return fmt.Errorf("subject is not open for voting until %v", time.Unix(v.subject.OpenAt, 0))
+	case subject.StateClosed:
+		return fmt.Errorf("subject has been closed at %v", time.Unix(v.subject.CloseAt, 0))
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package voter
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/unitychain/zkvote-node/zkvote/common/event"
+	ba "github.com/unitychain/zkvote-node/zkvote/model/ballot"
+	id "github.com/unitychain/zkvote-node/zkvote/model/identity"
+)
+
+func TestArchive(t *testing.T) {
+	v := newTestVoter(t)
+	defer v.Host.Close()
+
+	for _, idc := range []string{"0x1234", "0x5678"} {
+		_, err := v.InsertIdentity(id.NewIdentity(idc), "", false)
+		assert.Nil(t, err)
+	}
+	a := v.Archive()
+	assert.Equal(t, 2, len(a.Identities))
+	assert.Equal(t, 3, len(a.Roots))
+	v.Leave()
+
+	restored, err := NewVoterFromArchive(a, v.ps, v.Context, "{}", v.score)
+	assert.Nil(t, err)
+	assert.Equal(t, a.Identities, restored.Archive().Identities)
+	assert.Equal(t, a.Roots, restored.Archive().Roots)
+	restored.Leave()
+
+	// the identities don't reproduce the roots
+	a.Roots[1], a.Roots[2] = a.Roots[2], a.Roots[1]
+	_, err = NewVoterFromArchive(a, v.ps, v.Context, "{}", v.score)
+	assert.NotNil(t, err)
+	a.Roots[1], a.Roots[2] = a.Roots[2], a.Roots[1]
+
+	// ballots are verified again
+	ballot, err := ba.NewBallot(`{"root":"` + restored.GetRootHistory()[2].String() + `","nullifier_hash":"2","proof":{},"public_signal":["` + restored.GetRootHistory()[2].String() + `","2","3","4"]}`)
+	assert.Nil(t, err)
+	a.Ballots = []*ba.Ballot{ballot}
+	_, err = NewVoterFromArchive(a, v.ps, v.Context, "{}", v.score)
+	assert.NotNil(t, err)
+}
+
+func TestEvents(t *testing.T) {
+	v := newTestVoter(t)
+	defer v.Host.Close()
+
+	_, sub, err := v.Events.Subscribe(v.GetSubject().HashHex().String(), v.Events.LastID())
+	assert.Nil(t, err)
+	defer sub.Close()
+
+	_, err = v.InsertIdentity(id.NewIdentity("0x1234"), "", false)
+	assert.Nil(t, err)
+	e := <-sub.C
+	assert.Equal(t, event.IdentityInserted, e.Type)
+	assert.Equal(t, 0, e.Data["index"])
+	e = <-sub.C
+	assert.Equal(t, event.RootChanged, e.Type)
+	assert.Equal(t, v.GetSyncState().Root, e.Data["root"])
+
+	ballot, err := ba.NewBallot(`{"root":"1","proof":{},"public_signal":["1","2","3","4"],"nullifier_hash":"2"}`)
+	assert.Nil(t, err)
+	assert.NotNil(t, v.Vote(ballot, true))
+	e = <-sub.C
+	assert.Equal(t, event.BallotRejected, e.Type)
+	assert.Equal(t, "Not a member", e.Data["reason"])
+}
+
+func TestStartStop(t *testing.T) {
+	v := newTestVoter(t)
+	defer v.Host.Close()
+
+	// the handlers return once the subscriptions are cancelled
+	v.Stop()
+	v.Stop()
+	assert.Equal(t, 0, len(v.ps.GetTopics()))
+
+	assert.Nil(t, v.Start())
+	assert.Equal(t, 2, len(v.ps.GetTopics()))
+	v.Leave()
+}
+
+func TestStartStop_Concurrent(t *testing.T) {
+	v := newTestVoter(t)
+	defer v.Host.Close()
+
+	// the topics are subscribed and the validators registered once
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			assert.Nil(t, v.Start())
+		}()
+		go func() {
+			defer wg.Done()
+			v.Stop()
+		}()
+	}
+	wg.Wait()
+
+	assert.Nil(t, v.Start())
+	assert.Equal(t, 2, len(v.ps.GetTopics()))
+	v.Stop()
+	assert.Equal(t, 0, len(v.ps.GetTopics()))
+}
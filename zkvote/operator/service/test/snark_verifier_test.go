@@ -1,13 +1,17 @@
 package test
 
 import (
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"math/big"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/unitychain/zkvote-node/zkvote/common/utils"
+	ba "github.com/unitychain/zkvote-node/zkvote/model/ballot"
 	. "github.com/unitychain/zkvote-node/zkvote/model/identity"
+	"github.com/unitychain/zkvote-node/zkvote/model/subject"
 	"github.com/unitychain/zkvote-node/zkvote/operator/service/manager/voter"
 )
 
@@ -24,15 +28,21 @@ var idCommitment = [...]string{
 	"15339060964594858963739198162689168732368415427742964439329199487013648959013",
 }
 
+// external nullifier of the vectors, which is the subject hash divided by 8
+const externalNullifier = "9695771177025341492834515246141576816221841749730679787621778614635855226700"
+
 func TestRegister(t *testing.T) {
 	id, _ := voter.NewIdentityPool()
 	// New proposal
-	p, _ := voter.NewProposal(id)
-	qIdx := p.Propose("this is a question.")
-	vkData, err := ioutil.ReadFile("../snark/verification_key.json")
+	p, err := voter.NewProposal(subject.DefaultOptions)
+	assert.Nil(t, err)
+	bigSubjectHash, _ := big.NewInt(0).SetString(externalNullifier, 10)
+	bigSubjectHash.Mul(bigSubjectHash, big.NewInt(8))
+	qIdx := p.ProposeSubject(subject.HashHex(utils.Remove0x(utils.GetHexStringFromBigInt(bigSubjectHash))))
+	vkData, err := ioutil.ReadFile("../../../../snark/verification_key.json")
 	assert.Nil(t, err)
 
-	y, n := 0, 0
+	var votes []int
 	for i, s := range idCommitment {
 		fmt.Println("")
 		idc, _ := big.NewInt(0).SetString(s, 10)
@@ -42,12 +52,50 @@ func TestRegister(t *testing.T) {
 		// submit proof
 		dat, err := ioutil.ReadFile(fmt.Sprintf("./vectors/vote%d.proof", i))
 		assert.Nil(t, err)
+		ballot, err := ba.NewBallot(string(dat))
+		assert.Nil(t, err)
 
-		err = p.VoteWithProof(qIdx, string(dat), string(vkData))
+		err = p.VoteWithProof(ballot, string(vkData))
 		assert.Nil(t, err)
 
-		y, n = p.GetVotes(qIdx)
+		votes = p.GetVotes(qIdx)
+	}
+	assert.Equal(t, []int{5, 5}, votes)
+}
+
+func TestReplay(t *testing.T) {
+	p, err := voter.NewProposal(subject.DefaultOptions)
+	assert.Nil(t, err)
+	bigSubjectHash, _ := big.NewInt(0).SetString(externalNullifier, 10)
+	bigSubjectHash.Mul(bigSubjectHash, big.NewInt(8))
+	qIdx := p.ProposeSubject(subject.HashHex(utils.Remove0x(utils.GetHexStringFromBigInt(bigSubjectHash))))
+	vkData, err := ioutil.ReadFile("../../../../snark/verification_key.json")
+	assert.Nil(t, err)
+	dat, err := ioutil.ReadFile("./vectors/vote0.proof")
+	assert.Nil(t, err)
+
+	ballot, err := ba.NewBallot(string(dat))
+	assert.Nil(t, err)
+	assert.Nil(t, p.VoteWithProof(ballot, string(vkData)))
+
+	// the nullifier hash which isn't proven can't make the same proof count again
+	for _, nullifierHash := range []string{"111", "0x1", "abc"} {
+		var b ba.Ballot
+		assert.Nil(t, json.Unmarshal(dat, &b))
+		b.NullifierHash = nullifierHash
+		forged, _ := json.Marshal(b)
+		_, err = ba.NewBallot(string(forged))
+		assert.NotNil(t, err)
 	}
-	assert.Equal(t, 5, y)
-	assert.Equal(t, 5, n)
+
+	// neither can a proven signal written differently
+	var b ba.Ballot
+	assert.Nil(t, json.Unmarshal(dat, &b))
+	b.NullifierHash = "0" + b.NullifierHash
+	b.PublicSignal[1] = "0" + b.PublicSignal[1]
+	padded, _ := json.Marshal(b)
+	ballot, err = ba.NewBallot(string(padded))
+	assert.Nil(t, err)
+	assert.NotNil(t, p.VoteWithProof(ballot, string(vkData)))
+	assert.Equal(t, []int{1, 0}, p.GetVotes(qIdx))
 }
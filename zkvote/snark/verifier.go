@@ -2,14 +2,21 @@ package snark
 
 import (
 	"encoding/json"
+	"time"
 
 	goSnarkVerifier "github.com/arnaucube/go-snark/externalVerif"
 	"github.com/arnaucube/go-snark/groth16"
 	goSnarkUtils "github.com/arnaucube/go-snark/utils"
 
+	"github.com/unitychain/zkvote-node/zkvote/common/metrics"
 	"github.com/unitychain/zkvote-node/zkvote/common/utils"
 )
 
+var (
+	verifyDuration = metrics.NewHistogram("zkvote_proof_verification_seconds", "Latency of proof verifications", metrics.DEFAULT_BUCKETS)
+	verifyFailures = metrics.NewCounter("zkvote_proof_verification_failures_total", "Number of proofs failing the verification")
+)
+
 // VerifyByFile : verify proof
 // func VerifyByFile(vkPath string, pfPath string) bool {
 
@@ -28,6 +35,16 @@ import (
 
 // Verify : verify proof
 func Verify(vkString string, proof *goSnarkVerifier.CircomProof, publicSignal []string) bool {
+	defer verifyDuration.Since(time.Now())
+
+	verified := verify(vkString, proof, publicSignal)
+	if !verified {
+		verifyFailures.Inc()
+	}
+	return verified
+}
+
+func verify(vkString string, proof *goSnarkVerifier.CircomProof, publicSignal []string) bool {
 
 	//
 	// verification key